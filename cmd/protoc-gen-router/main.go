@@ -93,6 +93,7 @@ func newServiceModel(g *protogen.GeneratedFile, service *protogen.Service, file
 	g.QualifiedGoIdent(protogen.GoIdent{GoImportPath: "google.golang.org/grpc"})
 	g.QualifiedGoIdent(protogen.GoIdent{GoImportPath: "github.com/smart-core-os/sc-golang/pkg/router"})
 	g.QualifiedGoIdent(protogen.GoIdent{GoImportPath: "fmt"})
+	g.QualifiedGoIdent(protogen.GoIdent{GoImportPath: "google.golang.org/protobuf/proto"})
 
 	model := ServiceModel{
 		Service: service,
@@ -112,6 +113,14 @@ func newServiceModel(g *protogen.GeneratedFile, service *protogen.Service, file
 			GoName:       "Register" + service.GoName + "Server",
 			GoImportPath: file.GoImportPath,
 		}),
+		NewClient: ident(g, protogen.GoIdent{
+			GoName:       "New" + service.GoName + "Client",
+			GoImportPath: file.GoImportPath,
+		}),
+		ServiceDesc: ident(g, protogen.GoIdent{
+			GoName:       service.GoName + "_ServiceDesc",
+			GoImportPath: file.GoImportPath,
+		}),
 		PackageName: pkg,
 		RouterName:  routerName,
 	}
@@ -127,6 +136,10 @@ func newServiceModel(g *protogen.GeneratedFile, service *protogen.Service, file
 				GoName:       "EOF",
 				GoImportPath: "io",
 			})
+			g.QualifiedGoIdent(protogen.GoIdent{
+				GoName:       "MD",
+				GoImportPath: "google.golang.org/grpc/metadata",
+			})
 		}
 
 		model.Methods = append(model.Methods, ServiceMethod{
@@ -136,6 +149,10 @@ func newServiceModel(g *protogen.GeneratedFile, service *protogen.Service, file
 				GoName:       fmt.Sprintf("%s_%sServer", service.GoName, method.GoName),
 				GoImportPath: file.GoImportPath,
 			}),
+			ClientStream: ident(g, protogen.GoIdent{
+				GoName:       fmt.Sprintf("%s_%sClient", service.GoName, method.GoName),
+				GoImportPath: file.GoImportPath,
+			}),
 			GoInput:  ident(g, method.Input.GoIdent),
 			GoOutput: ident(g, method.Output.GoIdent),
 		})
@@ -159,6 +176,8 @@ type ServiceModel struct {
 	ClientName              Ident
 	UnimplementedServerName Ident
 	RegisterService         Ident
+	NewClient               Ident
+	ServiceDesc             Ident
 
 	Methods []ServiceMethod
 }
@@ -168,6 +187,7 @@ type ServiceMethod struct {
 
 	Streaming    bool
 	ServerStream Ident
+	ClientStream Ident
 
 	GoInput  Ident
 	GoOutput Ident
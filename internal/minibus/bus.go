@@ -2,14 +2,71 @@ package minibus
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 )
 
+// ErrTooManyListeners is returned by TryListen when the bus already has MaxListeners listeners.
+var ErrTooManyListeners = errors.New("too many listeners")
+
 type Bus struct {
+	// MaxListeners limits the number of active listeners, zero means no limit.
+	MaxListeners int
+	// SendTimeout, if positive, disconnects listeners that don't accept an event within this duration.
+	// Disconnected listeners have their chan closed.
+	SendTimeout time.Duration
+	// OnSlow, if not nil, is called when a listener hasn't accepted an event within SlowThreshold.
+	// OnSlow is called at most once per event and listener.
+	OnSlow        func(ListenerStats)
+	SlowThreshold time.Duration
+	// Clock, if not nil, is used to record listener stats and measure SendTimeout and SlowThreshold.
+	// If Clock also has an `At(time.Time) <-chan time.Time` method, like clock.Clock, it is used to wait, otherwise a
+	// time.Timer is used.
+	// Defaults to the time package.
+	Clock Clock
+
 	listenerM sync.RWMutex
 	listeners []*listener
 }
 
+// Clock provides the current time to a Bus.
+type Clock interface {
+	Now() time.Time
+}
+
+type timerClock interface {
+	At(t time.Time) <-chan time.Time
+}
+
+func (b *Bus) now() time.Time {
+	if b.Clock == nil {
+		return time.Now()
+	}
+	return b.Clock.Now()
+}
+
+// after returns a chan that emits once d has passed according to b.Clock, and a func to release any resources
+// associated with it.
+func (b *Bus) after(d time.Duration) (<-chan time.Time, func()) {
+	if tc, ok := b.Clock.(timerClock); ok {
+		return tc.At(b.Clock.Now().Add(d)), func() {}
+	}
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+// ListenerStats describes the state of a single listener.
+type ListenerStats struct {
+	Since    time.Time // when Listen was called
+	Sent     uint64    // the number of events accepted by the listener
+	LastSend time.Time // when the listener last accepted an event, zero if it hasn't accepted any
+	// Pending is the number of events waiting to be accepted by the listener.
+	Pending int
+	// BlockedSince is when the oldest pending event started waiting, zero if there are no pending events.
+	BlockedSince time.Time
+}
+
 func (b *Bus) Send(ctx context.Context, event any) (ok bool) {
 	// create a copy of the listeners so avoid holding the mutex a long time
 	var listeners []*listener
@@ -23,7 +80,7 @@ func (b *Bus) Send(ctx context.Context, event any) (ok bool) {
 
 	// send the event to each listener that's not closed
 	for _, l := range listeners {
-		ok, active := l.send(ctx, event)
+		ok, active := b.sendTo(ctx, l, event)
 		if !ok {
 			return false
 		}
@@ -40,10 +97,38 @@ func (b *Bus) Send(ctx context.Context, event any) (ok bool) {
 	return true
 }
 
+func (b *Bus) sendTo(ctx context.Context, l *listener, event any) (ok bool, active bool) {
+	if b.SendTimeout <= 0 && b.OnSlow == nil {
+		return l.send(ctx, event, nil, nil, nil)
+	}
+	var timeout, slow <-chan time.Time
+	if b.SendTimeout > 0 {
+		var stop func()
+		timeout, stop = b.after(b.SendTimeout)
+		defer stop()
+	}
+	if b.OnSlow != nil {
+		var stop func()
+		slow, stop = b.after(b.SlowThreshold)
+		defer stop()
+	}
+	ok, active = l.send(ctx, event, timeout, slow, func() {
+		b.OnSlow(l.stats())
+	})
+	if !active {
+		// timed out, disconnect the listener
+		l.kill()
+	}
+	return ok, active
+}
+
 func (b *Bus) collect() {
 	b.listenerM.Lock()
 	defer b.listenerM.Unlock()
+	b.collectLocked()
+}
 
+func (b *Bus) collectLocked() {
 	var activeListeners []*listener
 	for _, l := range b.listeners {
 		if l.alive() {
@@ -54,53 +139,191 @@ func (b *Bus) collect() {
 	b.listeners = activeListeners
 }
 
+// Listen returns a chan that emits all events sent on the bus until ctx is done.
+// If the bus already has MaxListeners listeners, the returned chan is closed.
+// Use TryListen to tell a rejected listener apart from one whose chan was closed for other reasons.
 func (b *Bus) Listen(ctx context.Context) <-chan any {
+	ch, err := b.TryListen(ctx)
+	if err != nil {
+		closed := make(chan any)
+		close(closed)
+		return closed
+	}
+	return ch
+}
+
+// TryListen is like Listen but returns ErrTooManyListeners if the bus already has MaxListeners listeners.
+func (b *Bus) TryListen(ctx context.Context) (<-chan any, error) {
+	return b.listen(ctx, false)
+}
+
+// ListenExempt is like Listen but the listener is not counted towards MaxListeners and is never rejected.
+// Use for listeners internal to the owner of the bus.
+func (b *Bus) ListenExempt(ctx context.Context) <-chan any {
+	ch, _ := b.listen(ctx, true)
+	return ch
+}
+
+func (b *Bus) listen(ctx context.Context, exempt bool) (<-chan any, error) {
 	ch := make(chan any)
 
 	l := &listener{
-		ch:  ch,
-		ctx: ctx,
+		ch:     ch,
+		ctx:    ctx,
+		killed: make(chan struct{}),
+		now:    b.now,
+		since:  b.now(),
+		exempt: exempt,
 	}
 
-	go func() {
-		<-ctx.Done()
-		l.stop()
-	}()
-
 	// store the listener
 	b.listenerM.Lock()
 	defer b.listenerM.Unlock()
+	if !exempt && b.MaxListeners > 0 && b.countLocked() >= b.MaxListeners {
+		b.collectLocked()
+		if b.countLocked() >= b.MaxListeners {
+			return nil, ErrTooManyListeners
+		}
+	}
 	b.listeners = append(b.listeners, l)
 
-	return ch
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-l.killed:
+		}
+		l.stop()
+	}()
+
+	return ch, nil
+}
+
+// countLocked returns the number of listeners that count towards MaxListeners.
+// b.listenerM must be held.
+func (b *Bus) countLocked() int {
+	n := 0
+	for _, l := range b.listeners {
+		if !l.exempt {
+			n++
+		}
+	}
+	return n
+}
+
+// Listeners returns the stats of all active listeners, oldest first.
+func (b *Bus) Listeners() []ListenerStats {
+	b.listenerM.RLock()
+	defer b.listenerM.RUnlock()
+	var res []ListenerStats
+	for _, l := range b.listeners {
+		if l.alive() {
+			res = append(res, l.stats())
+		}
+	}
+	return res
 }
 
 type listener struct {
 	m   sync.RWMutex
 	ch  chan any
 	ctx context.Context
+	// exempt listeners don't count towards Bus.MaxListeners, see ListenExempt
+	exempt bool
+
+	killOnce sync.Once
+	killed   chan struct{} // closed when the listener is disconnected by the bus
+
+	now     func() time.Time // see Bus.Clock
+	since   time.Time
+	statsM  sync.Mutex
+	sent    uint64
+	last    time.Time
+	pending []time.Time // when each pending send started
 }
 
-func (l *listener) send(ctx context.Context, event any) (ok bool, active bool) {
+// send sends event to l.
+// If timeout emits before the event is accepted, active will be false.
+// If slow emits before the event is accepted, onSlow is called.
+func (l *listener) send(ctx context.Context, event any, timeout, slow <-chan time.Time, onSlow func()) (ok bool, active bool) {
 	l.m.RLock()
 	defer l.m.RUnlock()
 
-	select {
-	case <-ctx.Done():
-		// send context cancelled
-		return false, true
+	start := l.begin()
+	for {
+		select {
+		case <-ctx.Done():
+			// send context cancelled
+			l.end(start, false)
+			return false, true
 
-	case <-l.ctx.Done():
-		// listen context cancelled
-		// this is considered a success even though the message is not sent
-		return true, false
+		case <-l.ctx.Done():
+			// listen context cancelled
+			// this is considered a success even though the message is not sent
+			l.end(start, false)
+			return true, false
 
-	case l.ch <- event:
-		// event sent successfully
-		return true, true
+		case <-l.killed:
+			l.end(start, false)
+			return true, false
+
+		case <-timeout:
+			l.end(start, false)
+			return true, false
+
+		case <-slow:
+			slow = nil
+			onSlow()
+
+		case l.ch <- event:
+			// event sent successfully
+			l.end(start, true)
+			return true, true
+		}
 	}
 }
 
+// begin records that a send has started, returning the time it started.
+func (l *listener) begin() time.Time {
+	now := l.now()
+	l.statsM.Lock()
+	defer l.statsM.Unlock()
+	l.pending = append(l.pending, now)
+	return now
+}
+
+// end records that a send started at start has finished.
+func (l *listener) end(start time.Time, sent bool) {
+	now := l.now()
+	l.statsM.Lock()
+	defer l.statsM.Unlock()
+	for i, p := range l.pending {
+		if p == start {
+			l.pending = append(l.pending[:i], l.pending[i+1:]...)
+			break
+		}
+	}
+	if sent {
+		l.sent++
+		l.last = now
+	}
+}
+
+func (l *listener) stats() ListenerStats {
+	l.statsM.Lock()
+	defer l.statsM.Unlock()
+	s := ListenerStats{Since: l.since, Sent: l.sent, LastSend: l.last, Pending: len(l.pending)}
+	if len(l.pending) > 0 {
+		s.BlockedSince = l.pending[0]
+	}
+	return s
+}
+
+func (l *listener) kill() {
+	l.killOnce.Do(func() {
+		close(l.killed)
+	})
+}
+
 func (l *listener) stop() {
 	l.m.Lock()
 	defer l.m.Unlock()
@@ -111,5 +334,13 @@ func (l *listener) stop() {
 }
 
 func (l *listener) alive() bool {
-	return l.ctx.Err() == nil
+	if l.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-l.killed:
+		return false
+	default:
+		return true
+	}
 }
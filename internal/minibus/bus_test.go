@@ -2,8 +2,10 @@ package minibus
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestBus_OneToMany(t *testing.T) {
@@ -53,3 +55,35 @@ func collector(source <-chan any) (collected []any) {
 	}
 	return
 }
+
+func TestBus_SendTimeout(t *testing.T) {
+	bus := Bus{SendTimeout: 10 * time.Millisecond}
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	ch := bus.Listen(ctx)
+
+	if !bus.Send(context.Background(), 1) {
+		t.Fatalf("Send failed")
+	}
+	if got := len(bus.Listeners()); got != 0 {
+		t.Fatalf("want listener disconnected, got %d listeners", got)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatalf("want closed chan")
+	}
+}
+
+func TestBus_MaxListeners(t *testing.T) {
+	bus := Bus{MaxListeners: 1}
+	ctx, stop := context.WithCancel(context.Background())
+	if _, err := bus.TryListen(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bus.TryListen(context.Background()); !errors.Is(err, ErrTooManyListeners) {
+		t.Fatalf("want ErrTooManyListeners, got %v", err)
+	}
+	stop()
+	if _, err := bus.TryListen(context.Background()); err != nil {
+		t.Fatalf("want listen after stop, got %v", err)
+	}
+}
@@ -0,0 +1,434 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tanema/gween/ease"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/smart-core-os/sc-api/go/types"
+	"github.com/smart-core-os/sc-golang/pkg/time/clock"
+)
+
+// AnimationOption configures an animation started by Animate.
+type AnimationOption func(*animationConfig)
+
+type animationConfig struct {
+	duration   time.Duration
+	easing     ease.TweenFunc
+	tick       time.Duration
+	clock      clock.Clock
+	targetPath string
+	tweenPath  string
+	targetFunc func(current, target float64) float64
+}
+
+// DefaultAnimationOptions are applied before any options passed to Animate.
+var DefaultAnimationOptions = []AnimationOption{
+	WithAnimationDuration(time.Second),
+	WithAnimationEasing(ease.Linear),
+	WithAnimationTick(time.Second / 15),
+}
+
+// WithAnimationDuration sets how long the animation takes to reach its target.
+// A duration of zero or less sets the target immediately.
+func WithAnimationDuration(d time.Duration) AnimationOption {
+	return func(ac *animationConfig) {
+		ac.duration = d
+	}
+}
+
+// WithAnimationEasing sets the easing function used to compute intermediate values.
+func WithAnimationEasing(easing ease.TweenFunc) AnimationOption {
+	return func(ac *animationConfig) {
+		ac.easing = easing
+	}
+}
+
+// WithAnimationTick sets the time between intermediate writes.
+func WithAnimationTick(tick time.Duration) AnimationOption {
+	return func(ac *animationConfig) {
+		ac.tick = tick
+	}
+}
+
+// WithAnimationClock sets the clock used to time the animation.
+// Defaults to the Values Clock if it implements clock.Clock, otherwise clock.Real.
+func WithAnimationClock(c clock.Clock) AnimationOption {
+	return func(ac *animationConfig) {
+		ac.clock = c
+	}
+}
+
+// WithAnimationTargetPath records the target of the animation in the numeric field at path while the animation runs.
+// For example "target_level_percent" for a traits.Brightness.
+// The field is cleared when the animation completes or is stopped.
+func WithAnimationTargetPath(path string) AnimationOption {
+	return func(ac *animationConfig) {
+		ac.targetPath = path
+	}
+}
+
+// WithAnimationTweenPath records the progress of the animation in the types.Tween field at path while the animation
+// runs.
+// For example "brightness_tween" for a traits.Brightness.
+// The tweens total_duration is set when the animation starts, and its progress updated with each write.
+// The field is cleared when the animation completes or is stopped.
+func WithAnimationTweenPath(path string) AnimationOption {
+	return func(ac *animationConfig) {
+		ac.tweenPath = path
+	}
+}
+
+// WithAnimationTargetFunc computes the target of the animation from the current value of the field, atomically with
+// the animations first write.
+// fn is called with the fields current value and the target passed to Animate, it returns the target to animate to.
+// Use this for relative changes, where reading the current value separately could miss a concurrent write.
+func WithAnimationTargetFunc(fn func(current, target float64) float64) AnimationOption {
+	return func(ac *animationConfig) {
+		ac.targetFunc = fn
+	}
+}
+
+// Animation is a running transition of a field of a Value, see Animate.
+type Animation struct {
+	start  proto.Message
+	stop   context.CancelFunc
+	done   chan struct{}
+	err    error
+	target float64
+}
+
+// Animate moves the numeric field at path of v from its current value to target.
+// Intermediate values are written every tick, computed using the configured easing, until the duration has elapsed.
+//
+// Each write expects the value to be unchanged since the last write made by the animation, see WithExpectedValue.
+// If any other write changes v the animation stops without making further changes, Err will return TweenStopped.
+// If ctx is done, or Stop is called, the animation stops leaving the field at its current value.
+//
+// Animate returns once the animation has started, after writing any target and tween fields.
+// Returns an error if path does not name a numeric field, or the first write fails.
+// Returns a FailedPrecondition error if v has never been set, there is nothing to animate from.
+func Animate(ctx context.Context, v *Value, path string, target float64, opts ...AnimationOption) (*Animation, error) {
+	ac := &animationConfig{}
+	for _, opt := range DefaultAnimationOptions {
+		opt(ac)
+	}
+	if c, ok := v.clock.(clock.Clock); ok {
+		ac.clock = c
+	} else {
+		ac.clock = clock.Real()
+	}
+	for _, opt := range opts {
+		opt(ac)
+	}
+
+	current := v.Get()
+	if current == nil {
+		return nil, status.Error(codes.FailedPrecondition, "value has not been set")
+	}
+	fieldPath := strings.Split(path, ".")
+	if _, err := numberAt(current.ProtoReflect(), fieldPath); err != nil {
+		return nil, err
+	}
+
+	if ac.duration <= 0 {
+		update := current.ProtoReflect().New()
+		if err := setNumberAt(update, fieldPath, target); err != nil {
+			return nil, err
+		}
+		writeOpts := ac.finalWriteOptions(path)
+		var targetErr error
+		if ac.targetFunc != nil {
+			writeOpts = append(writeOpts, InterceptBefore(func(old, change proto.Message) {
+				var n float64
+				if n, targetErr = numberAt(old.ProtoReflect(), fieldPath); targetErr != nil {
+					return
+				}
+				target = ac.targetFunc(n, target)
+				targetErr = setNumberAt(change.ProtoReflect(), fieldPath, target)
+			}))
+		}
+		final, err := v.Set(update.Interface(), writeOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if targetErr != nil {
+			return nil, targetErr
+		}
+		a := &Animation{start: final, stop: func() {}, done: make(chan struct{}), target: target}
+		close(a.done)
+		return a, nil
+	}
+
+	// record the target and tween, capturing the starting value atomically with the write
+	var (
+		start    float64
+		startErr error
+		update   = current.ProtoReflect().New()
+		paths    []string
+	)
+	if ac.targetPath != "" {
+		if err := setNumberAt(update, strings.Split(ac.targetPath, "."), target); err != nil {
+			return nil, err
+		}
+		paths = append(paths, ac.targetPath)
+	}
+	if ac.tweenPath != "" {
+		tween := &types.Tween{TotalDuration: durationpb.New(ac.duration)}
+		if err := setMessageAt(update, strings.Split(ac.tweenPath, "."), tween); err != nil {
+			return nil, err
+		}
+		paths = append(paths, ac.tweenPath)
+	}
+	if ac.targetFunc != nil && len(paths) == 0 {
+		// write the field back unchanged so the target is computed atomically with reading the start value
+		paths = append(paths, path)
+	}
+	started := current
+	if len(paths) > 0 {
+		var err error
+		started, err = v.Set(update.Interface(),
+			WithUpdatePaths(paths...),
+			WithMoreWritablePaths(paths...),
+			InterceptBefore(func(old, change proto.Message) {
+				if start, startErr = numberAt(old.ProtoReflect(), fieldPath); startErr != nil {
+					return
+				}
+				if ac.targetFunc == nil {
+					return
+				}
+				target = ac.targetFunc(start, target)
+				if ac.targetPath != "" {
+					if startErr = setNumberAt(change.ProtoReflect(), strings.Split(ac.targetPath, "."), target); startErr != nil {
+						return
+					}
+				}
+				if len(paths) == 1 && paths[0] == path {
+					startErr = setNumberAt(change.ProtoReflect(), fieldPath, start)
+				}
+			}),
+		)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		start, startErr = numberAt(current.ProtoReflect(), fieldPath)
+	}
+	if startErr != nil {
+		return nil, startErr
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	a := &Animation{start: started, stop: stop, done: make(chan struct{}), target: target}
+	go func() {
+		defer close(a.done)
+		defer stop()
+		a.err = a.run(ctx, v, ac, path, start, started)
+	}()
+	return a, nil
+}
+
+// Started returns the value of the resource once the animation started.
+// If the animation had no duration, this is the final value.
+func (a *Animation) Started() proto.Message {
+	return a.start
+}
+
+// Target returns the value the animation is moving towards.
+func (a *Animation) Target() float64 {
+	return a.target
+}
+
+// Done returns a chan that is closed when the animation stops.
+func (a *Animation) Done() <-chan struct{} {
+	return a.done
+}
+
+// Err returns why the animation stopped.
+// Nil if the animation reached its target, TweenStopped if another write changed the value, or the context error
+// if the animation was stopped.
+// Returns nil while the animation is running.
+func (a *Animation) Err() error {
+	select {
+	case <-a.done:
+		return a.err
+	default:
+		return nil
+	}
+}
+
+// Stop stops the animation, leaving the field at its current value, and waits for it to stop.
+func (a *Animation) Stop() {
+	a.stop()
+	<-a.done
+}
+
+func (a *Animation) run(ctx context.Context, v *Value, ac *animationConfig, path string, start float64, last proto.Message) error {
+	fieldPath := strings.Split(path, ".")
+	startTime := ac.clock.Now()
+	end := ac.clock.At(startTime.Add(ac.duration))
+	ticker := ac.clock.Every(ac.tick)
+	defer ticker.Stop()
+
+	write := func(value proto.Message, opts ...WriteOption) error {
+		var err error
+		last, err = v.Set(value, append(opts, WithExpectedValue(last))...)
+		if errors.Is(err, ExpectedValuePreconditionFailed) {
+			return TweenStopped // somebody else changed the value
+		}
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if ac.targetPath != "" || ac.tweenPath != "" {
+				if err := write(last.ProtoReflect().New().Interface(), ac.finalWriteOptions()...); err != nil {
+					return err
+				}
+			}
+			return ctx.Err()
+		case _, ok := <-end:
+			if !ok {
+				return TweenStopped // the clock stopped
+			}
+			update := last.ProtoReflect().New()
+			if err := setNumberAt(update, fieldPath, a.target); err != nil {
+				return err
+			}
+			return write(update.Interface(), ac.finalWriteOptions(path)...)
+		case now, ok := <-ticker.C():
+			if !ok {
+				return TweenStopped
+			}
+			playTime := now.Sub(startTime)
+			if playTime >= ac.duration {
+				continue // end will fire
+			}
+			t, d := float32(playTime.Milliseconds()), float32(ac.duration.Milliseconds())
+			value := ac.easing(t, float32(start), float32(a.target-start), d)
+
+			update := last.ProtoReflect().New()
+			if err := setNumberAt(update, fieldPath, float64(value)); err != nil {
+				return err
+			}
+			paths := []string{path}
+			if ac.tweenPath != "" {
+				// progress is based on time, not value, which leaves room for easing
+				tween := &types.Tween{Progress: 100 * t / d}
+				if err := setMessageAt(update, strings.Split(ac.tweenPath, "."), tween); err != nil {
+					return err
+				}
+				paths = append(paths, ac.tweenPath+".progress")
+			}
+			if err := write(update.Interface(), WithUpdatePaths(paths...), WithMoreWritablePaths(paths...)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// finalWriteOptions returns write options that write paths and clear the target and tween fields.
+// The target and tween fields are cleared by including them in the update mask, they must be unset in the update.
+func (ac *animationConfig) finalWriteOptions(paths ...string) []WriteOption {
+	if ac.targetPath != "" {
+		paths = append(paths, ac.targetPath)
+	}
+	if ac.tweenPath != "" {
+		paths = append(paths, ac.tweenPath)
+	}
+	return []WriteOption{
+		WithUpdatePaths(paths...),
+		WithMoreWritablePaths(paths...),
+	}
+}
+
+// fieldAt returns the message containing the last field of path, and that field.
+// If mutable, intermediate messages are created as needed.
+func fieldAt(msg protoreflect.Message, path []string, mutable bool) (protoreflect.Message, protoreflect.FieldDescriptor, error) {
+	for i, name := range path {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, nil, fmt.Errorf("%v: unknown field %q", msg.Descriptor().FullName(), strings.Join(path[:i+1], "."))
+		}
+		if i == len(path)-1 {
+			return msg, fd, nil
+		}
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return nil, nil, fmt.Errorf("%v: field %q is not a message", msg.Descriptor().FullName(), strings.Join(path[:i+1], "."))
+		}
+		if mutable {
+			msg = msg.Mutable(fd).Message()
+		} else {
+			msg = msg.Get(fd).Message()
+		}
+	}
+	return nil, nil, errors.New("empty field path")
+}
+
+func numberAt(msg protoreflect.Message, path []string) (float64, error) {
+	parent, fd, err := fieldAt(msg, path, false)
+	if err != nil {
+		return 0, err
+	}
+	if fd.IsList() || fd.IsMap() {
+		return 0, fmt.Errorf("field %q is not a number", strings.Join(path, "."))
+	}
+	n, ok := numericValue(fd, parent.Get(fd))
+	if !ok {
+		return 0, fmt.Errorf("field %q is not a number", strings.Join(path, "."))
+	}
+	return n, nil
+}
+
+func setNumberAt(msg protoreflect.Message, path []string, n float64) error {
+	parent, fd, err := fieldAt(msg, path, true)
+	if err != nil {
+		return err
+	}
+	if fd.IsList() || fd.IsMap() {
+		return fmt.Errorf("field %q is not a number", strings.Join(path, "."))
+	}
+	var v protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v = protoreflect.ValueOfInt32(int32(math.Round(n)))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v = protoreflect.ValueOfInt64(int64(math.Round(n)))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v = protoreflect.ValueOfUint32(uint32(math.Round(math.Max(n, 0))))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v = protoreflect.ValueOfUint64(uint64(math.Round(math.Max(n, 0))))
+	case protoreflect.FloatKind:
+		v = protoreflect.ValueOfFloat32(float32(n))
+	case protoreflect.DoubleKind:
+		v = protoreflect.ValueOfFloat64(n)
+	default:
+		return fmt.Errorf("field %q is not a number", strings.Join(path, "."))
+	}
+	parent.Set(fd, v)
+	return nil
+}
+
+func setMessageAt(msg protoreflect.Message, path []string, value proto.Message) error {
+	parent, fd, err := fieldAt(msg, path, true)
+	if err != nil {
+		return err
+	}
+	if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() ||
+		fd.Message().FullName() != value.ProtoReflect().Descriptor().FullName() {
+		return fmt.Errorf("field %q is not a %v", strings.Join(path, "."), value.ProtoReflect().Descriptor().FullName())
+	}
+	parent.Set(fd, protoreflect.ValueOfMessage(value.ProtoReflect()))
+	return nil
+}
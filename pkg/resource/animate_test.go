@@ -0,0 +1,209 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestAnimate(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.Brightness{}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := v.Pull(ctx, WithUpdatesOnly(true), WithBackpressure(true))
+
+	a, err := Animate(context.Background(), v, "level_percent", 50,
+		WithAnimationDuration(100*time.Millisecond),
+		WithAnimationTick(10*time.Millisecond),
+		WithAnimationTargetPath("target_level_percent"),
+		WithAnimationTweenPath("brightness_tween"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStarted := &traits.Brightness{
+		TargetLevelPercent: 50,
+		BrightnessTween:    &types.Tween{TotalDuration: durationpb.New(100 * time.Millisecond)},
+	}
+	if diff := cmp.Diff(wantStarted, a.Started(), protocmp.Transform()); diff != "" {
+		t.Fatalf("started (-want,+got)\n%s", diff)
+	}
+	var got []*traits.Brightness
+	for {
+		got = append(got, waitForChan(t, changes, time.Second).Value.(*traits.Brightness))
+		if got[len(got)-1].BrightnessTween == nil {
+			break // the tween fields are cleared by the last write
+		}
+	}
+	waitForChan(t, a.Done(), time.Second)
+	if err := a.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) < 3 {
+		t.Fatalf("want intermediate values, got %v", got)
+	}
+	for i := 1; i < len(got)-1; i++ {
+		prev, next := got[i-1], got[i]
+		if next.LevelPercent <= prev.LevelPercent {
+			t.Fatalf("[%d] level moved backwards %v -> %v", i, prev.LevelPercent, next.LevelPercent)
+		}
+		if next.BrightnessTween.GetProgress() < prev.BrightnessTween.GetProgress() {
+			t.Fatalf("[%d] progress moved backwards %v -> %v", i, prev.BrightnessTween, next.BrightnessTween)
+		}
+		if next.TargetLevelPercent != 50 {
+			t.Fatalf("[%d] want target 50, got %v", i, next.TargetLevelPercent)
+		}
+	}
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 50}, got[len(got)-1], protocmp.Transform()); diff != "" {
+		t.Fatalf("final value (-want,+got)\n%s", diff)
+	}
+}
+
+func TestAnimate_interrupted(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.Brightness{}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := v.Pull(ctx, WithUpdatesOnly(true))
+
+	a, err := Animate(context.Background(), v, "level_percent", 100,
+		WithAnimationDuration(time.Minute),
+		WithAnimationTick(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForChan(t, changes, time.Second) // the animation has made a change
+	if _, err := v.Set(&traits.Brightness{LevelPercent: 10}); err != nil {
+		t.Fatal(err)
+	}
+	waitForChan(t, a.Done(), time.Second)
+	if err := a.Err(); !errors.Is(err, TweenStopped) {
+		t.Fatalf("want TweenStopped, got %v", err)
+	}
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 10}, v.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("value (-want,+got)\n%s", diff)
+	}
+}
+
+func TestAnimation_Stop(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.Brightness{}))
+	a, err := Animate(context.Background(), v, "level_percent", 100,
+		WithAnimationDuration(time.Minute),
+		WithAnimationTargetPath("target_level_percent"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Get().(*traits.Brightness).TargetLevelPercent; got != 100 {
+		t.Fatalf("want target 100 while running, got %v", got)
+	}
+	a.Stop()
+	if err := a.Err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	if got := v.Get().(*traits.Brightness); got.TargetLevelPercent != 0 || got.LevelPercent >= 100 {
+		t.Fatalf("want target cleared and level unchanged, got %v", got)
+	}
+}
+
+func TestAnimate_immediate(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.Brightness{TargetLevelPercent: 20}))
+	a, err := Animate(context.Background(), v, "level_percent", 40,
+		WithAnimationDuration(0),
+		WithAnimationTargetPath("target_level_percent"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForChan(t, a.Done(), time.Second)
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 40}, v.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("value (-want,+got)\n%s", diff)
+	}
+}
+
+func TestWithAnimationTargetFunc(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.Brightness{LevelPercent: 30}))
+	delta := WithAnimationTargetFunc(func(current, target float64) float64 { return current + target })
+
+	a, err := Animate(context.Background(), v, "level_percent", 10, delta, WithAnimationDuration(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Target(); got != 40 {
+		t.Fatalf("immediate target want 40, got %v", got)
+	}
+
+	a, err = Animate(context.Background(), v, "level_percent", 20, delta,
+		WithAnimationDuration(50*time.Millisecond),
+		WithAnimationTick(10*time.Millisecond),
+		WithAnimationTargetPath("target_level_percent"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 40, TargetLevelPercent: 60}, a.Started(), protocmp.Transform()); diff != "" {
+		t.Fatalf("started (-want,+got)\n%s", diff)
+	}
+	waitForChan(t, a.Done(), time.Second)
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 60}, v.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("final value (-want,+got)\n%s", diff)
+	}
+}
+
+func TestAnimate_badPath(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.Brightness{}))
+	for _, path := range []string{"nope", "preset", "preset.name", "level_percent.value"} {
+		if _, err := Animate(context.Background(), v, path, 1); err == nil {
+			t.Errorf("%q: want error", path)
+		}
+	}
+}
+
+func TestAnimate_unset(t *testing.T) {
+	v := NewValue()
+	_, err := Animate(context.Background(), v, "level_percent", 1)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("want FailedPrecondition, got %v", err)
+	}
+}
+
+func TestAnimate_clock(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	v := NewValue(WithClock(clock), WithInitialValue(&traits.Brightness{}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := v.Pull(ctx, WithUpdatesOnly(true))
+
+	a, err := Animate(context.Background(), v, "level_percent", 100,
+		WithAnimationDuration(10*time.Second),
+		WithAnimationTick(time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < 10; i++ {
+		clock.waitForTimer(t, time.Unix(int64(i), 0))
+		clock.advance(time.Second)
+		change := waitForChan(t, changes, time.Second)
+		if got, want := change.Value.(*traits.Brightness).LevelPercent, float32(i*10); got != want {
+			t.Fatalf("at %ds want %v, got %v", i, want, got)
+		}
+	}
+	clock.waitForTimer(t, time.Unix(10, 0))
+	clock.advance(time.Second)
+	waitForChan(t, a.Done(), time.Second)
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 100}, v.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("final value (-want,+got)\n%s", diff)
+	}
+}
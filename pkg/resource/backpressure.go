@@ -9,21 +9,131 @@ import (
 // mergeCollectionExcess acts on a chan of *CollectionChange combining changes with the same key to maintain the
 // semantics without needing to emit every events.
 // This will use memory proportional to one change for each id that has not been emitted yet.
-func mergeCollectionExcess(in <-chan any) <-chan any {
+//
+// Changes with a Seq on or before after are discarded, they have already been seen by the consumer.
+// Emitted changes have their Seq adjusted so resuming from it will not miss any changes that are still waiting to be
+// emitted, see WithResumeAfter.
+//
+// If prepare is not nil it is called with each change before merging, returning the change to merge or false to drop
+// it. If equivalence is not nil, merged changes whose old and new values are equivalent are dropped.
+//
+// Groups of changes, sent as a []*CollectionChange, are emitted as individual changes with BatchEnd set on the last
+// that is emitted. If that change is later merged away BatchEnd moves to the last change waiting to be emitted, or the
+// next change if none are waiting.
+func mergeCollectionExcess(in <-chan any, after uint64, prepare func(*CollectionChange) (*CollectionChange, bool), equivalence Comparer) <-chan any {
 	out := make(chan any)
 	go func() {
 		defer close(out)
 
 		messages := make(map[string]CollectionChange)
 		var queue list.List // of string, Front is which id to send next
+		// byFirst orders waiting ids by the Seq of the first change merged into them, Front has the lowest Seq.
+		// Ids are added in the order changes arrive, which is Seq order, so no sorting is needed.
+		var byFirst list.List // of mergedSeq
+		firsts := make(map[string]*list.Element)
+		// pendingBatchEnd is true if a change with BatchEnd was dropped when nothing else was waiting to be sent
+		var pendingBatchEnd bool
 		event := func() any {
 			if queue.Len() == 0 {
 				return nil
 			}
 			id := queue.Front().Value.(string)
 			change := messages[id]
+			// don't let consumers resume beyond changes we haven't sent yet
+			for e := byFirst.Front(); e != nil; e = e.Next() {
+				first := e.Value.(mergedSeq)
+				if first.id == id {
+					continue
+				}
+				if first.seq > 0 && first.seq-1 < change.Seq {
+					change.Seq = first.seq - 1
+				}
+				break
+			}
 			return &change
 		}
+		forget := func(id string) {
+			delete(messages, id)
+			if e, ok := firsts[id]; ok {
+				byFirst.Remove(e)
+				delete(firsts, id)
+			}
+		}
+		// endBatch moves BatchEnd from a dropped change to the change that will be emitted last
+		endBatch := func() {
+			if queue.Len() == 0 {
+				pendingBatchEnd = true
+				return
+			}
+			id := queue.Back().Value.(string)
+			m := messages[id]
+			m.BatchEnd = true
+			messages[id] = m
+		}
+		keep := func(change *CollectionChange) (*CollectionChange, bool) {
+			if change.Seq != 0 && change.Seq <= after {
+				return nil, false
+			}
+			if prepare == nil {
+				return change, true
+			}
+			return prepare(change)
+		}
+
+		push := func(newMessage CollectionChange) {
+			if pendingBatchEnd {
+				newMessage.BatchEnd = true
+				pendingBatchEnd = false
+			}
+			oldMessage, hasOld := messages[newMessage.Id]
+			id := newMessage.Id
+			if hasOld {
+				batchEnd := oldMessage.BatchEnd || newMessage.BatchEnd
+				var send bool
+				newMessage, send = mergeChanges(oldMessage, newMessage)
+				if send && equivalence != nil && newMessage.ChangeType == types.ChangeType_UPDATE &&
+					equivalence.Compare(newMessage.OldValue, newMessage.NewValue) {
+					send = false
+				}
+				for n := queue.Front(); n != nil; n = n.Next() {
+					if n.Value.(string) == id {
+						queue.Remove(n)
+						break
+					}
+				}
+				if !send {
+					forget(id)
+					if batchEnd {
+						endBatch()
+					}
+					return
+				}
+			} else {
+				firsts[id] = byFirst.PushBack(mergedSeq{id: id, seq: newMessage.Seq})
+			}
+
+			messages[id] = newMessage
+			queue.PushBack(id)
+		}
+		receive := func(newAny any) {
+			switch newAny := newAny.(type) {
+			case *CollectionChange:
+				if change, ok := keep(newAny); ok {
+					push(*change)
+				}
+			case []*CollectionChange: // a group of changes, see WithGroupedChanges
+				var group []CollectionChange
+				for _, change := range newAny {
+					if change, ok := keep(change); ok {
+						group = append(group, *change)
+					}
+				}
+				for i, change := range group {
+					change.BatchEnd = i == len(group)-1
+					push(change)
+				}
+			}
+		}
 
 		for {
 			if queue.Len() > 0 {
@@ -32,39 +142,18 @@ func mergeCollectionExcess(in <-chan any) <-chan any {
 					if !ok {
 						return
 					}
-					newMessage := *(newAny.(*CollectionChange))
-					oldMessage, hasOld := messages[newMessage.Id]
-					id := newMessage.Id
-					if hasOld {
-						var send bool
-						newMessage, send = mergeChanges(oldMessage, newMessage)
-						for n := queue.Front(); n != nil; n = n.Next() {
-							if n.Value.(string) == id {
-								queue.Remove(n)
-								break
-							}
-						}
-						if !send {
-							delete(messages, id)
-							continue
-						}
-					}
-
-					messages[id] = newMessage
-					queue.PushBack(id)
+					receive(newAny)
 				case out <- event():
 					front := queue.Front()
 					queue.Remove(front)
-					delete(messages, front.Value.(string))
+					forget(front.Value.(string))
 				}
 			} else {
 				newAny, ok := <-in
 				if !ok {
 					return
 				}
-				newMessage := *(newAny.(*CollectionChange))
-				messages[newMessage.Id] = newMessage
-				queue.PushBack(newMessage.Id)
+				receive(newAny)
 			}
 		}
 
@@ -72,8 +161,14 @@ func mergeCollectionExcess(in <-chan any) <-chan any {
 	return out
 }
 
+type mergedSeq struct {
+	id  string
+	seq uint64
+}
+
 func mergeChanges(a, b CollectionChange) (c CollectionChange, send bool) {
 	b.LastSeedValue = a.LastSeedValue || b.LastSeedValue
+	b.BatchEnd = a.BatchEnd || b.BatchEnd
 
 	switch a.ChangeType {
 	case types.ChangeType_ADD:
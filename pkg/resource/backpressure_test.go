@@ -27,7 +27,7 @@ func Test_mergeCollectionExcess(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			in := make(chan any)
-			out := mergeCollectionExcess(in)
+			out := mergeCollectionExcess(in, 0, nil, nil)
 			<-sendTo(in, tt.in)
 			got := drain(out, len(tt.want))
 			want := parseAllCaseChanges(tt.want...)
@@ -48,7 +48,7 @@ func Test_mergeCollectionExcess(t *testing.T) {
 	for _, tt := range multiMergeTests {
 		t.Run(tt.name, func(t *testing.T) {
 			in := make(chan any)
-			out := mergeCollectionExcess(in)
+			out := mergeCollectionExcess(in, 0, nil, nil)
 			for i := range tt.in {
 				<-sendTo(in, tt.in[i])
 				got := drain(out, len(tt.want[i]))
@@ -62,6 +62,59 @@ func Test_mergeCollectionExcess(t *testing.T) {
 	}
 }
 
+func Test_mergeCollectionExcess_batchEnd(t *testing.T) {
+	group := func(vals ...string) []*CollectionChange {
+		var res []*CollectionChange
+		for _, v := range vals {
+			change := parseCaseChange(v)
+			res = append(res, &change)
+		}
+		return res
+	}
+	batchEnds := func(changes []CollectionChange) []string {
+		var res []string
+		for _, change := range changes {
+			if change.BatchEnd {
+				res = append(res, change.Id)
+			}
+		}
+		return res
+	}
+
+	t.Run("merged away", func(t *testing.T) {
+		in := make(chan any)
+		out := mergeCollectionExcess(in, 0, nil, nil)
+		in <- group("k1:foo", "k2:foo")
+		<-sendTo(in, []string{"k2:foo>"})
+		got := drain(out, 1)
+		if diff := cmp.Diff([]string{"k1"}, batchEnds(got)); diff != "" {
+			t.Fatalf("BatchEnd (-want,+got)\n%s", diff)
+		}
+	})
+	t.Run("merged away after the rest were sent", func(t *testing.T) {
+		in := make(chan any)
+		out := mergeCollectionExcess(in, 0, nil, nil)
+		in <- group("k1:foo", "k2:foo")
+		got := drain(out, 1)
+		<-sendTo(in, []string{"k2:foo>", "k3:foo"})
+		got = append(got, drain(out, 1)...)
+		if diff := cmp.Diff([]string{"k3"}, batchEnds(got)); diff != "" {
+			t.Fatalf("BatchEnd (-want,+got)\n%s", diff)
+		}
+	})
+	t.Run("prepared away", func(t *testing.T) {
+		in := make(chan any)
+		out := mergeCollectionExcess(in, 0, func(change *CollectionChange) (*CollectionChange, bool) {
+			return change, change.Id != "k2"
+		}, nil)
+		in <- group("k1:foo", "k2:foo")
+		got := drain(out, 1)
+		if diff := cmp.Diff([]string{"k1"}, batchEnds(got)); diff != "" {
+			t.Fatalf("BatchEnd (-want,+got)\n%s", diff)
+		}
+	})
+}
+
 // parseCaseChange parses a string of the form "id:old>new" into a CollectionChange.
 func parseCaseChange(s string) CollectionChange {
 	k, v, _ := strings.Cut(s, ":")
@@ -0,0 +1,187 @@
+package resource
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// BatchItem describes a single write performed as part of a batch.
+// See Collection.BatchUpdate, Collection.BatchAdd, and Collection.BatchDelete.
+type BatchItem struct {
+	ID string
+	// Value is the value to write, ignored by BatchDelete.
+	Value proto.Message
+	// Options apply to this item only, after any options passed to the batch call.
+	Options []WriteOption
+}
+
+// BatchResult describes the outcome of writing a single BatchItem.
+type BatchResult struct {
+	// ID identifies the item that was written, which may differ from BatchItem.ID, for example if WithGenIDIfAbsent
+	// is used.
+	ID string
+	// Value is the new value of the item, or the removed value for BatchDelete.
+	Value proto.Message
+	// Err is the error writing the item, if any.
+	Err error
+}
+
+// WithGroupedChanges instructs batch writes to emit their changes as a single group.
+// Pull subscribers receive the changes of a group one after another, with CollectionChange.BatchEnd set on the last
+// change they receive from the group.
+// Without backpressure, see WithBackpressure, grouped changes may be combined with other changes to the same item
+// like any other changes, in which case BatchEnd may be reported later than the end of the batch.
+//
+// Without this option, each change made by a batch write is emitted individually.
+// Applicable only to Collection.BatchUpdate, Collection.BatchAdd, and Collection.BatchDelete.
+func WithGroupedChanges() WriteOption {
+	return writeOptionFunc(func(wr *WriteRequest) {
+		wr.groupChanges = true
+	})
+}
+
+// BatchUpdate updates many items in the collection while holding the write lock only once.
+// Each item is written as if by Update, using opts followed by BatchItem.Options.
+// A failure to write one item does not prevent other items from being written, the outcome of each item is returned
+// in the same order as items.
+// Use Transact if all writes should succeed or fail together.
+//
+// Callbacks and interceptors passed as options are called while the write lock is held,
+// they must not read or write this Collection.
+func (c *Collection) BatchUpdate(items []BatchItem, opts ...WriteOption) []BatchResult {
+	return c.batch(items, opts, c.updateLocked)
+}
+
+// BatchAdd adds many items to the collection while holding the write lock only once.
+// Calling BatchAdd is equivalent to calling BatchUpdate(items, WithExpectAbsent(), WithCreateIfAbsent(), opts...).
+func (c *Collection) BatchAdd(items []BatchItem, opts ...WriteOption) []BatchResult {
+	opts = append([]WriteOption{
+		WithExpectAbsent(), WithCreateIfAbsent(),
+	}, opts...)
+	return c.BatchUpdate(items, opts...)
+}
+
+// BatchDelete removes many items from the collection while holding the write lock only once.
+// Each item is removed as if by Delete, using opts followed by BatchItem.Options, BatchItem.Value is ignored.
+// See BatchUpdate for details.
+func (c *Collection) BatchDelete(items []BatchItem, opts ...WriteOption) []BatchResult {
+	return c.batch(items, opts, func(id string, _ proto.Message, wr WriteRequest) (string, proto.Message, *CollectionChange, error) {
+		return c.deleteLocked(id, wr)
+	})
+}
+
+type batchWriteFunc func(id string, msg proto.Message, wr WriteRequest) (string, proto.Message, *CollectionChange, error)
+
+func (c *Collection) batch(items []BatchItem, opts []WriteOption, write batchWriteFunc) []BatchResult {
+	grouped := ComputeWriteConfig(opts...).groupChanges
+	results := make([]BatchResult, len(items))
+	var changes []*CollectionChange
+
+	c.mu.Lock()
+	for i, it := range items {
+		id := it.ID
+		if c.idInterceptor != nil {
+			id = c.idInterceptor(id)
+		}
+		itemOpts := append(opts[:len(opts):len(opts)], it.Options...)
+		id, value, change, err := write(id, it.Value, ComputeWriteConfig(itemOpts...))
+		if err != nil {
+			err = errorWithID(err, id)
+		}
+		results[i] = BatchResult{ID: id, Value: value, Err: err}
+		if change != nil {
+			changes = append(changes, change)
+		}
+	}
+	if grouped {
+		c.queueGroupLocked(changes)
+	} else {
+		for _, change := range changes {
+			c.queueLocked(change)
+		}
+	}
+	c.mu.Unlock()
+
+	c.flush()
+	return results
+}
+
+// updateLocked is like Update but expects c.mu to be held.
+// Returns the id of the item, its new value, and the change to send to subscribers.
+func (c *Collection) updateLocked(id string, msg proto.Message, wr WriteRequest) (string, proto.Message, *CollectionChange, error) {
+	writer := wr.fieldUpdater(c.writableFields)
+	if err := writer.Validate(msg); err != nil {
+		return id, nil, nil, err
+	}
+
+	if id == "" && wr.genEmptyID {
+		var err error
+		if id, err = c.genID(); err != nil {
+			return id, nil, nil, err
+		}
+		if wr.idCallback != nil {
+			wr.idCallback(id)
+		}
+	}
+
+	var old proto.Message
+	if it, exists := c.byId[id]; exists {
+		if wr.expectAbsent {
+			return id, nil, nil, ExpectAbsentPreconditionFailed
+		}
+		if err := wr.checkVersion(it.version); err != nil {
+			return id, nil, nil, err
+		}
+		old = it.body
+	} else {
+		if err := wr.checkNoVersion(); err != nil {
+			return id, nil, nil, err
+		}
+		if !wr.createIfAbsent {
+			return id, nil, nil, status.Errorf(codes.NotFound, "id %v not found", id)
+		}
+		old = msg.ProtoReflect().New().Interface()
+		if wr.createdCallback != nil {
+			wr.createdCallback()
+		}
+	}
+
+	newValue, err := c.validateChange(wr.changeFn(writer, msg))(old, proto.Clone(old))
+	if err != nil {
+		return id, nil, nil, err
+	}
+	changeTime := wr.updateTime(c.clock)
+	if err := c.save(id, newValue, changeTime); err != nil {
+		return id, nil, nil, status.Errorf(codes.Unavailable, "store: %v", err)
+	}
+	return id, newValue, c.apply(id, newValue, changeTime), nil
+}
+
+// deleteLocked is like Delete but expects c.mu to be held.
+// Returns the id of the item, its removed value, and the change to send to subscribers.
+func (c *Collection) deleteLocked(id string, wr WriteRequest) (string, proto.Message, *CollectionChange, error) {
+	it, exists := c.byId[id]
+	if !exists {
+		if !wr.allowMissing {
+			return id, nil, nil, status.Error(codes.NotFound, "not found")
+		}
+		return id, nil, nil, nil
+	}
+	if wr.expectedCheck != nil {
+		if err := wr.expectedCheck(it.body); err != nil {
+			return id, it.body, nil, err
+		}
+	}
+	if wr.expectedValue != nil && !proto.Equal(it.body, wr.expectedValue) {
+		return id, it.body, nil, ExpectedValuePreconditionFailed
+	}
+	if err := wr.checkVersion(it.version); err != nil {
+		return id, it.body, nil, err
+	}
+	changeTime := c.clock.Now()
+	if err := c.save(id, nil, changeTime); err != nil {
+		return id, nil, nil, status.Errorf(codes.Unavailable, "store: %v", err)
+	}
+	return id, it.body, c.apply(id, nil, changeTime), nil
+}
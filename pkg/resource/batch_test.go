@@ -0,0 +1,125 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestCollection_BatchAdd(t *testing.T) {
+	c := NewCollection(WithInitialRecord("b", &traits.OnOff{}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true))
+
+	results := c.BatchAdd([]BatchItem{
+		{ID: "a", Value: &traits.OnOff{State: traits.OnOff_ON}},
+		{ID: "b", Value: &traits.OnOff{State: traits.OnOff_ON}},
+		{Value: &traits.OnOff{State: traits.OnOff_OFF}, Options: []WriteOption{WithGenIDIfAbsent()}},
+	})
+	if results[0].Err != nil || results[0].ID != "a" {
+		t.Fatalf("a: want success, got %+v", results[0])
+	}
+	if status.Code(results[1].Err) != codes.AlreadyExists {
+		t.Fatalf("b: want AlreadyExists, got %v", results[1].Err)
+	}
+	if results[2].Err != nil || results[2].ID == "" {
+		t.Fatalf("generated: want success, got %+v", results[2])
+	}
+	if diff := cmp.Diff(&traits.OnOff{State: traits.OnOff_OFF}, results[2].Value, protocmp.Transform()); diff != "" {
+		t.Fatalf("generated value (-want,+got)\n%s", diff)
+	}
+
+	for _, id := range []string{"a", results[2].ID} {
+		change := waitForChan(t, changes, time.Second)
+		if change.Id != id || change.ChangeType != types.ChangeType_ADD || change.BatchEnd {
+			t.Fatalf("want individual ADD %v, got %v", id, change)
+		}
+	}
+	noEmitWithin(t, changes, 50*time.Millisecond)
+}
+
+func TestCollection_BatchUpdate_grouped(t *testing.T) {
+	c := NewCollection(
+		WithInitialRecord("a", &traits.OnOff{}),
+		WithInitialRecord("b", &traits.OnOff{}),
+	)
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true), WithBackpressure(true))
+
+	results := c.BatchUpdate([]BatchItem{
+		{ID: "a", Value: &traits.OnOff{State: traits.OnOff_ON}},
+		{ID: "missing", Value: &traits.OnOff{State: traits.OnOff_ON}},
+		{ID: "b", Value: &traits.OnOff{State: traits.OnOff_ON}},
+	}, WithGroupedChanges())
+	if status.Code(results[1].Err) != codes.NotFound {
+		t.Fatalf("want NotFound, got %v", results[1].Err)
+	}
+
+	first := waitForChan(t, changes, time.Second)
+	second := waitForChan(t, changes, time.Second)
+	if first.Id != "a" || first.BatchEnd {
+		t.Fatalf("want a without BatchEnd, got %v", first)
+	}
+	if second.Id != "b" || !second.BatchEnd {
+		t.Fatalf("want b with BatchEnd, got %v", second)
+	}
+	if second.Seq != first.Seq+1 {
+		t.Fatalf("want consecutive Seq, got %v and %v", first.Seq, second.Seq)
+	}
+
+	// BatchEnd is moved to the last change a subscriber sees
+	included := c.Pull(ctx, WithUpdatesOnly(true), WithBackpressure(true), WithInclude(func(id string, _ proto.Message) bool {
+		return id == "a"
+	}))
+	c.BatchUpdate([]BatchItem{
+		{ID: "a", Value: &traits.OnOff{State: traits.OnOff_OFF}},
+		{ID: "b", Value: &traits.OnOff{State: traits.OnOff_OFF}},
+	}, WithGroupedChanges())
+	if change := waitForChan(t, included, time.Second); change.Id != "a" || !change.BatchEnd {
+		t.Fatalf("want a with BatchEnd, got %v", change)
+	}
+}
+
+func TestCollection_BatchDelete(t *testing.T) {
+	c := NewCollection(
+		WithInitialRecord("a", &traits.OnOff{}),
+		WithInitialRecord("b", &traits.OnOff{State: traits.OnOff_ON}),
+	)
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true))
+
+	results := c.BatchDelete([]BatchItem{
+		{ID: "a"},
+		{ID: "b", Options: []WriteOption{WithExpectedValue(&traits.OnOff{})}},
+		{ID: "missing", Options: []WriteOption{WithAllowMissing(true)}},
+	}, WithGroupedChanges())
+	if results[0].Err != nil {
+		t.Fatalf("a: %v", results[0].Err)
+	}
+	if status.Code(results[1].Err) != codes.FailedPrecondition {
+		t.Fatalf("b: want FailedPrecondition, got %v", results[1].Err)
+	}
+	if results[2].Err != nil || results[2].Value != nil {
+		t.Fatalf("missing: want no error or value, got %+v", results[2])
+	}
+	if got := c.List(); len(got) != 1 {
+		t.Fatalf("want 1 remaining item, got %v", got)
+	}
+
+	change := waitForChan(t, changes, time.Second)
+	if change.Id != "a" || change.ChangeType != types.ChangeType_REMOVE || !change.BatchEnd {
+		t.Fatalf("want REMOVE a with BatchEnd, got %v", change)
+	}
+}
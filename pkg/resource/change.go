@@ -34,6 +34,8 @@ type ValueChange struct {
 	SeedValue bool
 	// LastSeedValue will be true if this change is the last change as part of the seed values.
 	LastSeedValue bool
+	// Version is the version of Value, see WithExpectedVersion.
+	Version string
 }
 
 func (v *ValueChange) filter(filter *masks.ResponseFilter) *ValueChange {
@@ -41,7 +43,7 @@ func (v *ValueChange) filter(filter *masks.ResponseFilter) *ValueChange {
 	if newValue == v.Value {
 		return v
 	}
-	return &ValueChange{Value: newValue, ChangeTime: v.ChangeTime, SeedValue: v.SeedValue, LastSeedValue: v.LastSeedValue}
+	return &ValueChange{Value: newValue, ChangeTime: v.ChangeTime, SeedValue: v.SeedValue, LastSeedValue: v.LastSeedValue, Version: v.Version}
 }
 
 // CollectionChange contains information about a change to a Collection.
@@ -56,6 +58,18 @@ type CollectionChange struct {
 	SeedValue bool
 	// LastSeedValue will be true if this change is the last change as part of the seed values.
 	LastSeedValue bool
+	// Seq identifies the position of this change in the sequence of changes made to the Collection.
+	// Passing Seq to WithResumeAfter resumes a Pull without missing any changes, though some changes may be repeated.
+	// Zero means the Pull cannot be resumed from this change.
+	Seq uint64
+	// Version is the version of NewValue, see WithExpectedVersion.
+	// Empty if there is no NewValue.
+	Version string
+	// Resync will be true for seed values sent because a Pull could not be resumed, see WithResumeAfter.
+	// If there are no items to send, a single change with no Id or values is sent with Resync and LastSeedValue set.
+	Resync bool
+	// BatchEnd will be true for the last change caused by a batch write, see WithGroupedChanges.
+	BatchEnd bool
 }
 
 func (c *CollectionChange) filter(filter *masks.ResponseFilter) *CollectionChange {
@@ -72,6 +86,10 @@ func (c *CollectionChange) filter(filter *masks.ResponseFilter) *CollectionChang
 		NewValue:      newNewValue,
 		SeedValue:     c.SeedValue,
 		LastSeedValue: c.LastSeedValue,
+		Seq:           c.Seq,
+		Version:       c.Version,
+		Resync:        c.Resync,
+		BatchEnd:      c.BatchEnd,
 	}
 }
 
@@ -121,6 +139,9 @@ func (c *CollectionChange) include(includeFunc FilterFunc) (newChange *Collectio
 			SeedValue:  c.SeedValue,
 			// this is not safe, the caller needs to deal with this
 			// LastSeedValue: c.LastSeedValue,
+			Seq:      c.Seq,
+			Version:  c.Version,
+			BatchEnd: c.BatchEnd,
 		}, true
 	}
 
@@ -130,5 +151,7 @@ func (c *CollectionChange) include(includeFunc FilterFunc) (newChange *Collectio
 		ChangeType: types.ChangeType_REMOVE,
 		ChangeTime: c.ChangeTime,
 		OldValue:   c.OldValue,
+		Seq:        c.Seq,
+		BatchEnd:   c.BatchEnd,
 	}, true
 }
@@ -19,10 +19,29 @@ import (
 type Collection struct {
 	*config
 
-	mu   sync.RWMutex // protects byId and rng from concurrent access
-	byId map[string]*item
+	mu      sync.RWMutex // protects byId, history, indexes, and rng from concurrent access
+	byId    map[string]*item
+	history *history[*CollectionChange]
+	indexes map[string]*index // see WithIndex
 	// "change" events contain a *CollectionChange instance
 	bus minibus.Bus
+
+	order uint64 // see Participant
+
+	// change sequencing, see WithResumeAfter
+	seq     uint64 // of the last applied change, protected by mu
+	sendMu  sync.Mutex
+	queueMu sync.Mutex
+	queue   []any // events waiting to be sent on bus, in sequence order, protected by queueMu
+
+	// expiry tracking, see WithExpiry
+	expiryQueue     expiryHeap
+	expiryEntries   map[string]*expiryEntry // keyed by item id, entries in expiryQueue
+	nextExpiry      time.Time
+	stopExpiry      func() // stops the scheduled call to removeExpired, nil if none is scheduled
+	removingExpired bool   // removeExpired is running, see scheduleExpiry
+
+	replicator *Replicator // see NewReplicator, protected by mu
 }
 
 func NewCollection(options ...Option) *Collection {
@@ -37,11 +56,57 @@ func NewCollection(options ...Option) *Collection {
 		config: conf,
 		byId:   initialItems,
 		mu:     sync.RWMutex{},
+		order:  nextParticipantOrder(),
+		seq:    initialSeq(conf.clock),
+	}
+	conf.configureBus(&c.bus)
+	if conf.store != nil {
+		c.restore()
+	}
+	c.indexes = newIndexes(conf.indexes)
+	for id, it := range c.byId {
+		it.version = c.seq
+		c.trackExpiry(id, it)
+		c.updateIndexes(id, it.body)
+	}
+	if c.history = newHistory[*CollectionChange](conf); c.history != nil {
+		for _, v := range c.sortedItems(&ReadRequest{}) {
+			c.history.add(v.changeTime, &CollectionChange{
+				Id:         v.id,
+				ChangeTime: v.changeTime,
+				ChangeType: types.ChangeType_ADD,
+				NewValue:   v.body,
+			})
+		}
 	}
 
 	return c
 }
 
+// restore loads the items from the configured store, or saves the initial records to the store if it is empty.
+func (c *Collection) restore() {
+	records, err := c.store.Load()
+	if err != nil {
+		log.Printf("WARN: resource.Collection failed to load from store, using initial records: %v", err)
+		return
+	}
+	if len(records) > 0 {
+		c.byId = make(map[string]*item, len(records))
+		for _, record := range records {
+			if record.Value == nil {
+				continue
+			}
+			c.byId[record.ID] = &item{body: record.Value, changeTime: record.ChangeTime}
+		}
+		return
+	}
+	for id, v := range c.byId {
+		if err := c.store.Save(StoreRecord{ID: id, Value: v.body, ChangeTime: v.changeTime}); err != nil {
+			log.Printf("WARN: resource.Collection failed to save initial record %v to store: %v", id, err)
+		}
+	}
+}
+
 // Get will find the entry with the given ID. If no such entry exists, returns false.
 func (c *Collection) Get(id string, opts ...ReadOption) (proto.Message, bool) {
 	if c.idInterceptor != nil {
@@ -58,23 +123,22 @@ func (c *Collection) Get(id string, opts ...ReadOption) (proto.Message, bool) {
 		return nil, false
 	}
 
+	readConfig.reportVersion(id, entry.version)
 	return readConfig.FilterClone(entry.body), true
 }
 
-// List returns a list of all the entries, sorted by their ID.
+// List returns a list of all the entries, sorted by their ID or by WithSortKey.
 func (c *Collection) List(opts ...ReadOption) []proto.Message {
 	readConfig := ComputeReadConfig(opts...)
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	tmp := c.itemSlice(readConfig)
-	sort.Slice(tmp, func(i, j int) bool {
-		return tmp[i].id < tmp[j].id
-	})
+	tmp := c.sortedItems(readConfig)
 
 	result := make([]proto.Message, 0, len(tmp))
 	filter := readConfig.ResponseFilter()
 	for _, e := range tmp {
+		readConfig.reportVersion(e.id, e.version)
 		result = append(result, filter.FilterClone(e.body))
 	}
 	return result
@@ -105,10 +169,14 @@ func (c *Collection) Update(id string, msg proto.Message, opts ...WriteOption) (
 	}
 
 	var created proto.Message // during create, this is returned by GetFn so concurrent reference checks pass
-	oldValue, newValue, err := GetAndUpdate(
+	var versionErr, storeErr error
+	changeFn := c.validateChange(writeRequest.changeFn(writer, msg))
+	var change *CollectionChange
+	_, newValue, err := GetAndUpdate(
 		&c.mu,
 		func() (item proto.Message, err error) {
 			if created != nil {
+				versionErr = writeRequest.checkNoVersion()
 				return created, nil
 			}
 
@@ -124,6 +192,7 @@ func (c *Collection) Update(id string, msg proto.Message, opts ...WriteOption) (
 			}
 
 			val, exists := c.byId[id]
+			versionErr = c.checkVersion(id, writeRequest)
 			if exists {
 				if writeRequest.expectAbsent {
 					return nil, ExpectAbsentPreconditionFailed
@@ -139,29 +208,34 @@ func (c *Collection) Update(id string, msg proto.Message, opts ...WriteOption) (
 			}
 			return created, nil
 		},
-		writeRequest.changeFn(writer, msg),
+		func(old, dst proto.Message) (proto.Message, error) {
+			if versionErr != nil {
+				return nil, versionErr
+			}
+			return changeFn(old, dst)
+		},
 		func(msg proto.Message) {
-			c.byId[id] = &item{body: msg, changeTime: writeRequest.updateTime(c.clock)}
+			if versionErr = c.checkVersion(id, writeRequest); versionErr != nil {
+				return
+			}
+			changeTime := writeRequest.updateTime(c.clock)
+			if storeErr = c.save(id, msg, changeTime); storeErr != nil {
+				return
+			}
+			change = c.apply(id, msg, changeTime)
+			c.queueLocked(change)
 		})
 
 	if err != nil {
-		if s, ok := status.FromError(err); ok {
-			return nil, status.Errorf(s.Code(), "%v %v", s.Message(), id)
-		}
-		return nil, err
+		return nil, errorWithID(err, id)
 	}
-	changeType := types.ChangeType_UPDATE
-	if oldValue == nil || created != nil {
-		changeType = types.ChangeType_ADD
-		oldValue = nil
-	}
-	c.bus.Send(context.TODO(), &CollectionChange{
-		Id:         id,
-		ChangeTime: writeRequest.updateTime(c.clock),
-		ChangeType: changeType,
-		OldValue:   oldValue,
-		NewValue:   newValue,
-	})
+	if versionErr != nil {
+		return nil, status.Errorf(status.Code(versionErr), "%v %v", status.Convert(versionErr).Message(), id)
+	}
+	if storeErr != nil {
+		return nil, status.Errorf(codes.Unavailable, "store: %v %v", storeErr, id)
+	}
+	c.flush()
 	return newValue, nil
 }
 
@@ -195,6 +269,9 @@ func (c *Collection) Delete(id string, opts ...WriteOption) (proto.Message, erro
 		if args.expectedValue != nil && !proto.Equal(oldVal.body, args.expectedValue) {
 			return oldVal.body, ExpectedValuePreconditionFailed
 		}
+		if err := args.checkVersion(oldVal.version); err != nil {
+			return oldVal.body, err
+		}
 
 		c.mu.Lock()
 		oldVal2, exists2 := c.byId[id]
@@ -206,31 +283,126 @@ func (c *Collection) Delete(id string, opts ...WriteOption) (proto.Message, erro
 		}
 
 		// actually do the delete
-		delete(c.byId, id)
-		c.bus.Send(context.TODO(), &CollectionChange{
-			Id:         id,
-			ChangeTime: c.clock.Now(),
-			ChangeType: types.ChangeType_REMOVE,
-			OldValue:   oldVal.body,
-		})
+		changeTime := args.updateTime(c.clock)
+		if err := c.save(id, nil, changeTime); err != nil {
+			c.mu.Unlock()
+			return nil, status.Errorf(codes.Unavailable, "store: %v %v", err, id)
+		}
+		c.queueLocked(c.apply(id, nil, changeTime))
 		c.mu.Unlock()
+		c.flush()
 		return oldVal.body, nil
 	}
 
 	return nil, status.Error(codes.Unavailable, "concurrent writes")
 }
 
+// save persists the item identified by id to the configured store, if any.
+// A nil msg deletes the item from the store.
+// c.mu must be held.
+func (c *Collection) save(id string, msg proto.Message, changeTime time.Time) error {
+	if c.store == nil {
+		return nil
+	}
+	if msg == nil {
+		return c.store.Delete(id)
+	}
+	return c.store.Save(StoreRecord{ID: id, Value: msg, ChangeTime: changeTime})
+}
+
+// apply sets the item identified by id to msg, or removes it if msg is nil.
+// Returns the change that was made, or nil if no change was made.
+// c.mu must be held.
+func (c *Collection) apply(id string, msg proto.Message, changeTime time.Time) *CollectionChange {
+	old, exists := c.byId[id]
+	if msg == nil && !exists {
+		return nil
+	}
+	change := &CollectionChange{Id: id, ChangeTime: changeTime, NewValue: msg}
+	c.nextSeq(change)
+	switch {
+	case msg == nil:
+		delete(c.byId, id)
+		change.ChangeType = types.ChangeType_REMOVE
+		change.OldValue = old.body
+	case exists:
+		c.byId[id] = &item{body: msg, changeTime: changeTime, version: change.Seq}
+		change.ChangeType = types.ChangeType_UPDATE
+		change.OldValue = old.body
+		change.Version = formatVersion(change.Seq)
+	default:
+		c.byId[id] = &item{body: msg, changeTime: changeTime, version: change.Seq}
+		change.ChangeType = types.ChangeType_ADD
+		change.Version = formatVersion(change.Seq)
+	}
+	c.trackExpiry(id, c.byId[id]) // nil if removed
+	c.updateIndexes(id, msg)
+	if c.history != nil {
+		c.history.prune(c.clock.Now())
+		c.history.add(changeTime, change)
+	}
+	if c.replicator != nil {
+		c.replicator.recordLocked(id, msg, changeTime)
+	}
+	return change
+}
+
 func (c *Collection) Pull(ctx context.Context, opts ...ReadOption) <-chan *CollectionChange {
 	readConfig := ComputeReadConfig(opts...)
 	filter := readConfig.ResponseFilter()
+	include := c.includeFunc(readConfig)
+	// prepare adjusts a live change for this subscriber, returning false if it shouldn't be sent
+	prepare := func(change *CollectionChange) (*CollectionChange, bool) {
+		change, ok := change.include(include)
+		if !ok {
+			return nil, false
+		}
+		change = change.filter(filter)
+		if c.equivalence != nil && c.equivalence.Compare(change.OldValue, change.NewValue) {
+			return nil, false
+		}
+		return change, true
+	}
 
-	emit, currentValues := c.onUpdate(ctx, readConfig)
+	emit, start := c.onUpdate(ctx, readConfig, prepare)
 	send := make(chan *CollectionChange)
 
 	go func() {
 		defer close(send)
+		if emit == nil {
+			return // too many subscribers
+		}
 
-		if len(currentValues) > 0 {
+		if !start.resumed {
+			// see WithReplaySince, replayed changes come before the seed values as if they were live changes
+			seeding := len(start.items) > 0 || start.resync
+			lastIndex := len(start.replay) - 1
+			for i, change := range start.replay {
+				change := *change.filter(filter)
+				if c.equivalence != nil && c.equivalence.Compare(change.OldValue, change.NewValue) {
+					continue
+				}
+				change.Seq = 0
+				if i == lastIndex && !seeding {
+					change.Seq = start.seq
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case send <- &change:
+				}
+			}
+		}
+		if start.resumed {
+			for _, change := range start.replay {
+				select {
+				case <-ctx.Done():
+					return
+				case send <- change.filter(filter):
+				}
+			}
+		} else if len(start.items) > 0 {
+			currentValues := start.items
 			sort.Slice(currentValues, func(i, j int) bool {
 				return currentValues[i].id < currentValues[j].id
 			})
@@ -243,6 +415,11 @@ func (c *Collection) Pull(ctx context.Context, opts ...ReadOption) <-chan *Colle
 					NewValue:      value.body,
 					SeedValue:     true,
 					LastSeedValue: i == lastIndex,
+					Version:       formatVersion(value.version),
+					Resync:        start.resync,
+				}
+				if change.LastSeedValue {
+					change.Seq = start.seq
 				}
 				change = change.filter(filter)
 				select {
@@ -251,29 +428,74 @@ func (c *Collection) Pull(ctx context.Context, opts ...ReadOption) <-chan *Colle
 				case send <- change:
 				}
 			}
+		} else if start.resync {
+			change := &CollectionChange{SeedValue: true, LastSeedValue: true, Resync: true, Seq: start.seq}
+			select {
+			case <-ctx.Done():
+				return
+			case send <- change:
+			}
 		}
 
 		for event := range emit {
-			change := event.(*CollectionChange)
-			change, ok := change.include(readConfig.Include)
-			if !ok {
-				continue
+			var (
+				group   []*CollectionChange
+				grouped bool
+			)
+			switch event := event.(type) {
+			case *CollectionChange:
+				group = []*CollectionChange{event}
+			case []*CollectionChange: // see WithGroupedChanges, mergeCollectionExcess splits these without backpressure
+				group, grouped = event, true
 			}
-			change = change.filter(filter)
-			if c.equivalence != nil && c.equivalence.Compare(change.OldValue, change.NewValue) {
+			if !readConfig.Backpressure {
+				// mergeCollectionExcess has already prepared the changes and set BatchEnd
+				for _, change := range group {
+					select {
+					case send <- change:
+					case <-ctx.Done():
+						return
+					}
+				}
 				continue
 			}
-			select {
-			case send <- change:
-			case <-ctx.Done():
-				return
+			var toSend []*CollectionChange
+			for _, change := range group {
+				if change.Seq <= start.seq {
+					continue // already included in start
+				}
+				change, ok := prepare(change)
+				if !ok {
+					continue
+				}
+				toSend = append(toSend, change)
+			}
+			if grouped && len(toSend) > 0 {
+				last := *toSend[len(toSend)-1]
+				last.BatchEnd = true
+				toSend[len(toSend)-1] = &last
+			}
+			for _, change := range toSend {
+				select {
+				case send <- change:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}
 	}()
 
+	if readConfig.RateLimit.enabled() {
+		m := newCollectionChangeMerger()
+		return rateLimit(ctx, c.clock, readConfig.RateLimit, send, isCollectionSeed, m.merge, m.emit)
+	}
 	return send
 }
 
+func isCollectionSeed(c *CollectionChange) bool {
+	return c.SeedValue || c.Resync
+}
+
 // PullID subscribes to changes for a single item in the collection.
 // The returned channel will close if ctx is Done or the item identified by id is deleted.
 func (c *Collection) PullID(ctx context.Context, id string, opts ...ReadOption) <-chan *ValueChange {
@@ -302,27 +524,91 @@ func (c *Collection) PullID(ctx context.Context, id string, opts ...ReadOption)
 			select {
 			case <-ctx.Done():
 				return
-			case send <- &ValueChange{ChangeTime: change.ChangeTime, Value: change.NewValue, SeedValue: change.SeedValue, LastSeedValue: change.LastSeedValue}:
+			case send <- &ValueChange{ChangeTime: change.ChangeTime, Value: change.NewValue, SeedValue: change.SeedValue, LastSeedValue: change.LastSeedValue, Version: change.Version}:
 			}
 		}
 	}()
 	return send
 }
 
-func (c *Collection) onUpdate(ctx context.Context, config *ReadRequest) (<-chan any, []idItem) {
-	var res []idItem
-	if !config.UpdatesOnly {
+// pullStart describes what a Pull sends before live changes.
+type pullStart struct {
+	items   []idItem            // seed values
+	replay  []*CollectionChange // replayed history
+	seq     uint64              // of the last change reflected by items or replay, zero if unknown
+	resumed bool                // replay continues a previous Pull, see WithResumeAfter
+	resync  bool                // a previous Pull couldn't be resumed, items are sent instead
+}
+
+// onUpdate returns a chan of live changes and what to send before them.
+// Without backpressure, prepare is applied to each live change, see mergeCollectionExcess.
+func (c *Collection) onUpdate(ctx context.Context, config *ReadRequest, prepare func(*CollectionChange) (*CollectionChange, bool)) (<-chan any, pullStart) {
+	var start pullStart
+	replaying := c.history != nil && !config.ReplaySince.IsZero()
+	if !config.UpdatesOnly || replaying || config.Resume {
 		c.mu.RLock()
 		defer c.mu.RUnlock()
-		res = c.itemSlice(config)
+		start.seq = c.seq
+	}
+	include := c.includeFunc(config)
+	switch {
+	case config.Resume:
+		changes, ok := c.resumeChanges(config.ResumeAfter)
+		if !ok {
+			start.resync = true
+			start.items = c.itemSlice(config)
+			break
+		}
+		start.resumed = true
+		for _, change := range changes {
+			if change, ok := change.include(include); ok {
+				start.replay = append(start.replay, change)
+			}
+		}
+	case !config.UpdatesOnly:
+		start.items = c.itemSlice(config)
+	}
+	if replaying && !config.Resume {
+		for _, change := range c.history.since(c.clock.Now(), config.ReplaySince) {
+			if change, ok := change.include(include); ok {
+				start.replay = append(start.replay, change)
+			}
+		}
 	}
 
-	ch := c.bus.Listen(ctx)
-	if !config.Backpressure {
-		ch = mergeCollectionExcess(ch)
+	ch := listen(ctx, &c.bus, config)
+	if ch != nil && !config.Backpressure {
+		ch = mergeCollectionExcess(ch, start.seq, prepare, c.equivalence)
 	}
 
-	return ch, res
+	return ch, start
+}
+
+// ListHistory returns the changes retained by this Collection that match req, oldest first.
+// Changes are adjusted to account for WithInclude in the same way as Pull.
+// Returns HistoryNotEnabled if the Collection was not configured to retain history, see WithHistoryCapacity.
+func (c *Collection) ListHistory(req HistoryRequest, opts ...ReadOption) ([]*CollectionChange, HistoryInfo, error) {
+	if c.history == nil {
+		return nil, HistoryInfo{}, HistoryNotEnabled
+	}
+	readConfig := ComputeReadConfig(opts...)
+	if err := c.checkIndex(readConfig); err != nil {
+		return nil, HistoryInfo{}, err
+	}
+	filter := readConfig.ResponseFilter()
+	include := c.includeFunc(readConfig)
+	c.mu.RLock()
+	changes, info, err := c.history.list(c.clock.Now(), req, func(change *CollectionChange) (*CollectionChange, bool) {
+		return change.include(include)
+	})
+	c.mu.RUnlock()
+	if err != nil {
+		return nil, info, err
+	}
+	for i, change := range changes {
+		changes[i] = change.filter(filter)
+	}
+	return changes, info, nil
 }
 
 // Clock returns the clock used by this resource for reporting time.
@@ -330,11 +616,45 @@ func (c *Collection) Clock() Clock {
 	return c.clock
 }
 
+func (c *Collection) txOrder() uint64 {
+	return c.order
+}
+
+func (c *Collection) txLock() *sync.RWMutex {
+	return &c.mu
+}
+
 // itemSlice returns all the values in byId adjusted to match readConfig settings like ReadRequest.Include.
 func (c *Collection) itemSlice(readConfig *ReadRequest) []idItem {
+	idf := c.idFilter(readConfig)
+	if idx := c.index(readConfig); idx != nil {
+		ids := idx.lookup(readConfig.IndexKeys)
+		res := make([]idItem, 0, len(ids))
+		for _, id := range ids {
+			value := c.byId[id]
+			if idf != nil && !idf.matches(id) || readConfig.Exclude(id, value.body) {
+				continue
+			}
+			res = append(res, idItem{item: *value, id: id})
+		}
+		return res
+	}
+	if idf != nil {
+		if ids, ok := idf.exact(); ok {
+			res := make([]idItem, 0, len(ids))
+			for id := range ids {
+				value, exists := c.byId[id]
+				if !exists || readConfig.Exclude(id, value.body) {
+					continue
+				}
+				res = append(res, idItem{item: *value, id: id})
+			}
+			return res
+		}
+	}
 	res := make([]idItem, 0, len(c.byId))
 	for id, value := range c.byId {
-		if readConfig.Exclude(id, value.body) {
+		if idf != nil && !idf.matches(id) || readConfig.Exclude(id, value.body) {
 			continue
 		}
 		res = append(res, idItem{item: *value, id: id})
@@ -342,6 +662,28 @@ func (c *Collection) itemSlice(readConfig *ReadRequest) []idItem {
 	return res
 }
 
+// sortedItems is like itemSlice but sorted by id, or by ReadRequest.SortKey.
+func (c *Collection) sortedItems(readConfig *ReadRequest) []idItem {
+	res := c.itemSlice(readConfig)
+	if readConfig.SortKey != nil {
+		keys := make(map[string]string, len(res))
+		for _, it := range res {
+			keys[it.id] = readConfig.SortKey(it.id, it.body)
+		}
+		sort.Slice(res, func(i, j int) bool {
+			if ki, kj := keys[res[i].id], keys[res[j].id]; ki != kj {
+				return ki < kj
+			}
+			return res[i].id < res[j].id
+		})
+		return res
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].id < res[j].id
+	})
+	return res
+}
+
 func (c *Collection) genID() (string, error) {
 	return GenerateUniqueId(c.rng, func(candidate string) bool {
 		if c.idInterceptor != nil {
@@ -355,6 +697,8 @@ func (c *Collection) genID() (string, error) {
 type item struct {
 	body       proto.Message
 	changeTime time.Time
+	expireTime time.Time // zero if the item doesn't expire, see WithExpiry
+	version    uint64    // the Seq of the change that last wrote this item, see WithExpectedVersion
 }
 
 type idItem struct {
@@ -36,6 +36,7 @@ func TestCollection_Pull(t *testing.T) {
 			NewValue:      &traits.OnOff{State: traits.OnOff_ON},
 			SeedValue:     true,
 			LastSeedValue: false,
+			Version:       "2",
 		}
 		if diff := cmp.Diff(want, seed, protocmp.Transform()); diff != "" {
 			t.Fatalf("Seed Value (-want,+got)\n%s", diff)
@@ -49,6 +50,8 @@ func TestCollection_Pull(t *testing.T) {
 			NewValue:      &traits.OnOff{State: traits.OnOff_ON},
 			SeedValue:     true,
 			LastSeedValue: true,
+			Seq:           2,
+			Version:       "1",
 		}
 		if diff := cmp.Diff(want, seed, protocmp.Transform()); diff != "" {
 			t.Fatalf("Seed Value (-want,+got)\n%s", diff)
@@ -63,6 +66,8 @@ func TestCollection_Pull(t *testing.T) {
 			ChangeType: types.ChangeType_UPDATE,
 			OldValue:   &traits.OnOff{State: traits.OnOff_ON},
 			NewValue:   &traits.OnOff{State: traits.OnOff_OFF},
+			Seq:        3,
+			Version:    "3",
 		}
 		if diff := cmp.Diff(want, next, protocmp.Transform()); diff != "" {
 			t.Fatalf("Next Value (-want,+got)\n%s", diff)
@@ -76,6 +81,8 @@ func TestCollection_Pull(t *testing.T) {
 			ChangeTime: now,
 			ChangeType: types.ChangeType_ADD,
 			NewValue:   &traits.OnOff{State: traits.OnOff_ON},
+			Seq:        4,
+			Version:    "4",
 		}
 		if diff := cmp.Diff(want, next, protocmp.Transform()); diff != "" {
 			t.Fatalf("Next Value (-want,+got)\n%s", diff)
@@ -107,6 +114,8 @@ func TestCollection_Pull(t *testing.T) {
 			ChangeType: types.ChangeType_UPDATE,
 			OldValue:   &traits.OnOff{State: traits.OnOff_ON},
 			NewValue:   &traits.OnOff{State: traits.OnOff_OFF},
+			Seq:        2,
+			Version:    "2",
 		}
 		if diff := cmp.Diff(want, change, protocmp.Transform()); diff != "" {
 			t.Fatalf("Value (-want,+got)\n%s", diff)
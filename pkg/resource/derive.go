@@ -0,0 +1,222 @@
+package resource
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Source is a resource that can be used as an input to Derive or Combine.
+// Source is implemented by *Value and *Collection.
+type Source interface {
+	// subscribe returns the current state of the source and a chan that emits the new state whenever it changes.
+	// The chan is closed when ctx is done.
+	subscribe(ctx context.Context) (sourceState, <-chan sourceState)
+}
+
+type sourceState struct {
+	state      any
+	changeTime time.Time
+}
+
+// CombineFunc computes a new value from the state of each source passed to Combine, in the same order.
+// The state of a *Value is its proto.Message value, which may be nil if the Value has no value.
+// The state of a *Collection is a []proto.Message of its items, sorted by id.
+// Returning nil leaves the derived value unchanged.
+// CombineFunc should not modify the states it is passed.
+type CombineFunc func(states []any) proto.Message
+
+// Derived is a read-only Value whose content is computed from other resources.
+// See Derive and Combine.
+type Derived struct {
+	v    *Value
+	done <-chan struct{}
+}
+
+// Derive returns a Derived whose value is fn applied to the value of src.
+// fn is called with the current value of src and again each time src changes.
+// See Combine for details.
+func Derive(ctx context.Context, src *Value, fn func(msg proto.Message) proto.Message, opts ...Option) *Derived {
+	return Combine(ctx, []Source{src}, func(states []any) proto.Message {
+		msg, _ := states[0].(proto.Message)
+		return fn(msg)
+	}, opts...)
+}
+
+// Combine returns a Derived whose value is computed using fn from the state of sources.
+// fn is called with the current state of all sources before Combine returns, and again each time any source changes.
+//
+// The ChangeTime of the Derived value is the ChangeTime of the source change that caused it to be computed.
+// If a Comparer is configured, via WithEquivalence or WithNoDuplicates, computed values equivalent to the current
+// value are discarded.
+// Options that relate to writing, like WithStore or WithWritableFields, have no effect.
+//
+// The Derived stops tracking sources once ctx is done, any Pull calls on the Derived will also stop.
+func Combine(ctx context.Context, sources []Source, fn CombineFunc, opts ...Option) *Derived {
+	ctx, stop := context.WithCancel(ctx)
+	d := &Derived{v: NewValue(opts...), done: ctx.Done()}
+
+	states := make([]any, len(sources))
+	updates := make(chan sourceUpdate)
+	var changeTime time.Time
+	for i, src := range sources {
+		initial, changes := src.subscribe(ctx)
+		states[i] = initial.state
+		if initial.changeTime.After(changeTime) {
+			changeTime = initial.changeTime
+		}
+		go func() {
+			for change := range changes {
+				select {
+				case <-ctx.Done():
+					return
+				case updates <- sourceUpdate{index: i, sourceState: change}:
+				}
+			}
+		}()
+	}
+	if changeTime.IsZero() {
+		changeTime = d.v.clock.Now()
+	}
+	d.update(fn(states), changeTime)
+
+	go func() {
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u := <-updates:
+				states[u.index] = u.state
+				d.update(fn(states), u.changeTime)
+			}
+		}
+	}()
+	return d
+}
+
+type sourceUpdate struct {
+	index int
+	sourceState
+}
+
+// update sets the value of d to msg, unless msg is nil or equivalent to the current value.
+func (d *Derived) update(msg proto.Message, changeTime time.Time) {
+	if msg == nil {
+		return
+	}
+	v := d.v
+	v.mu.Lock()
+	if v.value != nil && v.equivalence != nil && v.equivalence.Compare(v.value, msg) {
+		v.mu.Unlock()
+		return
+	}
+	change := v.apply(msg, changeTime)
+	v.mu.Unlock()
+	v.bus.Send(context.TODO(), change)
+}
+
+// Get returns the current derived value.
+// See Value.Get.
+func (d *Derived) Get(opts ...ReadOption) proto.Message {
+	return d.v.Get(opts...)
+}
+
+// Pull emits a ValueChange on the returned chan whenever the derived value changes.
+// The returned chan is closed when ctx is done or the Derived stops tracking its sources.
+// See Value.Pull.
+func (d *Derived) Pull(ctx context.Context, opts ...ReadOption) <-chan *ValueChange {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		select {
+		case <-ctx.Done():
+		case <-d.done:
+		}
+	}()
+	return d.v.Pull(ctx, opts...)
+}
+
+// Subscribers returns the stats of all active Pull calls on this Derived, see Value.Subscribers.
+func (d *Derived) Subscribers() []SubscriberStats {
+	return d.v.Subscribers()
+}
+
+// Done returns a chan that is closed when the Derived stops tracking its sources.
+func (d *Derived) Done() <-chan struct{} {
+	return d.done
+}
+
+// Clock returns the clock used by this resource for reporting time.
+func (d *Derived) Clock() Clock {
+	return d.v.clock
+}
+
+func (r *Value) subscribe(ctx context.Context) (sourceState, <-chan sourceState) {
+	on, value, changeTime, _, _ := r.onUpdate(ctx, &ReadRequest{internal: true})
+	out := make(chan sourceState)
+	go func() {
+		defer close(out)
+		if on == nil {
+			return // too many subscribers
+		}
+		for event := range on {
+			change := event.(*ValueChange)
+			select {
+			case <-ctx.Done():
+				return
+			case out <- sourceState{state: change.Value, changeTime: change.ChangeTime}:
+			}
+		}
+	}()
+	return sourceState{state: value, changeTime: changeTime}, out
+}
+
+func (c *Collection) subscribe(ctx context.Context) (sourceState, <-chan sourceState) {
+	on, start := c.onUpdate(ctx, &ReadRequest{internal: true}, nil)
+	items := make(map[string]proto.Message, len(start.items))
+	var changeTime time.Time
+	for _, it := range start.items {
+		items[it.id] = it.body
+		if it.changeTime.After(changeTime) {
+			changeTime = it.changeTime
+		}
+	}
+	list := func() []proto.Message {
+		ids := make([]string, 0, len(items))
+		for id := range items {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		res := make([]proto.Message, len(ids))
+		for i, id := range ids {
+			res[i] = items[id]
+		}
+		return res
+	}
+	initial := sourceState{state: list(), changeTime: changeTime}
+
+	out := make(chan sourceState)
+	go func() {
+		defer close(out)
+		if on == nil {
+			return // too many subscribers
+		}
+		for event := range on {
+			change := event.(*CollectionChange)
+			if change.NewValue == nil {
+				delete(items, change.Id)
+			} else {
+				items[change.Id] = change.NewValue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- sourceState{state: list(), changeTime: change.ChangeTime}:
+			}
+		}
+	}()
+	return initial, out
+}
@@ -0,0 +1,123 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+)
+
+func TestDerive(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	src := NewValue(WithInitialValue(&traits.Brightness{LevelPercent: 0}))
+	onOff := Derive(ctx, src, func(msg proto.Message) proto.Message {
+		if msg.(*traits.Brightness).LevelPercent > 0 {
+			return &traits.OnOff{State: traits.OnOff_ON}
+		}
+		return &traits.OnOff{State: traits.OnOff_OFF}
+	}, WithNoDuplicates())
+
+	if diff := cmp.Diff(&traits.OnOff{State: traits.OnOff_OFF}, onOff.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("initial (-want,+got)\n%s", diff)
+	}
+
+	changes := onOff.Pull(ctx, WithUpdatesOnly(true))
+	writeTime := time.Unix(100, 0)
+	if _, err := src.Set(&traits.Brightness{LevelPercent: 50}, WithWriteTime(writeTime)); err != nil {
+		t.Fatal(err)
+	}
+	change := waitForChan(t, changes, time.Second)
+	if change.Value.(*traits.OnOff).State != traits.OnOff_ON {
+		t.Fatalf("want ON, got %v", change.Value)
+	}
+	if !change.ChangeTime.Equal(writeTime) {
+		t.Fatalf("want ChangeTime %v, got %v", writeTime, change.ChangeTime)
+	}
+
+	// still ON, so no change
+	if _, err := src.Set(&traits.Brightness{LevelPercent: 60}); err != nil {
+		t.Fatal(err)
+	}
+	noEmitWithin(t, changes, 50*time.Millisecond)
+}
+
+func TestCombine(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	levels := NewCollection(
+		WithInitialRecord("a", &traits.Brightness{LevelPercent: 10}),
+		WithInitialRecord("b", &traits.Brightness{LevelPercent: 30}),
+	)
+	offset := NewValue(WithInitialValue(&traits.Brightness{LevelPercent: 0}))
+	avg := Combine(ctx, []Source{levels, offset}, func(states []any) proto.Message {
+		items := states[0].([]proto.Message)
+		if len(items) == 0 {
+			return &traits.Brightness{}
+		}
+		var sum float32
+		for _, item := range items {
+			sum += item.(*traits.Brightness).LevelPercent
+		}
+		return &traits.Brightness{LevelPercent: sum/float32(len(items)) + states[1].(*traits.Brightness).LevelPercent}
+	})
+	level := func(c *ValueChange) float32 {
+		return c.Value.(*traits.Brightness).LevelPercent
+	}
+
+	changes := avg.Pull(ctx)
+	if got := level(waitForChan(t, changes, time.Second)); got != 20 {
+		t.Fatalf("initial want 20, got %v", got)
+	}
+	add(t, levels, "c", &traits.Brightness{LevelPercent: 50})
+	if got := level(waitForChan(t, changes, time.Second)); got != 30 {
+		t.Fatalf("after add want 30, got %v", got)
+	}
+	if _, err := offset.Set(&traits.Brightness{LevelPercent: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if got := level(waitForChan(t, changes, time.Second)); got != 35 {
+		t.Fatalf("after offset want 35, got %v", got)
+	}
+	if _, err := levels.Delete("c"); err != nil {
+		t.Fatal(err)
+	}
+	if got := level(waitForChan(t, changes, time.Second)); got != 25 {
+		t.Fatalf("after delete want 25, got %v", got)
+	}
+}
+
+func TestDerive_cancel(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	src := NewValue(WithInitialValue(&traits.OnOff{}))
+	d := Derive(ctx, src, func(msg proto.Message) proto.Message {
+		return msg
+	})
+	changes := d.Pull(context.Background())
+	waitForChan(t, changes, time.Second) // seed
+	stop()
+
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Done not closed after cancel")
+	}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("Pull not closed after cancel")
+		}
+	}
+}
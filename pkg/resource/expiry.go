@@ -0,0 +1,148 @@
+package resource
+
+import (
+	"container/heap"
+	"log"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ExpiryFunc returns the time at which an item in a Collection should be removed.
+// changeTime is the time the item was last changed.
+// A zero time means the item never expires.
+type ExpiryFunc func(id string, item proto.Message, changeTime time.Time) time.Time
+
+// WithExpiry configures a Collection to remove items once the time returned by fn has passed.
+// fn is called each time an item is added or updated, the returned expiry replaces any previous expiry for that item.
+// Expired items are removed as if Collection.Delete was called, emitting REMOVE changes to Pull subscribers.
+// The configured Clock is used to determine when items have expired, if the Clock implements
+// `At(time.Time) <-chan time.Time`, like clock.Clock, it will also be used to schedule removals.
+// Applicable only to Collection.
+func WithExpiry(fn ExpiryFunc) Option {
+	return optionFunc(func(s *config) {
+		s.expiry = fn
+	})
+}
+
+// WithTTL configures a Collection to remove items ttl after they were last changed.
+// See WithExpiry.
+// Applicable only to Collection.
+func WithTTL(ttl time.Duration) Option {
+	return WithExpiry(func(_ string, _ proto.Message, changeTime time.Time) time.Time {
+		return changeTime.Add(ttl)
+	})
+}
+
+// trackExpiry records when the item identified by id expires, replacing any previous expiry, and reschedules the
+// removal of expired items if needed.
+// A nil it stops tracking id, for example because it has been removed.
+// c.mu must be held.
+func (c *Collection) trackExpiry(id string, it *item) {
+	if c.expiry == nil {
+		return
+	}
+	if old, ok := c.expiryEntries[id]; ok {
+		heap.Remove(&c.expiryQueue, old.index)
+		delete(c.expiryEntries, id)
+	}
+	if it != nil {
+		it.expireTime = c.expiry(id, it.body, it.changeTime)
+		if !it.expireTime.IsZero() {
+			if c.expiryEntries == nil {
+				c.expiryEntries = make(map[string]*expiryEntry)
+			}
+			e := &expiryEntry{id: id, t: it.expireTime}
+			heap.Push(&c.expiryQueue, e)
+			c.expiryEntries[id] = e
+		}
+	}
+	c.scheduleExpiry()
+}
+
+// scheduleExpiry arranges for removeExpired to be called when the next item expires.
+// Any scheduled call that is no longer needed, because no items expire or the next item expires at a different time,
+// is stopped so it doesn't keep c reachable.
+// c.mu must be held.
+func (c *Collection) scheduleExpiry() {
+	if c.removingExpired {
+		return // removeExpired schedules once it's done
+	}
+	if len(c.expiryQueue) == 0 {
+		if c.stopExpiry != nil {
+			c.stopExpiry()
+			c.stopExpiry = nil
+		}
+		return
+	}
+	next := c.expiryQueue[0].t
+	if c.stopExpiry != nil {
+		if next.Equal(c.nextExpiry) {
+			return // already scheduled
+		}
+		c.stopExpiry()
+	}
+	c.nextExpiry = next
+	c.stopExpiry = afterFunc(c.clock, next, c.removeExpired)
+}
+
+// expiryRetryDelay is how long removeExpired waits before trying again to remove an expired item it failed to remove
+// from the Store.
+const expiryRetryDelay = 5 * time.Second
+
+// removeExpired removes all items that have expired according to the configured clock.
+// Items that can't be removed from the Store stay in c and are tried again after expiryRetryDelay.
+func (c *Collection) removeExpired() {
+	c.mu.Lock()
+	c.stopExpiry = nil
+	c.removingExpired = true
+	now := c.clock.Now()
+	for len(c.expiryQueue) > 0 && !c.expiryQueue[0].t.After(now) {
+		e := heap.Pop(&c.expiryQueue).(*expiryEntry)
+		delete(c.expiryEntries, e.id)
+		if err := c.save(e.id, nil, now); err != nil {
+			log.Printf("WARN: resource.Collection failed to remove expired item %v from store, retrying in %v: %v", e.id, expiryRetryDelay, err)
+			e.t = now.Add(expiryRetryDelay)
+			heap.Push(&c.expiryQueue, e)
+			c.expiryEntries[e.id] = e
+			continue
+		}
+		c.queueLocked(c.apply(e.id, nil, now))
+	}
+	c.removingExpired = false
+	c.scheduleExpiry()
+	c.mu.Unlock()
+
+	c.flush()
+}
+
+type expiryEntry struct {
+	id    string
+	t     time.Time
+	index int // in expiryHeap, maintained by the heap
+}
+
+// expiryHeap is a min-heap of expiryEntry ordered by expiry time.
+// Entries are removed when the item they belong to changes or is removed, see Collection.trackExpiry.
+type expiryHeap []*expiryEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].t.Before(h[j].t) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *expiryHeap) Push(x any) {
+	e := x.(*expiryEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,191 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+	"github.com/smart-core-os/sc-golang/pkg/time/clock"
+)
+
+func TestWithTTL(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	c := NewCollection(WithClock(clock), WithTTL(10*time.Second), WithInitialRecord("a", &traits.OnOff{}))
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true))
+
+	clock.advance(5 * time.Second)
+	add(t, c, "b", &traits.OnOff{})
+	waitForChan(t, changes, time.Second) // the add
+	clock.advance(5 * time.Second)
+
+	change := waitForChan(t, changes, time.Second)
+	want := &CollectionChange{
+		Id:         "a",
+		ChangeTime: time.Unix(10, 0),
+		ChangeType: types.ChangeType_REMOVE,
+		OldValue:   &traits.OnOff{},
+		Seq:        2,
+	}
+	if diff := cmp.Diff(want, change, protocmp.Transform()); diff != "" {
+		t.Fatalf("REMOVE a (-want,+got)\n%s", diff)
+	}
+
+	// updating an item extends its life
+	if _, err := c.Update("b", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	waitForChan(t, changes, time.Second) // the update
+	clock.advance(5 * time.Second)
+	noEmitWithin(t, changes, 50*time.Millisecond)
+	if _, ok := c.Get("b"); !ok {
+		t.Fatalf("b should not have expired yet")
+	}
+	clock.advance(5 * time.Second)
+	change = waitForChan(t, changes, time.Second)
+	if change.Id != "b" || change.ChangeType != types.ChangeType_REMOVE {
+		t.Fatalf("want REMOVE b, got %v", change)
+	}
+	if got := c.List(); len(got) != 0 {
+		t.Fatalf("want empty collection, got %v", got)
+	}
+}
+
+func TestWithExpiry(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	// items with a level expire at that many seconds since the epoch, items without a level never expire
+	c := NewCollection(WithClock(clock), WithExpiry(func(_ string, item proto.Message, _ time.Time) time.Time {
+		level := item.(*traits.Brightness).LevelPercent
+		if level == 0 {
+			return time.Time{}
+		}
+		return time.Unix(int64(level), 0)
+	}))
+	add(t, c, "forever", &traits.Brightness{})
+	add(t, c, "5", &traits.Brightness{LevelPercent: 5})
+	add(t, c, "20", &traits.Brightness{LevelPercent: 20})
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true))
+
+	clock.advance(10 * time.Second)
+	change := waitForChan(t, changes, time.Second)
+	if change.Id != "5" || change.ChangeType != types.ChangeType_REMOVE {
+		t.Fatalf("want REMOVE 5, got %v", change)
+	}
+	clock.advance(100 * time.Second)
+	change = waitForChan(t, changes, time.Second)
+	if change.Id != "20" || change.ChangeType != types.ChangeType_REMOVE {
+		t.Fatalf("want REMOVE 20, got %v", change)
+	}
+	noEmitWithin(t, changes, 50*time.Millisecond)
+	if _, ok := c.Get("forever"); !ok {
+		t.Fatalf("forever should not expire")
+	}
+}
+
+// manualClock is a clock.Fake with helpers for tests.
+type manualClock struct {
+	*clock.Fake
+}
+
+func newManualClock(now time.Time) *manualClock {
+	return &manualClock{Fake: clock.NewFake(now)}
+}
+
+func (c *manualClock) advance(d time.Duration) {
+	c.Add(d)
+}
+
+// waitForTimer blocks until something is waiting for the clock to reach at.
+func (c *manualClock) waitForTimer(t *testing.T, at time.Time) {
+	t.Helper()
+	ctx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+	if err := c.BlockUntilAt(ctx, at); err != nil {
+		t.Fatalf("timeout waiting for a timer at %v", at)
+	}
+}
+
+func TestWithTTL_stopsTimers(t *testing.T) {
+	c := NewCollection(WithTTL(time.Hour))
+	add(t, c, "a", &traits.OnOff{})
+	add(t, c, "b", &traits.OnOff{})
+	if _, err := c.Update("a", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	c.mu.RLock()
+	tracked := len(c.expiryQueue)
+	c.mu.RUnlock()
+	if tracked != 2 {
+		t.Fatalf("want 2 tracked expiries after replacing one, got %d", tracked)
+	}
+	for _, id := range []string{"a", "b"} {
+		if _, err := c.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.expiryQueue) != 0 || len(c.expiryEntries) != 0 {
+		t.Fatalf("want no tracked expiries once all items are removed, got %d", len(c.expiryQueue))
+	}
+	if c.stopExpiry != nil {
+		t.Fatalf("want no scheduled removal once all items are removed")
+	}
+}
+
+func TestWithTTL_storeFailure(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	store := &failingStore{}
+	c := NewCollection(WithClock(clock), WithTTL(10*time.Second), WithStore(store))
+	add(t, c, "a", &traits.OnOff{})
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true))
+
+	store.failDelete.Store(true)
+	clock.waitForTimer(t, time.Unix(10, 0))
+	clock.advance(10 * time.Second)
+	retry := time.Unix(10, 0).Add(expiryRetryDelay)
+	clock.waitForTimer(t, retry)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("a should not be removed while the store fails")
+	}
+
+	store.failDelete.Store(false)
+	clock.advance(expiryRetryDelay)
+	change := waitForChan(t, changes, time.Second)
+	if change.Id != "a" || change.ChangeType != types.ChangeType_REMOVE {
+		t.Fatalf("want REMOVE a, got %v", change)
+	}
+	if !change.ChangeTime.Equal(retry) {
+		t.Fatalf("want removed at %v, got %v", retry, change.ChangeTime)
+	}
+}
+
+// failingStore is a Store that holds nothing, failing Delete while failDelete is set.
+type failingStore struct {
+	failDelete atomic.Bool
+}
+
+func (s *failingStore) Load() ([]StoreRecord, error) { return nil, nil }
+func (s *failingStore) Save(StoreRecord) error       { return nil }
+func (s *failingStore) Delete(string) error {
+	if s.failDelete.Load() {
+		return errors.New("delete failed")
+	}
+	return nil
+}
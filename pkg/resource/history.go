@@ -0,0 +1,240 @@
+package resource
+
+import (
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/smart-core-os/sc-api/go/types"
+	timepb "github.com/smart-core-os/sc-api/go/types/time"
+
+	sctime "github.com/smart-core-os/sc-golang/pkg/time"
+)
+
+// WithHistoryCapacity configures the resource to retain the last capacity changes made to it.
+// Retained changes can be queried using ListHistory or replayed during Pull using WithReplaySince.
+// Combine with WithHistoryMaxAge to also discard changes older than a certain age.
+func WithHistoryCapacity(capacity int) Option {
+	return optionFunc(func(s *config) {
+		s.historyCapacity = capacity
+	})
+}
+
+// WithHistoryMaxAge configures the resource to retain all changes made to it within maxAge of now.
+// Retained changes can be queried using ListHistory or replayed during Pull using WithReplaySince.
+// Combine with WithHistoryCapacity to bound the number of changes retained.
+func WithHistoryMaxAge(maxAge time.Duration) Option {
+	return optionFunc(func(s *config) {
+		s.historyMaxAge = maxAge
+	})
+}
+
+// WithReplaySince instructs Pull to emit all retained history with a ChangeTime on or after since, before the seed
+// values and live updates.
+// Replayed changes are reported in the order they were made, as if they were live changes, they are not seed values.
+// The seed values that follow describe the current state of the resource, which the replayed changes lead up to.
+// Combine with WithUpdatesOnly to receive only the replayed changes and later live changes.
+// If the resource has no history configured, or no retained changes match, Pull behaves as if this option were not
+// specified.
+func WithReplaySince(since time.Time) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.ReplaySince = since
+	})
+}
+
+// HistoryRequest describes a query against the history retained by a resource.
+type HistoryRequest struct {
+	// Period restricts the returned records to those whose ChangeTime is enclosed by Period.
+	// A nil Period returns all retained records.
+	Period *timepb.Period
+	// PageSize is the maximum number of records returned.
+	// Zero or negative means no limit.
+	PageSize int
+	// PageToken is the NextPageToken from a previous query, used to fetch the next page of records.
+	PageToken string
+}
+
+// HistoryNotEnabled is returned when querying the history of a resource that was not configured to retain history.
+var HistoryNotEnabled = status.Error(codes.FailedPrecondition, "history is not enabled")
+
+// HistoryInfo describes the paging state of a response to a history query.
+type HistoryInfo struct {
+	// NextPageToken can be used in a subsequent HistoryRequest to fetch the next page.
+	// Empty if there are no more records.
+	NextPageToken string
+	// TotalSize is the number of retained records that match the request Period.
+	TotalSize int
+}
+
+// history retains recent changes to a resource.
+// It is a ring buffer bounded by a capacity, max age, or both.
+// history is not safe for concurrent use.
+type history[T any] struct {
+	capacity int
+	maxAge   time.Duration
+
+	buf     []historyRecord[T]
+	head, n int
+	nextSeq uint64
+}
+
+type historyRecord[T any] struct {
+	seq        uint64
+	changeTime time.Time
+	change     T
+}
+
+// newHistory returns a history configured from c, or nil if c does not enable history.
+func newHistory[T any](c *config) *history[T] {
+	if c.historyCapacity <= 0 && c.historyMaxAge <= 0 {
+		return nil
+	}
+	return &history[T]{capacity: c.historyCapacity, maxAge: c.historyMaxAge}
+}
+
+// add appends change to the history, discarding the oldest record if needed.
+func (h *history[T]) add(changeTime time.Time, change T) {
+	h.nextSeq++
+	r := historyRecord[T]{seq: h.nextSeq, changeTime: changeTime, change: change}
+	if h.n == len(h.buf) {
+		if h.capacity > 0 && h.n >= h.capacity {
+			h.buf[h.head] = r
+			h.head = (h.head + 1) % len(h.buf)
+			return
+		}
+		h.grow()
+	}
+	h.buf[(h.head+h.n)%len(h.buf)] = r
+	h.n++
+}
+
+func (h *history[T]) grow() {
+	size := 2 * len(h.buf)
+	if size == 0 {
+		size = 8
+	}
+	if h.capacity > 0 && size > h.capacity {
+		size = h.capacity
+	}
+	buf := make([]historyRecord[T], size)
+	for i := 0; i < h.n; i++ {
+		buf[i] = h.at(i)
+	}
+	h.buf = buf
+	h.head = 0
+}
+
+// prune removes all records that are older than maxAge relative to now.
+func (h *history[T]) prune(now time.Time) {
+	if h.maxAge <= 0 {
+		return
+	}
+	cutoff := now.Add(-h.maxAge)
+	for h.n > 0 && h.at(0).changeTime.Before(cutoff) {
+		var zero historyRecord[T]
+		h.buf[h.head] = zero // allow gc
+		h.head = (h.head + 1) % len(h.buf)
+		h.n--
+	}
+}
+
+func (h *history[T]) at(i int) historyRecord[T] {
+	return h.buf[(h.head+i)%len(h.buf)]
+}
+
+// first returns the index of the first record that has not expired relative to now.
+func (h *history[T]) first(now time.Time) int {
+	if h.maxAge <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-h.maxAge)
+	for i := 0; i < h.n; i++ {
+		if !h.at(i).changeTime.Before(cutoff) {
+			return i
+		}
+	}
+	return h.n
+}
+
+// since returns all unexpired changes with a ChangeTime on or after t, oldest first.
+func (h *history[T]) since(now, t time.Time) []T {
+	var res []T
+	for i := h.first(now); i < h.n; i++ {
+		r := h.at(i)
+		if r.changeTime.Before(t) {
+			continue
+		}
+		res = append(res, r.change)
+	}
+	return res
+}
+
+// list returns unexpired records matching req, oldest first.
+// include, if not nil, further restricts or adjusts the records returned.
+func (h *history[T]) list(now time.Time, req HistoryRequest, include func(T) (T, bool)) ([]T, HistoryInfo, error) {
+	var afterSeq uint64
+	if req.PageToken != "" {
+		var err error
+		afterSeq, err = decodeHistoryPageToken(req.PageToken)
+		if err != nil {
+			return nil, HistoryInfo{}, err
+		}
+	}
+
+	var (
+		res     []T
+		info    HistoryInfo
+		lastSeq uint64
+	)
+	for i := h.first(now); i < h.n; i++ {
+		r := h.at(i)
+		if !sctime.PeriodContains(req.Period, timestamppb.New(r.changeTime)) {
+			continue
+		}
+		if include != nil {
+			var ok bool
+			if r.change, ok = include(r.change); !ok {
+				continue
+			}
+		}
+		info.TotalSize++
+		if r.seq <= afterSeq {
+			continue
+		}
+		if req.PageSize > 0 && len(res) >= req.PageSize {
+			info.NextPageToken = encodeHistoryPageToken(lastSeq)
+			continue // keep counting the total size
+		}
+		res = append(res, r.change)
+		lastSeq = r.seq
+	}
+	return res, info, nil
+}
+
+func encodeHistoryPageToken(seq uint64) string {
+	tokenBytes, _ := proto.Marshal(&types.PageToken{
+		PageStart: &types.PageToken_LastResourceName{LastResourceName: strconv.FormatUint(seq, 10)},
+	})
+	return base64.StdEncoding.EncodeToString(tokenBytes)
+}
+
+func decodeHistoryPageToken(token string) (uint64, error) {
+	tokenBytes, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "bad page token: %v", err)
+	}
+	pageToken := &types.PageToken{}
+	if err := proto.Unmarshal(tokenBytes, pageToken); err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "bad page token: %v", err)
+	}
+	seq, err := strconv.ParseUint(pageToken.GetLastResourceName(), 10, 64)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "bad page token: %v", err)
+	}
+	return seq, nil
+}
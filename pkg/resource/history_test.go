@@ -0,0 +1,177 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+	timepb "github.com/smart-core-os/sc-api/go/types/time"
+)
+
+func TestHistory_add(t *testing.T) {
+	h := &history[int]{capacity: 3}
+	for i := 0; i < 5; i++ {
+		h.add(time.Unix(int64(i), 0), i)
+	}
+	got := h.since(time.Time{}, time.Time{})
+	if diff := cmp.Diff([]int{2, 3, 4}, got); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+
+	h = &history[int]{maxAge: 10 * time.Second}
+	for i := 0; i < 20; i++ {
+		h.add(time.Unix(int64(i), 0), i)
+	}
+	h.prune(time.Unix(20, 0))
+	got = h.since(time.Unix(20, 0), time.Time{})
+	if diff := cmp.Diff([]int{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, got); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+	// expired records are not returned, even if they haven't been pruned
+	got = h.since(time.Unix(25, 0), time.Unix(12, 0))
+	if diff := cmp.Diff([]int{15, 16, 17, 18, 19}, got); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+}
+
+func TestValue_ListHistory(t *testing.T) {
+	now := time.Unix(0, 0)
+	clock := clockFunc(func() time.Time {
+		return now
+	})
+	v := NewValue(WithClock(clock), WithHistoryCapacity(10), WithInitialValue(&traits.Brightness{LevelPercent: 0}))
+	for i := 1; i < 15; i++ {
+		now = time.Unix(int64(i), 0)
+		if _, err := v.Set(&traits.Brightness{LevelPercent: float32(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := HistoryRequest{
+		Period:   &timepb.Period{StartTime: timestamppb.New(time.Unix(6, 0)), EndTime: timestamppb.New(time.Unix(12, 0))},
+		PageSize: 4,
+	}
+	page1, info, err := v.ListHistory(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.TotalSize != 6 {
+		t.Fatalf("TotalSize want 6, got %d", info.TotalSize)
+	}
+	if info.NextPageToken == "" {
+		t.Fatalf("NextPageToken should not be empty")
+	}
+	req.PageToken = info.NextPageToken
+	page2, info, err := v.ListHistory(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NextPageToken != "" {
+		t.Fatalf("NextPageToken should be empty, got %q", info.NextPageToken)
+	}
+
+	var got []float32
+	for _, change := range append(page1, page2...) {
+		got = append(got, change.Value.(*traits.Brightness).LevelPercent)
+	}
+	if diff := cmp.Diff([]float32{6, 7, 8, 9, 10, 11}, got); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+
+	_, _, err = NewValue().ListHistory(HistoryRequest{})
+	if err != HistoryNotEnabled {
+		t.Fatalf("want HistoryNotEnabled, got %v", err)
+	}
+}
+
+func TestValue_Pull_replay(t *testing.T) {
+	now := time.Unix(0, 0)
+	clock := clockFunc(func() time.Time {
+		return now
+	})
+	v := NewValue(WithClock(clock), WithHistoryMaxAge(time.Minute), WithInitialValue(&traits.OnOff{State: traits.OnOff_ON}))
+	now = time.Unix(10, 0)
+	_, _ = v.Set(&traits.OnOff{State: traits.OnOff_OFF})
+	now = time.Unix(20, 0)
+	_, _ = v.Set(&traits.OnOff{State: traits.OnOff_ON})
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := v.Pull(ctx, WithReplaySince(time.Unix(5, 0)))
+	want := []*ValueChange{
+		{Value: &traits.OnOff{State: traits.OnOff_OFF}, ChangeTime: time.Unix(10, 0), Version: "1"},
+		{Value: &traits.OnOff{State: traits.OnOff_ON}, ChangeTime: time.Unix(20, 0), Version: "2"},
+		{Value: &traits.OnOff{State: traits.OnOff_ON}, ChangeTime: time.Unix(20, 0), SeedValue: true, LastSeedValue: true, Version: "2"},
+	}
+	for i, w := range want {
+		got := waitForChan(t, changes, time.Second)
+		if diff := cmp.Diff(w, got, protocmp.Transform()); diff != "" {
+			t.Fatalf("change %d (-want,+got)\n%s", i, diff)
+		}
+	}
+
+	now = time.Unix(30, 0)
+	_, _ = v.Set(&traits.OnOff{State: traits.OnOff_OFF})
+	got := waitForChan(t, changes, time.Second)
+	if diff := cmp.Diff(&ValueChange{Value: &traits.OnOff{State: traits.OnOff_OFF}, ChangeTime: now, Version: "3"}, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("live change (-want,+got)\n%s", diff)
+	}
+}
+
+func TestCollection_Pull_replay(t *testing.T) {
+	now := time.Unix(0, 0)
+	clock := clockFunc(func() time.Time {
+		return now
+	})
+	c := NewCollection(WithClock(clock), WithHistoryCapacity(10), WithInitialRecord("a", &traits.OnOff{State: traits.OnOff_ON}))
+	now = time.Unix(10, 0)
+	add(t, c, "b", &traits.OnOff{State: traits.OnOff_ON})
+	now = time.Unix(20, 0)
+	_, _ = c.Update("a", &traits.OnOff{State: traits.OnOff_OFF})
+	now = time.Unix(30, 0)
+	_, _ = c.Delete("b")
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithReplaySince(time.Unix(10, 0)))
+	want := []*CollectionChange{
+		// replayed changes in the order they were made, then the seed values holding the current state
+		{Id: "b", ChangeTime: time.Unix(10, 0), ChangeType: types.ChangeType_ADD, NewValue: &traits.OnOff{State: traits.OnOff_ON}, Version: "1"},
+		{Id: "a", ChangeTime: time.Unix(20, 0), ChangeType: types.ChangeType_UPDATE, OldValue: &traits.OnOff{State: traits.OnOff_ON}, NewValue: &traits.OnOff{State: traits.OnOff_OFF}, Version: "2"},
+		{Id: "b", ChangeTime: time.Unix(30, 0), ChangeType: types.ChangeType_REMOVE, OldValue: &traits.OnOff{State: traits.OnOff_ON}},
+		{Id: "a", ChangeTime: time.Unix(20, 0), ChangeType: types.ChangeType_ADD, NewValue: &traits.OnOff{State: traits.OnOff_OFF}, SeedValue: true, LastSeedValue: true, Seq: 3, Version: "2"},
+	}
+	for i, w := range want {
+		got := waitForChan(t, changes, time.Second)
+		if diff := cmp.Diff(w, got, protocmp.Transform()); diff != "" {
+			t.Fatalf("change %d (-want,+got)\n%s", i, diff)
+		}
+	}
+
+	// no seed values, only the replay, the last replayed change has the Seq instead
+	changes = c.Pull(ctx, WithReplaySince(time.Unix(10, 0)), WithUpdatesOnly(true))
+	want = want[:3]
+	lastReplay := *want[2]
+	lastReplay.Seq = 3
+	want[2] = &lastReplay
+	for i, w := range want {
+		got := waitForChan(t, changes, time.Second)
+		if diff := cmp.Diff(w, got, protocmp.Transform()); diff != "" {
+			t.Fatalf("updates only change %d (-want,+got)\n%s", i, diff)
+		}
+	}
+
+	all, info, err := c.ListHistory(HistoryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 || info.TotalSize != 4 {
+		t.Fatalf("want 4 history records, got %d (total %d)", len(all), info.TotalSize)
+	}
+}
@@ -0,0 +1,68 @@
+package resource
+
+import (
+	"strings"
+)
+
+// WithIDs instructs Collection List or Pull to only include items with one of the given ids.
+// Pull sends seed values for the matching items that exist, and changes to items as they are added, updated, or
+// removed.
+// Multiple WithIDs and WithIDPrefix options combine, an item is included if it matches any of them.
+// Can be combined with WithInclude or WithIndexLookup to further restrict the items returned.
+// Ids are passed through the Collection's IDInterceptor, if any.
+func WithIDs(ids ...string) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.IDs = append(rr.IDs, ids...)
+	})
+}
+
+// WithIDPrefix instructs Collection List or Pull to only include items whose id starts with one of prefixes.
+// For example WithIDPrefix("building/floor3/") includes "building/floor3/room1" but not "building/floor30/room1".
+// See WithIDs for how this combines with other options.
+// Unlike ids, prefixes are not passed through the Collection's IDInterceptor.
+func WithIDPrefix(prefixes ...string) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.IDPrefixes = append(rr.IDPrefixes, prefixes...)
+	})
+}
+
+// idFilter matches ids against the ReadRequest.IDs and ReadRequest.IDPrefixes.
+type idFilter struct {
+	ids      map[string]struct{}
+	prefixes []string
+}
+
+// idFilter returns the idFilter described by readConfig, or nil if readConfig doesn't restrict ids.
+func (c *Collection) idFilter(readConfig *ReadRequest) *idFilter {
+	if len(readConfig.IDs) == 0 && len(readConfig.IDPrefixes) == 0 {
+		return nil
+	}
+	f := &idFilter{prefixes: readConfig.IDPrefixes}
+	if len(readConfig.IDs) > 0 {
+		f.ids = make(map[string]struct{}, len(readConfig.IDs))
+		for _, id := range readConfig.IDs {
+			if c.idInterceptor != nil {
+				id = c.idInterceptor(id)
+			}
+			f.ids[id] = struct{}{}
+		}
+	}
+	return f
+}
+
+func (f *idFilter) matches(id string) bool {
+	if _, ok := f.ids[id]; ok {
+		return true
+	}
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// exact returns the ids f matches if it matches no prefixes, allowing lookup instead of scanning all items.
+func (f *idFilter) exact() (map[string]struct{}, bool) {
+	return f.ids, len(f.prefixes) == 0
+}
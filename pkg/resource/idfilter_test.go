@@ -0,0 +1,107 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestWithIDs_Pull(t *testing.T) {
+	c := NewCollection()
+	add(t, c, "a", &traits.OnOff{})
+	add(t, c, "b", &traits.OnOff{})
+	add(t, c, "floor3/x", &traits.OnOff{})
+	add(t, c, "floor30/x", &traits.OnOff{})
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithIDs("a", "missing"), WithIDPrefix("floor3/"))
+
+	type seed struct {
+		id   string
+		last bool
+	}
+	var got []seed
+	for range 2 {
+		change := waitForChan(t, changes, time.Second)
+		got = append(got, seed{change.Id, change.LastSeedValue})
+	}
+	if diff := cmp.Diff([]seed{{"a", false}, {"floor3/x", true}}, got, cmp.AllowUnexported(seed{})); diff != "" {
+		t.Fatalf("seeds (-want,+got)\n%s", diff)
+	}
+
+	// changes outside the watched set are not sent
+	if _, err := c.Update("b", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	add(t, c, "floor30/y", &traits.OnOff{})
+	add(t, c, "missing", &traits.OnOff{})
+	if change := waitForChan(t, changes, time.Second); change.Id != "missing" || change.ChangeType != types.ChangeType_ADD {
+		t.Fatalf("want ADD missing, got %v", change)
+	}
+	add(t, c, "floor3/y", &traits.OnOff{})
+	if change := waitForChan(t, changes, time.Second); change.Id != "floor3/y" || change.ChangeType != types.ChangeType_ADD {
+		t.Fatalf("want ADD floor3/y, got %v", change)
+	}
+	if _, err := c.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if change := waitForChan(t, changes, time.Second); change.Id != "a" || change.ChangeType != types.ChangeType_REMOVE {
+		t.Fatalf("want REMOVE a, got %v", change)
+	}
+}
+
+func TestWithIDs_include(t *testing.T) {
+	c := NewCollection()
+	add(t, c, "a", &traits.OnOff{State: traits.OnOff_ON})
+	add(t, c, "b", &traits.OnOff{State: traits.OnOff_ON})
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	isOn := func(_ string, item proto.Message) bool {
+		return item.(*traits.OnOff).State == traits.OnOff_ON
+	}
+	changes := c.Pull(ctx, WithIDs("a"), WithInclude(isOn), WithUpdatesOnly(true))
+
+	// a moves out of the watched set, then back in
+	if _, err := c.Update("a", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+	if change := waitForChan(t, changes, time.Second); change.Id != "a" || change.ChangeType != types.ChangeType_REMOVE {
+		t.Fatalf("want REMOVE a, got %v", change)
+	}
+	if _, err := c.Update("b", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Update("a", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	if change := waitForChan(t, changes, time.Second); change.Id != "a" || change.ChangeType != types.ChangeType_ADD {
+		t.Fatalf("want ADD a, got %v", change)
+	}
+}
+
+func TestWithIDs_List(t *testing.T) {
+	c := NewCollection(WithIDInterceptor(func(oldID string) string {
+		return "site/" + oldID
+	}))
+	add(t, c, "a", &traits.OnOff{State: traits.OnOff_ON})
+	add(t, c, "b", &traits.OnOff{})
+	add(t, c, "c", &traits.OnOff{})
+
+	got := c.List(WithIDs("a", "c"))
+	want := []proto.Message{&traits.OnOff{State: traits.OnOff_ON}, &traits.OnOff{}}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("WithIDs (-want,+got)\n%s", diff)
+	}
+	if got := c.List(WithIDPrefix("site/b")); len(got) != 1 {
+		t.Fatalf("WithIDPrefix want 1 item, got %v", got)
+	}
+}
@@ -0,0 +1,247 @@
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// IndexFunc returns the keys an item in a Collection should be indexed under.
+// An item may be indexed under zero or more keys.
+type IndexFunc func(id string, item proto.Message) []string
+
+// WithIndex configures a Collection to maintain a secondary index called name, with keys computed using fn.
+// Use WithIndexLookup to restrict List or Pull to items with specific keys, without scanning every item.
+// The index is updated whenever an item is added, updated, or removed.
+// Applicable only to Collection.
+func WithIndex(name string, fn IndexFunc) Option {
+	return optionFunc(func(s *config) {
+		if s.indexes == nil {
+			s.indexes = make(map[string]IndexFunc)
+		}
+		s.indexes[name] = fn
+	})
+}
+
+// WithFieldIndex is like WithIndex using the value of the field at path as the index key.
+// The index is named path.
+// Path is a dot separated list of field names, for example "membership.subsystem".
+// Scalar and enum fields are supported, repeated fields index the item under each element.
+// Items where the field is not set, including proto3 scalar fields with their default value, are not indexed.
+// Applicable only to Collection.
+func WithFieldIndex(path string) Option {
+	return WithIndex(path, func(_ string, item proto.Message) []string {
+		return fieldKeys(item.ProtoReflect(), strings.Split(path, "."))
+	})
+}
+
+// WithIndexLookup instructs Collection List or Pull to only include items indexed under one of keys in the index
+// called name.
+// If the collection has no index called name, see WithIndex, no items are included and methods that return an error,
+// like ListPage, return an error with codes.InvalidArgument.
+// Can be combined with WithInclude to further restrict the items returned.
+func WithIndexLookup(name string, keys ...string) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.IndexName = name
+		rr.IndexKeys = keys
+	})
+}
+
+// index is a secondary index over the items in a Collection.
+type index struct {
+	fn    IndexFunc
+	byKey map[string]map[string]struct{} // key -> set of ids
+	byId  map[string][]string            // id -> keys
+}
+
+func newIndexes(fns map[string]IndexFunc) map[string]*index {
+	if len(fns) == 0 {
+		return nil
+	}
+	res := make(map[string]*index, len(fns))
+	for name, fn := range fns {
+		res[name] = &index{
+			fn:    fn,
+			byKey: make(map[string]map[string]struct{}),
+			byId:  make(map[string][]string),
+		}
+	}
+	return res
+}
+
+// update replaces the keys recorded for id with those computed from msg.
+// A nil msg removes id from the index.
+func (idx *index) update(id string, msg proto.Message) {
+	for _, key := range idx.byId[id] {
+		ids := idx.byKey[key]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx.byKey, key)
+		}
+	}
+	delete(idx.byId, id)
+	if msg == nil {
+		return
+	}
+	keys := idx.fn(id, msg)
+	if len(keys) == 0 {
+		return
+	}
+	idx.byId[id] = keys
+	for _, key := range keys {
+		ids, ok := idx.byKey[key]
+		if !ok {
+			ids = make(map[string]struct{})
+			idx.byKey[key] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// lookup returns the ids of all items indexed under any of keys, in no particular order.
+func (idx *index) lookup(keys []string) []string {
+	if len(keys) == 1 {
+		ids := make([]string, 0, len(idx.byKey[keys[0]]))
+		for id := range idx.byKey[keys[0]] {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, key := range keys {
+		for id := range idx.byKey[key] {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// matches returns true if msg would be indexed under any of keys.
+func (idx *index) matches(id string, msg proto.Message, keys []string) bool {
+	for _, k := range idx.fn(id, msg) {
+		for _, key := range keys {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateIndexes records msg in all the indexes of c.
+// A nil msg removes id from all indexes.
+// c.mu must be held.
+func (c *Collection) updateIndexes(id string, msg proto.Message) {
+	for _, idx := range c.indexes {
+		idx.update(id, msg)
+	}
+}
+
+// unknownIndex is used for lookups against an index that a Collection doesn't have, it matches no items.
+var unknownIndex = &index{fn: func(string, proto.Message) []string { return nil }}
+
+// index returns the index named by readConfig, or nil if readConfig doesn't reference an index.
+// Returns unknownIndex if the named index doesn't exist.
+func (c *Collection) index(readConfig *ReadRequest) *index {
+	if readConfig.IndexName == "" {
+		return nil
+	}
+	idx, ok := c.indexes[readConfig.IndexName]
+	if !ok {
+		return unknownIndex
+	}
+	return idx
+}
+
+// checkIndex returns an error if readConfig references an index that c doesn't have.
+func (c *Collection) checkIndex(readConfig *ReadRequest) error {
+	if c.index(readConfig) == unknownIndex {
+		return status.Errorf(codes.InvalidArgument, "unknown index %q", readConfig.IndexName)
+	}
+	return nil
+}
+
+// includeFunc returns a FilterFunc combining readConfig.Include, any index lookup, and any id restrictions.
+// Returns nil if all items should be included.
+func (c *Collection) includeFunc(readConfig *ReadRequest) FilterFunc {
+	include := readConfig.Include
+	if idx := c.index(readConfig); idx != nil {
+		next := include
+		keys := readConfig.IndexKeys
+		include = func(id string, item proto.Message) bool {
+			if item == nil || !idx.matches(id, item, keys) {
+				return false
+			}
+			return next == nil || next(id, item)
+		}
+	}
+	if ids := c.idFilter(readConfig); ids != nil {
+		next := include
+		include = func(id string, item proto.Message) bool {
+			if !ids.matches(id) {
+				return false
+			}
+			return next == nil || next(id, item)
+		}
+	}
+	return include
+}
+
+// fieldKeys returns the string form of the field at path in msg.
+func fieldKeys(msg protoreflect.Message, path []string) []string {
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(path[0]))
+	if fd == nil || !msg.Has(fd) {
+		return nil
+	}
+	v := msg.Get(fd)
+	if len(path) > 1 {
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return nil
+		}
+		return fieldKeys(v.Message(), path[1:])
+	}
+	if fd.IsMap() {
+		return nil
+	}
+	if fd.IsList() {
+		list := v.List()
+		var keys []string
+		for i := 0; i < list.Len(); i++ {
+			if key, ok := scalarKey(fd, list.Get(i)); ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	if key, ok := scalarKey(fd, v); ok {
+		return []string{key}
+	}
+	return nil
+}
+
+func scalarKey(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, bool) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return "", false
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), true
+		}
+		return fmt.Sprint(v.Enum()), true
+	case protoreflect.BytesKind:
+		return string(v.Bytes()), true
+	default:
+		return v.String(), true
+	}
+}
@@ -0,0 +1,179 @@
+package resource
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestWithIndex(t *testing.T) {
+	c := NewCollection(
+		WithFieldIndex("membership.subsystem"),
+		WithInitialRecord("a", &traits.Metadata{Name: "a", Membership: &traits.Metadata_Membership{Subsystem: "lighting"}}),
+		WithInitialRecord("b", &traits.Metadata{Name: "b", Membership: &traits.Metadata_Membership{Subsystem: "hvac"}}),
+		WithInitialRecord("c", &traits.Metadata{Name: "c"}),
+	)
+	add(t, c, "d", &traits.Metadata{Name: "d", Membership: &traits.Metadata_Membership{Subsystem: "lighting"}})
+
+	ids := func(msgs []proto.Message) []string {
+		var res []string
+		for _, msg := range msgs {
+			res = append(res, msg.(*traits.Metadata).Name)
+		}
+		return res
+	}
+	if diff := cmp.Diff([]string{"a", "d"}, ids(c.List(WithIndexLookup("membership.subsystem", "lighting")))); diff != "" {
+		t.Fatalf("lighting (-want,+got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "d"}, ids(c.List(WithIndexLookup("membership.subsystem", "lighting", "hvac")))); diff != "" {
+		t.Fatalf("lighting,hvac (-want,+got)\n%s", diff)
+	}
+	withInclude := c.List(WithIndexLookup("membership.subsystem", "lighting"), WithInclude(func(id string, _ proto.Message) bool {
+		return id != "a"
+	}))
+	if diff := cmp.Diff([]string{"d"}, ids(withInclude)); diff != "" {
+		t.Fatalf("lighting with include (-want,+got)\n%s", diff)
+	}
+
+	// updates move items between keys
+	if _, err := c.Update("a", &traits.Metadata{Name: "a", Membership: &traits.Metadata_Membership{Subsystem: "hvac"}}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"d"}, ids(c.List(WithIndexLookup("membership.subsystem", "lighting")))); diff != "" {
+		t.Fatalf("lighting after update (-want,+got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, ids(c.List(WithIndexLookup("membership.subsystem", "hvac")))); diff != "" {
+		t.Fatalf("hvac after update (-want,+got)\n%s", diff)
+	}
+	// deletes remove items from the index
+	if _, err := c.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a"}, ids(c.List(WithIndexLookup("membership.subsystem", "hvac")))); diff != "" {
+		t.Fatalf("hvac after delete (-want,+got)\n%s", diff)
+	}
+}
+
+func TestWithIndex_Pull(t *testing.T) {
+	c := NewCollection(
+		WithIndex("state", func(_ string, item proto.Message) []string {
+			return []string{item.(*traits.OnOff).State.String()}
+		}),
+		WithInitialRecord("a", &traits.OnOff{State: traits.OnOff_ON}),
+		WithInitialRecord("b", &traits.OnOff{State: traits.OnOff_OFF}),
+	)
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithIndexLookup("state", "ON"))
+
+	change := waitForChan(t, changes, time.Second)
+	if change.Id != "a" || !change.LastSeedValue {
+		t.Fatalf("want seed a, got %v", change)
+	}
+
+	// changes to items that aren't indexed under ON are not emitted
+	if _, err := c.Update("b", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+	noEmitWithin(t, changes, 50*time.Millisecond)
+
+	// items moving into the index are ADDs
+	if _, err := c.Update("b", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	change = waitForChan(t, changes, time.Second)
+	want := &CollectionChange{
+		Id:         "b",
+		ChangeTime: change.ChangeTime,
+		ChangeType: types.ChangeType_ADD,
+		NewValue:   &traits.OnOff{State: traits.OnOff_ON},
+		Seq:        change.Seq,
+		Version:    formatVersion(change.Seq),
+	}
+	if diff := cmp.Diff(want, change, protocmp.Transform()); diff != "" {
+		t.Fatalf("ADD b (-want,+got)\n%s", diff)
+	}
+
+	// items moving out of the index are REMOVEs
+	if _, err := c.Update("a", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+	change = waitForChan(t, changes, time.Second)
+	if change.Id != "a" || change.ChangeType != types.ChangeType_REMOVE {
+		t.Fatalf("want REMOVE a, got %v", change)
+	}
+}
+
+func TestWithIndexLookup_unknown(t *testing.T) {
+	c := NewCollection(WithInitialRecord("a", &traits.OnOff{}))
+	if got := c.List(WithIndexLookup("missing", "key")); len(got) != 0 {
+		t.Fatalf("want no items, got %v", got)
+	}
+	if _, err := c.ListPage(10, "", WithIndexLookup("missing", "key")); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ListPage want InvalidArgument, got %v", err)
+	}
+}
+
+func TestTransaction_ListItems_indexLookup(t *testing.T) {
+	member := func(name, subsystem string) *traits.Metadata {
+		return &traits.Metadata{Name: name, Membership: &traits.Metadata_Membership{Subsystem: subsystem}}
+	}
+	c := NewCollection(WithFieldIndex("membership.subsystem"), WithInitialRecord("a", member("a", "lighting")))
+	err := Transact(func(tx *Transaction) error {
+		if _, err := tx.AddItem(c, "b", member("b", "lighting")); err != nil {
+			return err
+		}
+		if _, err := tx.UpdateItem(c, "a", member("a", "hvac"), WithAllFieldsWritable()); err != nil {
+			return err
+		}
+		var got []string
+		for _, msg := range tx.ListItems(c, WithIndexLookup("membership.subsystem", "lighting")) {
+			got = append(got, msg.(*traits.Metadata).Name)
+		}
+		if diff := cmp.Diff([]string{"b"}, got); diff != "" {
+			t.Errorf("ListItems (-want,+got)\n%s", diff)
+		}
+		return nil
+	}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_fieldKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  proto.Message
+		path string
+		want []string
+	}{
+		{"string", &traits.Metadata{Name: "n"}, "name", []string{"n"}},
+		{"unset", &traits.Metadata{}, "name", nil},
+		{"nested", &traits.Metadata{Membership: &traits.Metadata_Membership{Group: "g"}}, "membership.group", []string{"g"}},
+		{"nested unset", &traits.Metadata{}, "membership.group", nil},
+		{"enum", &traits.OnOff{State: traits.OnOff_ON}, "state", []string{"ON"}},
+		{"repeated", &traits.Metadata_NIC{Dns: []string{"b", "a"}}, "dns", []string{"a", "b"}},
+		{"message", &traits.Metadata{Traits: []*traits.TraitMetadata{{Name: "x"}}}, "traits", nil},
+		{"unknown field", &traits.Metadata{Name: "n"}, "nope", nil},
+		{"number", &traits.Brightness{LevelPercent: 12.5}, "level_percent", []string{"12.5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldKeys(tt.msg.ProtoReflect(), strings.Split(tt.path, "."))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("(-want,+got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -30,6 +30,8 @@ func (e EmptyOption) apply(_ *config) {
 
 // WithClock configures the clock used when time is needed.
 // Defaults to a Clock backed by the time package.
+// If c also implements clock.Clock, for example clock.Fake, it is used for timers like those needed by WithExpiry,
+// WithDebounce, WithSubscriberTimeout, and Animate, otherwise timers use the time package.
 func WithClock(c Clock) Option {
 	return optionFunc(func(s *config) {
 		s.clock = c
@@ -121,6 +123,21 @@ type config struct {
 	initialRecords map[string]proto.Message
 	writableFields *fieldmaskpb.FieldMask
 	idInterceptor  IDInterceptor
+	store          Store
+
+	historyCapacity int
+	historyMaxAge   time.Duration
+
+	expiry ExpiryFunc
+
+	indexes map[string]IndexFunc
+
+	validation []ValidationRule
+
+	maxSubscribers          int
+	subscriberTimeout       time.Duration
+	slowSubscriberThreshold time.Duration
+	slowSubscriberFunc      func(SubscriberStats)
 }
 
 func computeConfig(opts ...Option) *config {
@@ -218,8 +235,35 @@ type ReadRequest struct {
 
 	UpdatesOnly  bool
 	Backpressure bool
+	ReplaySince  time.Time
+	// Resume and ResumeAfter configure a Collection Pull to resume from a previous Pull, see WithResumeAfter.
+	Resume      bool
+	ResumeAfter uint64
 
 	Include FilterFunc
+
+	// IndexName and IndexKeys restrict collection reads to items in the named index under any of the keys.
+	// See WithIndexLookup.
+	IndexName string
+	IndexKeys []string
+
+	// IDs and IDPrefixes restrict collection reads to items with matching ids, see WithIDs and WithIDPrefix.
+	IDs        []string
+	IDPrefixes []string
+
+	// SortKey orders collection List results, see WithSortKey.
+	SortKey SortKeyFunc
+
+	// RateLimit limits how often Pull emits changes, see WithMinInterval, WithDebounce, and WithSampleEvery.
+	RateLimit RateLimit
+
+	// VersionCallback is called with the version of items returned from Get or List, see WithVersionCallback.
+	VersionCallback func(id, version string)
+
+	// RejectedCallback is called if a Pull is rejected, see WithRejectedCallback.
+	RejectedCallback func(err error)
+
+	internal bool // see internalSubscriber
 }
 
 // ResponseFilter returns a masks.ResponseFilter configured using this readRequest properties.
@@ -277,6 +321,8 @@ type WriteRequest struct {
 	expectedCheck func(old proto.Message) error
 	allowMissing  bool
 
+	expectedVersion string
+
 	interceptBefore UpdateInterceptor
 	interceptAfter  UpdateInterceptor
 
@@ -290,6 +336,8 @@ type WriteRequest struct {
 
 	genEmptyID bool
 	idCallback func(id string)
+
+	groupChanges bool
 }
 
 func (wr WriteRequest) fieldUpdater(writableFields *fieldmaskpb.FieldMask) *masks.FieldUpdater {
@@ -0,0 +1,164 @@
+package resource
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+const (
+	// DefaultPageSize is the page size used by ListPage when no page size is given.
+	DefaultPageSize = 50
+	// MaxPageSize is the largest page size ListPage will return.
+	MaxPageSize = 1000
+)
+
+// SortKeyFunc returns the key used to order an item in a collection.
+type SortKeyFunc func(id string, item proto.Message) string
+
+// WithSortKey instructs Collection.List and Collection.ListPage to order items by the key returned by fn instead of
+// by id.
+// Items with the same key are ordered by id.
+// Applicable only to Collection.
+func WithSortKey(fn SortKeyFunc) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.SortKey = fn
+	})
+}
+
+// Page is a single page of items returned by Collection.ListPage.
+type Page struct {
+	Items []proto.Message
+	// NextPageToken can be passed to ListPage to fetch the next page, empty if there are no more pages.
+	NextPageToken string
+	// TotalSize is the number of items across all pages, at the time this page was fetched.
+	TotalSize int
+}
+
+// ListPage returns a page of items in the collection.
+// Items are sorted by id, or by WithSortKey, and filtered by WithInclude or WithIndexLookup.
+// A pageSize of 0 uses DefaultPageSize, larger page sizes are capped at MaxPageSize.
+// An empty pageToken returns the first page, use Page.NextPageToken to fetch the page after.
+//
+// Page tokens record the last item returned rather than a position, so items added or removed between calls do not
+// cause other items to be skipped or repeated.
+// An item whose sort key changes between calls may be skipped or repeated.
+// Returns an InvalidArgument error if pageToken cannot be decoded.
+func (c *Collection) ListPage(pageSize int, pageToken string, opts ...ReadOption) (*Page, error) {
+	readConfig := ComputeReadConfig(opts...)
+	if err := c.checkIndex(readConfig); err != nil {
+		return nil, err
+	}
+	last, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+	pageSize = capPageSize(pageSize)
+
+	c.mu.RLock()
+	items := c.sortedItems(readConfig)
+	c.mu.RUnlock()
+
+	keyOf := func(it idItem) pageKey {
+		return pageKey{id: it.id}
+	}
+	if readConfig.SortKey != nil {
+		keyOf = func(it idItem) pageKey {
+			return pageKey{key: readConfig.SortKey(it.id, it.body), id: it.id}
+		}
+	}
+	start := 0
+	if last != nil {
+		if (readConfig.SortKey != nil) != last.keyed {
+			return nil, status.Error(codes.InvalidArgument, "bad page token: sort order changed")
+		}
+		start = sort.Search(len(items), func(i int) bool {
+			return last.less(keyOf(items[i]))
+		})
+	}
+	end := min(start+pageSize, len(items))
+
+	page := &Page{TotalSize: len(items)}
+	filter := readConfig.ResponseFilter()
+	for _, it := range items[start:end] {
+		readConfig.reportVersion(it.id, it.version)
+		page.Items = append(page.Items, filter.FilterClone(it.body))
+	}
+	if end < len(items) {
+		next := keyOf(items[end-1])
+		next.keyed = readConfig.SortKey != nil
+		page.NextPageToken, err = encodePageToken(next)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// pageKey identifies the position of an item in a sorted list of items.
+type pageKey struct {
+	keyed bool // whether key is used, see WithSortKey
+	key   string
+	id    string
+}
+
+// less returns true if k sorts before o.
+func (k pageKey) less(o pageKey) bool {
+	if k.key != o.key {
+		return k.key < o.key
+	}
+	return k.id < o.id
+}
+
+// keySep separates the sort key from the id in a page token.
+// Ids are not expected to contain it.
+const keySep = "\x00"
+
+func capPageSize(pageSize int) int {
+	if pageSize <= 0 {
+		return DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		return MaxPageSize
+	}
+	return pageSize
+}
+
+// decodePageToken returns the key of the last item from a previous page, or nil if token is empty.
+func decodePageToken(token string) (*pageKey, error) {
+	if token == "" {
+		return nil, nil
+	}
+	tokenBytes, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad page token: %v", err)
+	}
+	pageToken := &types.PageToken{}
+	if err := proto.Unmarshal(tokenBytes, pageToken); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad page token: %v", err)
+	}
+	last := pageToken.GetLastResourceName()
+	if i := strings.LastIndex(last, keySep); i >= 0 {
+		return &pageKey{keyed: true, key: last[:i], id: last[i+len(keySep):]}, nil
+	}
+	return &pageKey{id: last}, nil
+}
+
+func encodePageToken(k pageKey) (string, error) {
+	last := k.id
+	if k.keyed {
+		last = k.key + keySep + k.id
+	}
+	pageToken := &types.PageToken{PageStart: &types.PageToken_LastResourceName{LastResourceName: last}}
+	tokenBytes, err := proto.Marshal(pageToken)
+	if err != nil {
+		return "", status.Errorf(codes.Unknown, "unable to create page token: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(tokenBytes), nil
+}
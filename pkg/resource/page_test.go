@@ -0,0 +1,110 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+)
+
+func TestCollection_ListPage(t *testing.T) {
+	c := NewCollection()
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		add(t, c, id, &traits.Child{Name: id})
+	}
+	names := func(page *Page) []string {
+		var res []string
+		for _, item := range page.Items {
+			res = append(res, item.(*traits.Child).Name)
+		}
+		return res
+	}
+
+	page, err := c.ListPage(2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, names(page)); diff != "" {
+		t.Fatalf("first page (-want,+got)\n%s", diff)
+	}
+	if page.TotalSize != 5 || page.NextPageToken == "" {
+		t.Fatalf("want TotalSize 5 and a next page, got %v %q", page.TotalSize, page.NextPageToken)
+	}
+
+	// changes between pages don't cause items to be skipped or repeated
+	if _, err := c.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	add(t, c, "aa", &traits.Child{Name: "aa"})
+	add(t, c, "cc", &traits.Child{Name: "cc"})
+
+	page, err = c.ListPage(2, page.NextPageToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"c", "cc"}, names(page)); diff != "" {
+		t.Fatalf("second page (-want,+got)\n%s", diff)
+	}
+	page, err = c.ListPage(2, page.NextPageToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"d", "e"}, names(page)); diff != "" {
+		t.Fatalf("last page (-want,+got)\n%s", diff)
+	}
+	if page.NextPageToken != "" {
+		t.Fatalf("want no next page, got %q", page.NextPageToken)
+	}
+}
+
+func TestCollection_ListPage_sortAndFilter(t *testing.T) {
+	c := NewCollection()
+	add(t, c, "1", &traits.Child{Name: "c"})
+	add(t, c, "2", &traits.Child{Name: "a"})
+	add(t, c, "3", &traits.Child{Name: "b"})
+	add(t, c, "4", &traits.Child{Name: "a"})
+	add(t, c, "5", &traits.Child{Name: "z"})
+	byName := WithSortKey(func(_ string, item proto.Message) string {
+		return item.(*traits.Child).Name
+	})
+	notZ := WithInclude(func(_ string, item proto.Message) bool {
+		return item.(*traits.Child).Name != "z"
+	})
+
+	var got []string
+	var token string
+	for {
+		page, err := c.ListPage(1, token, byName, notZ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.TotalSize != 4 {
+			t.Fatalf("want TotalSize 4, got %v", page.TotalSize)
+		}
+		for _, item := range page.Items {
+			got = append(got, item.(*traits.Child).Name)
+		}
+		if token = page.NextPageToken; token == "" {
+			break
+		}
+	}
+	if diff := cmp.Diff([]string{"a", "a", "b", "c"}, got); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+
+	// tokens can't be used with a different sort order
+	page, err := c.ListPage(1, "", byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListPage(1, page.NextPageToken); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("want InvalidArgument, got %v", err)
+	}
+	if _, err := c.ListPage(1, "not a token"); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("want InvalidArgument, got %v", err)
+	}
+}
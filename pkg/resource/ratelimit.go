@@ -0,0 +1,237 @@
+package resource
+
+import (
+	"context"
+	"time"
+)
+
+// WithMinInterval instructs Pull to emit changes no more often than once every interval.
+// The first change is emitted immediately, changes made within interval of the last emission are combined and emitted
+// once interval has passed, so the latest value is always delivered.
+// Seed values are not delayed.
+// Only one of WithMinInterval, WithDebounce, or WithSampleEvery applies, the last one specified wins.
+// Time is measured using the resources Clock, see WithClock.
+func WithMinInterval(interval time.Duration) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.RateLimit = RateLimit{Mode: RateLimitMinInterval, Interval: interval}
+	})
+}
+
+// WithDebounce instructs Pull to wait until no changes have been made for quiet before emitting.
+// Changes made while waiting are combined and emitted together once the resource has been quiet for long enough.
+// Seed values are not delayed.
+// Only one of WithMinInterval, WithDebounce, or WithSampleEvery applies, the last one specified wins.
+// Time is measured using the resources Clock, see WithClock.
+func WithDebounce(quiet time.Duration) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.RateLimit = RateLimit{Mode: RateLimitDebounce, Interval: quiet}
+	})
+}
+
+// WithSampleEvery instructs Pull to emit changes at most once per period, on period boundaries measured from when Pull
+// was called.
+// Changes made during a period are combined and emitted at the end of the period, nothing is emitted for periods
+// without changes.
+// Seed values are not delayed.
+// Only one of WithMinInterval, WithDebounce, or WithSampleEvery applies, the last one specified wins.
+// Time is measured using the resources Clock, see WithClock.
+func WithSampleEvery(period time.Duration) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.RateLimit = RateLimit{Mode: RateLimitSample, Interval: period}
+	})
+}
+
+// RateLimit describes how changes emitted by Pull are limited.
+type RateLimit struct {
+	Mode     RateLimitMode
+	Interval time.Duration
+}
+
+// RateLimitMode describes the strategy used to limit changes emitted by Pull.
+type RateLimitMode int
+
+const (
+	RateLimitNone        RateLimitMode = iota // changes are emitted as they happen
+	RateLimitMinInterval                      // see WithMinInterval
+	RateLimitDebounce                         // see WithDebounce
+	RateLimitSample                           // see WithSampleEvery
+)
+
+func (l RateLimit) enabled() bool {
+	return l.Mode != RateLimitNone && l.Interval > 0
+}
+
+// rateLimit returns a chan that emits the values from in according to limit.
+// Values are held back until limit allows them to be emitted, held values are combined using merge, which is passed
+// the held values and the new value and returns the new held values.
+// Values where bypass returns true are emitted immediately if no values are being held.
+// If emit is not nil, it is called with the next held value to emit, along with the values held after it, and returns
+// the value to emit. Emit may be called more than once for the same value.
+// The returned chan is closed when in is closed, after all held values have been emitted, or when ctx is done.
+func rateLimit[T any](ctx context.Context, clock Clock, limit RateLimit, in <-chan T, bypass func(T) bool, merge func([]T, T) []T, emit func(T, []T) T) <-chan T {
+	out := make(chan T)
+	start := clock.Now()
+	go func() {
+		defer close(out)
+
+		var (
+			held  []T
+			ready bool // whether held values can be emitted
+
+			timers    = make(chan int, 1)
+			timerGen  int
+			stopTimer func()
+			hasTimer  bool
+
+			lastEmit time.Time
+		)
+		schedule := func(t time.Time) {
+			if stopTimer != nil {
+				stopTimer()
+			}
+			timerGen++
+			gen := timerGen
+			hasTimer = true
+			stopTimer = afterFunc(clock, t, func() {
+				select {
+				case timers <- gen:
+				case <-ctx.Done():
+				}
+			})
+		}
+		defer func() {
+			if stopTimer != nil {
+				stopTimer()
+			}
+		}()
+		// emptied is called when all held values have been emitted
+		emptied := func() {
+			ready = false
+			if limit.Mode == RateLimitMinInterval {
+				lastEmit = clock.Now()
+				schedule(lastEmit.Add(limit.Interval))
+			}
+		}
+		arrived := func() {
+			switch limit.Mode {
+			case RateLimitMinInterval:
+				if !hasTimer && !clock.Now().Before(lastEmit.Add(limit.Interval)) {
+					ready = true
+				}
+			case RateLimitDebounce:
+				ready = false
+				schedule(clock.Now().Add(limit.Interval))
+			case RateLimitSample:
+				if !hasTimer {
+					periods := clock.Now().Sub(start)/limit.Interval + 1
+					schedule(start.Add(periods * limit.Interval))
+				}
+			}
+		}
+
+		closed := false
+		for {
+			var (
+				send chan<- T
+				next T
+			)
+			if len(held) > 0 && (ready || closed) {
+				send, next = out, held[0]
+				if emit != nil {
+					next = emit(next, held[1:])
+				}
+			} else if closed {
+				return
+			}
+			recv := in
+			if closed {
+				recv = nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-recv:
+				if !ok {
+					closed = true
+					continue
+				}
+				if bypass != nil && bypass(v) && len(held) == 0 {
+					select {
+					case <-ctx.Done():
+						return
+					case out <- v:
+					}
+					continue
+				}
+				wasEmpty := len(held) == 0
+				held = merge(held, v)
+				if wasEmpty && len(held) > 0 || limit.Mode == RateLimitDebounce {
+					arrived()
+				}
+			case send <- next:
+				var zero T
+				held[0] = zero
+				held = held[1:]
+				if len(held) == 0 {
+					emptied()
+				}
+			case gen := <-timers:
+				if gen != timerGen {
+					continue // a stale timer
+				}
+				hasTimer = false
+				ready = len(held) > 0
+			}
+		}
+	}()
+	return out
+}
+
+// mergeLatest is a merge func for rateLimit that keeps only the latest value.
+func mergeLatest[T any](_ []T, v T) []T {
+	return []T{v}
+}
+
+// collectionChangeMerger combines held CollectionChanges for rateLimit.
+// Changes are combined in the same way as mergeCollectionExcess, including adjusting Seq so consumers can resume
+// without missing any held changes.
+type collectionChangeMerger struct {
+	first map[string]uint64 // the Seq of the first change combined into each held change
+}
+
+func newCollectionChangeMerger() *collectionChangeMerger {
+	return &collectionChangeMerger{first: make(map[string]uint64)}
+}
+
+func (m *collectionChangeMerger) merge(held []*CollectionChange, v *CollectionChange) []*CollectionChange {
+	for i, h := range held {
+		if h.Id != v.Id {
+			continue
+		}
+		merged, send := mergeChanges(*h, *v)
+		held = append(held[:i], held[i+1:]...)
+		if !send {
+			delete(m.first, v.Id)
+			return held
+		}
+		return append(held, &merged)
+	}
+	m.first[v.Id] = v.Seq
+	return append(held, v)
+}
+
+func (m *collectionChangeMerger) emit(next *CollectionChange, rest []*CollectionChange) *CollectionChange {
+	seq := next.Seq
+	for _, r := range rest {
+		if first := m.first[r.Id]; first > 0 && first-1 < seq {
+			seq = first - 1
+		}
+	}
+	if seq == next.Seq {
+		return next
+	}
+	adjusted := *next
+	adjusted.Seq = seq
+	return &adjusted
+}
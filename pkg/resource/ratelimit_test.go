@@ -0,0 +1,102 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestWithMinInterval(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	v := NewValue(WithClock(clock), WithInitialValue(&traits.Brightness{}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := v.Pull(ctx, WithMinInterval(10*time.Second))
+	level := func(c *ValueChange) float32 {
+		return c.Value.(*traits.Brightness).LevelPercent
+	}
+
+	if seed := waitForChan(t, changes, time.Second); !seed.SeedValue {
+		t.Fatalf("want seed, got %v", seed)
+	}
+	// the first change is not delayed
+	_, _ = v.Set(&traits.Brightness{LevelPercent: 1})
+	if got := level(waitForChan(t, changes, time.Second)); got != 1 {
+		t.Fatalf("want 1, got %v", got)
+	}
+	clock.waitForTimer(t, time.Unix(10, 0))
+	_, _ = v.Set(&traits.Brightness{LevelPercent: 2})
+	_, _ = v.Set(&traits.Brightness{LevelPercent: 3})
+	noEmitWithin(t, changes, 50*time.Millisecond)
+	clock.advance(10 * time.Second)
+	if got := level(waitForChan(t, changes, time.Second)); got != 3 {
+		t.Fatalf("want latest 3, got %v", got)
+	}
+	noEmitWithin(t, changes, 50*time.Millisecond)
+
+	// after a quiet interval changes are emitted immediately again
+	clock.waitForTimer(t, time.Unix(20, 0))
+	clock.advance(10 * time.Second)
+	_, _ = v.Set(&traits.Brightness{LevelPercent: 4})
+	if got := level(waitForChan(t, changes, time.Second)); got != 4 {
+		t.Fatalf("want 4, got %v", got)
+	}
+}
+
+func TestWithDebounce(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	v := NewValue(WithClock(clock), WithInitialValue(&traits.Brightness{}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := v.Pull(ctx, WithDebounce(10*time.Second), WithUpdatesOnly(true))
+
+	_, _ = v.Set(&traits.Brightness{LevelPercent: 1})
+	clock.waitForTimer(t, time.Unix(10, 0))
+	clock.advance(5 * time.Second)
+	_, _ = v.Set(&traits.Brightness{LevelPercent: 2})
+	clock.waitForTimer(t, time.Unix(15, 0))
+	clock.advance(5 * time.Second)
+	noEmitWithin(t, changes, 50*time.Millisecond)
+	clock.advance(5 * time.Second)
+	change := waitForChan(t, changes, time.Second)
+	if got := change.Value.(*traits.Brightness).LevelPercent; got != 2 {
+		t.Fatalf("want 2, got %v", got)
+	}
+	noEmitWithin(t, changes, 50*time.Millisecond)
+}
+
+func TestWithSampleEvery(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	c := NewCollection(WithClock(clock))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithSampleEvery(10*time.Second))
+
+	clock.advance(2 * time.Second)
+	add(t, c, "a", &traits.OnOff{State: traits.OnOff_OFF})
+	clock.waitForTimer(t, time.Unix(10, 0))
+	add(t, c, "b", &traits.OnOff{State: traits.OnOff_OFF})
+	_, _ = c.Update("a", &traits.OnOff{State: traits.OnOff_ON})
+	add(t, c, "c", &traits.OnOff{})
+	_, _ = c.Delete("c")
+	noEmitWithin(t, changes, 50*time.Millisecond)
+
+	clock.advance(8 * time.Second)
+	// changes to the same item are combined, emitted in the order of their last change
+	first := waitForChan(t, changes, time.Second)
+	second := waitForChan(t, changes, time.Second)
+	if first.Id != "b" || first.ChangeType != types.ChangeType_ADD {
+		t.Fatalf("want ADD b, got %v", first)
+	}
+	if second.Id != "a" || second.ChangeType != types.ChangeType_ADD || second.NewValue.(*traits.OnOff).State != traits.OnOff_ON {
+		t.Fatalf("want ADD a ON, got %v", second)
+	}
+	// the change to a happened before b, so resuming after b must not skip it
+	if first.Seq >= second.Seq || first.Seq != 0 {
+		t.Fatalf("want first Seq 0 before second Seq, got %v and %v", first.Seq, second.Seq)
+	}
+	noEmitWithin(t, changes, 50*time.Millisecond)
+}
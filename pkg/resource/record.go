@@ -0,0 +1,333 @@
+package resource
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Recorder writes the changes made to a set of resources to an io.Writer so they can be replayed later, see Replay.
+//
+// Each change is written as a varint length-prefixed message
+//
+//	message RecordedChange {
+//	  string id = 1; // the item id for Collections
+//	  google.protobuf.Any value = 2; // absent for removed items
+//	  google.protobuf.Timestamp change_time = 3;
+//	  bool deleted = 4;
+//	  string resource = 5; // the name given to RecordValue or RecordCollection
+//	  bool seed = 6; // the value was current when recording started
+//	}
+//
+// Message types must be registered with protoregistry.GlobalTypes to be replayed.
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+	wg  sync.WaitGroup
+}
+
+// NewRecorder returns a Recorder that writes changes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// RecordValue records the current value of v and all changes to it under name, until ctx is done.
+// Changes are received with backpressure so none are missed, a slow writer will slow down writes to v.
+func (r *Recorder) RecordValue(ctx context.Context, name string, v *Value) {
+	changes := v.Pull(ctx, WithBackpressure(true), internalSubscriber())
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for change := range changes {
+			r.write(recordEntry{
+				resource: name,
+				seed:     change.SeedValue,
+				storeEntry: storeEntry{
+					StoreRecord: StoreRecord{Value: change.Value, ChangeTime: change.ChangeTime},
+				},
+			})
+		}
+	}()
+}
+
+// RecordCollection records the current items in c and all changes to them under name, until ctx is done.
+// See RecordValue.
+func (r *Recorder) RecordCollection(ctx context.Context, name string, c *Collection) {
+	changes := c.Pull(ctx, WithBackpressure(true), internalSubscriber())
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for change := range changes {
+			r.write(recordEntry{
+				resource: name,
+				seed:     change.SeedValue,
+				storeEntry: storeEntry{
+					StoreRecord: StoreRecord{ID: change.Id, Value: change.NewValue, ChangeTime: change.ChangeTime},
+					deleted:     change.NewValue == nil,
+				},
+			})
+		}
+	}()
+}
+
+// Wait blocks until all recordings have stopped, returning the first error encountered writing changes.
+// Once an error occurs no more changes are written.
+func (r *Recorder) Wait() error {
+	r.wg.Wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(e recordEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	b, err := encodeRecordEntry(e)
+	if err != nil {
+		r.err = err
+		return
+	}
+	_, r.err = r.w.Write(protowire.AppendBytes(nil, b))
+}
+
+// ReplayOption configures how Replay paces changes.
+type ReplayOption interface {
+	apply(rc *replayConfig)
+}
+
+type replayOptionFunc func(rc *replayConfig)
+
+func (f replayOptionFunc) apply(rc *replayConfig) {
+	f(rc)
+}
+
+type replayConfig struct {
+	speed float64
+	clock Clock
+}
+
+// WithPlaybackSpeed replays changes speed times faster than they were recorded.
+// A speed of zero or less replays changes without waiting between them.
+// Not to be confused with WithReplaySince, which replays retained history during Pull.
+func WithPlaybackSpeed(speed float64) ReplayOption {
+	return replayOptionFunc(func(rc *replayConfig) {
+		rc.speed = speed
+	})
+}
+
+// WithReplayClock uses c to wait between changes, instead of real time.
+// Use a clock that can be advanced manually to step through a recording.
+// If c has an At method, like clock.Clock, it is used to wait, otherwise a time.Timer is used.
+func WithReplayClock(c Clock) ReplayOption {
+	return replayOptionFunc(func(rc *replayConfig) {
+		rc.clock = c
+	})
+}
+
+// Replay reads changes written by a Recorder from r and applies them to resources, which must contain a *Value or
+// *Collection for each recorded resource name.
+// Seed values are applied immediately, other changes are applied with the same spacing as their ChangeTime,
+// adjusted by WithPlaybackSpeed and measured using WithReplayClock.
+// Items in a *Collection that were not present when recording started are deleted once its seed has been applied,
+// so the collection matches the recording.
+// Changes are written with their recorded ChangeTime, see WithWriteTime.
+//
+// Replay returns when all changes have been applied, ctx is done, or an error occurs.
+func Replay(ctx context.Context, r io.Reader, resources map[string]Source, opts ...ReplayOption) error {
+	rc := &replayConfig{speed: 1, clock: WallClock()}
+	for _, opt := range opts {
+		opt.apply(rc)
+	}
+
+	br := bufio.NewReader(r)
+	var (
+		started   bool
+		firstTime time.Time                          // ChangeTime of the first non-seed change
+		startTime time.Time                          // rc.clock time the first non-seed change was applied
+		seeded    = make(map[string]map[string]bool) // collection name -> seed ids, until the seed has been applied
+	)
+	for name, target := range resources {
+		if _, ok := target.(*Collection); ok {
+			seeded[name] = make(map[string]bool)
+		}
+	}
+	// endSeed deletes items in the named collection that were not part of its recorded seed.
+	endSeed := func(name string) error {
+		ids, ok := seeded[name]
+		if !ok {
+			return nil
+		}
+		delete(seeded, name)
+		return removeUnseeded(resources[name].(*Collection), ids)
+	}
+	for {
+		b, _, err := readDelimited(br)
+		if errors.Is(err, io.EOF) {
+			for name := range seeded {
+				if err := endSeed(name); err != nil {
+					return fmt.Errorf("replay %s: %w", name, err)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e, err := decodeRecordEntry(b)
+		if err != nil {
+			return err
+		}
+		target, ok := resources[e.resource]
+		if !ok {
+			return fmt.Errorf("replay: unknown resource %q", e.resource)
+		}
+
+		if ids, ok := seeded[e.resource]; ok && e.seed {
+			ids[e.ID] = true
+		} else if !e.seed {
+			if err := endSeed(e.resource); err != nil {
+				return fmt.Errorf("replay %s: %w", e.resource, err)
+			}
+		}
+
+		if !e.seed && rc.speed > 0 {
+			if !started {
+				started, firstTime, startTime = true, e.ChangeTime, rc.clock.Now()
+			} else if offset := e.ChangeTime.Sub(firstTime); offset > 0 {
+				at := startTime.Add(time.Duration(float64(offset) / rc.speed))
+				ready := make(chan struct{})
+				stop := afterFunc(rc.clock, at, func() { close(ready) })
+				select {
+				case <-ctx.Done():
+					stop()
+					return ctx.Err()
+				case <-ready:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := replayEntry(target, e); err != nil {
+			return fmt.Errorf("replay %s %s: %w", e.resource, e.ID, err)
+		}
+	}
+}
+
+func replayEntry(target Source, e recordEntry) error {
+	var opts []WriteOption
+	if !e.ChangeTime.IsZero() {
+		opts = append(opts, WithWriteTime(e.ChangeTime))
+	}
+	switch target := target.(type) {
+	case *Value:
+		if e.Value == nil {
+			return nil
+		}
+		_, err := target.Set(e.Value, append(opts, WithAllFieldsWritable())...)
+		return err
+	case *Collection:
+		if e.deleted {
+			_, err := target.Delete(e.ID, append(opts, WithAllowMissing(true))...)
+			return err
+		}
+		_, err := target.Update(e.ID, e.Value, append(opts, WithCreateIfAbsent(), WithAllFieldsWritable())...)
+		return err
+	default:
+		return fmt.Errorf("unsupported resource type %T", target)
+	}
+}
+
+// removeUnseeded deletes all items from c whose id is not in keep.
+func removeUnseeded(c *Collection, keep map[string]bool) error {
+	c.mu.RLock()
+	var remove []string
+	for id := range c.byId {
+		if !keep[id] {
+			remove = append(remove, id)
+		}
+	}
+	c.mu.RUnlock()
+	for _, id := range remove {
+		if _, err := c.Delete(id, WithAllowMissing(true)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEntry is a change as written by a Recorder.
+type recordEntry struct {
+	storeEntry
+	resource string
+	seed     bool
+}
+
+// Field numbers used when encoding a recordEntry, in addition to those used by storeEntry.
+const (
+	recordResourceField protowire.Number = 5
+	recordSeedField     protowire.Number = 6
+)
+
+func encodeRecordEntry(e recordEntry) ([]byte, error) {
+	b, err := encodeStoreEntry(e.storeEntry)
+	if err != nil {
+		return nil, err
+	}
+	b = protowire.AppendTag(b, recordResourceField, protowire.BytesType)
+	b = protowire.AppendString(b, e.resource)
+	if e.seed {
+		b = protowire.AppendTag(b, recordSeedField, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(true))
+	}
+	return b, nil
+}
+
+func decodeRecordEntry(b []byte) (recordEntry, error) {
+	var e recordEntry
+	se, err := decodeStoreEntry(b) // ignores the fields specific to recordEntry
+	if err != nil {
+		return e, err
+	}
+	e.storeEntry = se
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == recordResourceField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			e.resource = v
+			b = b[n:]
+		case num == recordSeedField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			e.seed = protowire.DecodeBool(v)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,153 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestRecorder_Replay(t *testing.T) {
+	recording := record(t)
+
+	clock := newManualClock(time.Unix(0, 0))
+	v := NewValue()
+	c := NewCollection()
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true))
+	done := make(chan error, 1)
+	go func() {
+		done <- Replay(ctx, bytes.NewReader(recording), map[string]Source{"v": v, "c": c}, WithReplayClock(clock))
+	}()
+
+	// seeds and the first change are applied immediately
+	if change := waitForChan(t, changes, time.Second); change.Id != "a" || change.ChangeType != types.ChangeType_ADD {
+		t.Fatalf("want seed ADD a, got %v", change)
+	}
+	clock.waitForTimer(t, time.Unix(10, 0))
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 20}, v.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("value (-want,+got)\n%s", diff)
+	}
+	v.mu.RLock()
+	valueTime := v.changeTime
+	v.mu.RUnlock()
+	if !valueTime.Equal(time.Unix(10, 0)) {
+		t.Fatalf("value want ChangeTime 10s, got %v", valueTime)
+	}
+	clock.advance(10 * time.Second)
+	// changes keep their recorded ChangeTime
+	if change := waitForChan(t, changes, time.Second); change.Id != "b" || change.ChangeType != types.ChangeType_ADD || !change.ChangeTime.Equal(time.Unix(20, 0)) {
+		t.Fatalf("want ADD b at 20s, got %v", change)
+	}
+	clock.waitForTimer(t, time.Unix(20, 0))
+	clock.advance(10 * time.Second)
+	if change := waitForChan(t, changes, time.Second); change.Id != "a" || change.ChangeType != types.ChangeType_REMOVE || !change.ChangeTime.Equal(time.Unix(30, 0)) {
+		t.Fatalf("want REMOVE a at 30s, got %v", change)
+	}
+	if err := waitForChan(t, done, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplay_speed(t *testing.T) {
+	recording := record(t)
+	v := NewValue()
+	c := NewCollection()
+	err := Replay(context.Background(), bytes.NewReader(recording), map[string]Source{"v": v, "c": c}, WithPlaybackSpeed(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 20}, v.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("value (-want,+got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]proto.Message{&traits.Brightness{LevelPercent: 50}}, c.List(), protocmp.Transform()); diff != "" {
+		t.Fatalf("collection (-want,+got)\n%s", diff)
+	}
+
+	if err := Replay(context.Background(), bytes.NewReader(recording), map[string]Source{"v": v}); err == nil {
+		t.Fatalf("want error replaying into unknown resource")
+	}
+}
+
+func TestReplay_removesUnseeded(t *testing.T) {
+	recording := record(t)
+	v := NewValue()
+	c := NewCollection(WithInitialRecord("a", &traits.Brightness{LevelPercent: 1}), WithInitialRecord("z", &traits.Brightness{}))
+	err := Replay(context.Background(), bytes.NewReader(recording), map[string]Source{"v": v, "c": c}, WithPlaybackSpeed(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]proto.Message{&traits.Brightness{LevelPercent: 50}}, c.List(), protocmp.Transform()); diff != "" {
+		t.Fatalf("collection (-want,+got)\n%s", diff)
+	}
+}
+
+// record returns a recording of a Value and Collection with changes made 10s apart.
+func record(t *testing.T) []byte {
+	t.Helper()
+	clock := newManualClock(time.Unix(30, 0))
+	v := NewValue(WithInitialValue(&traits.Brightness{LevelPercent: 10}))
+	c := NewCollection(WithClock(clock), WithInitialRecord("a", &traits.Brightness{}))
+
+	w := &notifyWriter{writes: make(chan struct{}, 10)}
+	r := NewRecorder(w)
+	ctx, stop := context.WithCancel(context.Background())
+	r.RecordValue(ctx, "v", v)
+	r.RecordCollection(ctx, "c", c)
+	w.wait(t, 2) // seeds
+
+	if _, err := v.Set(&traits.Brightness{LevelPercent: 20}, WithWriteTime(time.Unix(10, 0))); err != nil {
+		t.Fatal(err)
+	}
+	w.wait(t, 1)
+	if _, err := c.Add("b", &traits.Brightness{LevelPercent: 50}, WithWriteTime(time.Unix(20, 0))); err != nil {
+		t.Fatal(err)
+	}
+	w.wait(t, 1)
+	if _, err := c.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	w.wait(t, 1)
+
+	stop()
+	if err := r.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	return w.Bytes()
+}
+
+// notifyWriter is an io.Writer that signals each write.
+type notifyWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes chan struct{}
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer func() { w.writes <- struct{}{} }()
+	return w.buf.Write(p)
+}
+
+func (w *notifyWriter) Bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Bytes()
+}
+
+func (w *notifyWriter) wait(t *testing.T, n int) {
+	t.Helper()
+	for range n {
+		waitForChan(t, w.writes, time.Second)
+	}
+}
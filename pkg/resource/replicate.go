@@ -0,0 +1,469 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Replicator keeps a Collection in sync with Collections in other processes, called peers.
+// Each process creates a Replicator for its Collection with a unique node id, then connects the Replicators using
+// Connect on one side and RegisterReplicationServer on the other.
+//
+// When a peer connects each side sends every item it knows about, then any changes made while connected.
+// Changes received from one peer are forwarded to all other connected peers.
+// Concurrent writes to the same item are resolved using last-writer-wins: the write with the latest write time is
+// kept, ties are broken by choosing the write from the node with the greatest id.
+// Local writes are given a write time after the write they replace so they always win locally, even if this
+// process' clock is behind the peer that made the replaced write.
+//
+// Deleted items are remembered, as tombstones, so a delete is not undone by an older write from a peer.
+// By default tombstones are kept for the life of the Replicator, use WithTombstoneTTL to forget them.
+//
+// Replicated writes do not use the Collection's write options, like WithValidation or WithIDInterceptor.
+type Replicator struct {
+	c    *Collection
+	node string
+	rc   replicatorConfig
+
+	mu         sync.Mutex // acquired after c.mu, never before
+	meta       map[string]replicaMeta
+	peers      map[*replicaPeer]struct{}
+	tombstones int       // number of deleted items in meta
+	lastPrune  time.Time // when tombstones were last pruned, see WithTombstoneTTL
+
+	applying *replicaMeta // set while applying a change from a peer, protected by c.mu
+}
+
+// ReplicatorOption configures a Replicator.
+type ReplicatorOption func(*replicatorConfig)
+
+type replicatorConfig struct {
+	minReconnectDelay time.Duration
+	maxReconnectDelay time.Duration
+	tombstoneTTL      time.Duration
+}
+
+// DefaultReplicatorOptions are applied before any options passed to NewReplicator.
+var DefaultReplicatorOptions = []ReplicatorOption{
+	WithReconnectDelay(100*time.Millisecond, 30*time.Second),
+}
+
+// WithReconnectDelay configures how long Connect waits before reconnecting after a failure.
+// The delay starts at min and doubles with each consecutive failure, up to max.
+func WithReconnectDelay(min, max time.Duration) ReplicatorOption {
+	return func(rc *replicatorConfig) {
+		rc.minReconnectDelay = min
+		rc.maxReconnectDelay = max
+	}
+}
+
+// WithTombstoneTTL forgets deleted items once ttl has passed since they were deleted, measured using the Collection's
+// Clock.
+// A tombstone must outlive the longest time a peer can be disconnected, otherwise a peer that reconnects with an
+// older write for the item will add it back.
+// Zero, the default, keeps tombstones for the life of the Replicator.
+func WithTombstoneTTL(ttl time.Duration) ReplicatorOption {
+	return func(rc *replicatorConfig) {
+		rc.tombstoneTTL = ttl
+	}
+}
+
+// replicaMeta records the last write to an item.
+type replicaMeta struct {
+	writeTime time.Time
+	origin    string        // id of the node that made the write, empty if not known
+	value     proto.Message // nil if the item was deleted
+	from      *replicaPeer  // the peer we received the write from, nil for local writes
+}
+
+// newer returns true if a write at writeTime by origin should replace m.
+func (m replicaMeta) newer(writeTime time.Time, origin string) bool {
+	if !writeTime.Equal(m.writeTime) {
+		return writeTime.After(m.writeTime)
+	}
+	return origin > m.origin
+}
+
+// NewReplicator returns a Replicator for c, identifying this process using nodeID.
+// Each process replicating the same Collection must use a different nodeID.
+// Items already in c, for example those restored from a Store, are treated as written by an unknown node at their
+// change time, so an identical copy restored by a peer is not seen as a conflicting write.
+// Panics if c already has a Replicator.
+func NewReplicator(c *Collection, nodeID string, opts ...ReplicatorOption) *Replicator {
+	r := &Replicator{
+		c:     c,
+		node:  nodeID,
+		meta:  make(map[string]replicaMeta),
+		peers: make(map[*replicaPeer]struct{}),
+	}
+	for _, opt := range DefaultReplicatorOptions {
+		opt(&r.rc)
+	}
+	for _, opt := range opts {
+		opt(&r.rc)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replicator != nil {
+		panic("collection already has a Replicator")
+	}
+	c.replicator = r
+	for id, it := range c.byId {
+		r.meta[id] = replicaMeta{writeTime: it.changeTime, value: it.body}
+	}
+	return r
+}
+
+// ReplicaPeer describes a peer connected to a Replicator.
+type ReplicaPeer struct {
+	// Node is the node id of the peer.
+	Node string
+	// Synced is true once all the items the peer had when it connected have been received.
+	Synced bool
+}
+
+// Peers returns the peers currently connected to r, sorted by node id.
+func (r *Replicator) Peers() []ReplicaPeer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]ReplicaPeer, 0, len(r.peers))
+	for p := range r.peers {
+		p.mu.Lock()
+		res = append(res, ReplicaPeer{Node: p.node, Synced: p.synced})
+		p.mu.Unlock()
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Node < res[j].Node
+	})
+	return res
+}
+
+// recordLocked records a change to the Collection, called by Collection.apply.
+// c.mu must be held.
+func (r *Replicator) recordLocked(id string, msg proto.Message, changeTime time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := replicaMeta{writeTime: changeTime, origin: r.node, value: msg}
+	if a := r.applying; a != nil {
+		m.writeTime, m.origin, m.from = a.writeTime, a.origin, a.from
+	} else if old, ok := r.meta[id]; ok {
+		if !old.newer(m.writeTime, m.origin) {
+			// keep local writes after the write they replace, our clock might be behind
+			m.writeTime = old.writeTime.Add(time.Nanosecond)
+		}
+	}
+	r.setMetaLocked(id, m)
+}
+
+// setMetaLocked records m as the last write to id, queueing it to be sent to peers.
+// r.mu must be held.
+func (r *Replicator) setMetaLocked(id string, m replicaMeta) {
+	if old, ok := r.meta[id]; ok && old.value == nil {
+		r.tombstones--
+	}
+	if m.value == nil {
+		r.tombstones++
+	}
+	r.meta[id] = m
+	r.pruneTombstonesLocked()
+	for p := range r.peers {
+		if p != m.from {
+			p.markDirty(id)
+		}
+	}
+}
+
+// pruneTombstonesLocked forgets deleted items older than the configured tombstone TTL.
+// To avoid scanning every item on each write, tombstones are pruned at most once every ttl/2.
+// r.mu must be held.
+func (r *Replicator) pruneTombstonesLocked() {
+	ttl := r.rc.tombstoneTTL
+	if ttl <= 0 || r.tombstones == 0 {
+		return
+	}
+	now := r.c.clock.Now()
+	if now.Sub(r.lastPrune) < ttl/2 {
+		return
+	}
+	r.lastPrune = now
+	for id, m := range r.meta {
+		if m.value == nil && now.Sub(m.writeTime) >= ttl {
+			delete(r.meta, id)
+			r.tombstones--
+		}
+	}
+}
+
+// applyRemote applies a change received from peer from, if it is newer than the change we know about.
+func (r *Replicator) applyRemote(from *replicaPeer, e replicaFrame) error {
+	c := r.c
+	c.mu.Lock()
+	r.mu.Lock()
+	old, known := r.meta[e.ID]
+	r.mu.Unlock()
+	if known && !old.newer(e.ChangeTime, e.origin) {
+		c.mu.Unlock()
+		return nil // we already have this change, or a later one
+	}
+
+	var value proto.Message
+	if !e.deleted {
+		if e.Value == nil {
+			c.mu.Unlock()
+			return fmt.Errorf("replicate %v: missing value", e.ID)
+		}
+		value = e.Value
+	}
+	if err := c.save(e.ID, value, e.ChangeTime); err != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("replicate %v: store: %w", e.ID, err)
+	}
+	meta := replicaMeta{writeTime: e.ChangeTime, origin: e.origin, value: value, from: from}
+	r.applying = &meta
+	change := c.apply(e.ID, value, e.ChangeTime)
+	r.applying = nil
+	if change == nil {
+		// deleting an item we don't have, remember the delete in case an older write arrives later
+		r.mu.Lock()
+		r.setMetaLocked(e.ID, meta)
+		r.mu.Unlock()
+	}
+	c.queueLocked(change)
+	c.mu.Unlock()
+
+	c.flush()
+	return nil
+}
+
+// sync exchanges changes with the peer identified by node until ctx is done, or send or recv fail.
+// Returns nil if the peer closes the connection, recv returns io.EOF.
+func (r *Replicator) sync(ctx context.Context, node string, send func(replicaFrame) error, recv func() (replicaFrame, error)) error {
+	if node == r.node {
+		return fmt.Errorf("replicate: peer has the same node id %q", node)
+	}
+	p := r.addPeer(node)
+	defer r.removePeer(p)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- r.sendChanges(ctx, p, send)
+		cancel()
+	}()
+
+	for {
+		e, err := recv()
+		if err != nil {
+			cancel()
+			if sErr := <-sendErr; sErr != nil && !errors.Is(sErr, context.Canceled) {
+				return sErr
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if e.snapshotEnd {
+			p.mu.Lock()
+			p.synced = true
+			p.mu.Unlock()
+			continue
+		}
+		if err := r.applyRemote(p, e); err != nil {
+			cancel()
+			<-sendErr
+			return err
+		}
+	}
+}
+
+// sendChanges sends all known items to p, followed by a snapshot end frame, then any changes as they happen.
+func (r *Replicator) sendChanges(ctx context.Context, p *replicaPeer, send func(replicaFrame) error) error {
+	snapshotSent := false
+	for {
+		p.mu.Lock()
+		dirty := p.dirty
+		p.dirty = make(map[string]struct{})
+		p.mu.Unlock()
+
+		ids := make([]string, 0, len(dirty))
+		for id := range dirty {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			r.mu.Lock()
+			m, ok := r.meta[id]
+			r.mu.Unlock()
+			if !ok || m.from == p {
+				continue
+			}
+			e := replicaFrame{origin: m.origin}
+			e.ID, e.Value, e.ChangeTime, e.deleted = id, m.value, m.writeTime, m.value == nil
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+		if !snapshotSent {
+			if err := send(replicaFrame{snapshotEnd: true}); err != nil {
+				return err
+			}
+			snapshotSent = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.notify:
+		}
+	}
+}
+
+func (r *Replicator) addPeer(node string) *replicaPeer {
+	p := &replicaPeer{node: node, dirty: make(map[string]struct{}), notify: make(chan struct{}, 1)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id := range r.meta {
+		p.dirty[id] = struct{}{}
+	}
+	r.peers[p] = struct{}{}
+	return p
+}
+
+func (r *Replicator) removePeer(p *replicaPeer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.peers, p)
+}
+
+// replicaPeer tracks the items that need sending to a connected peer.
+type replicaPeer struct {
+	node string
+
+	mu     sync.Mutex
+	dirty  map[string]struct{} // ids whose latest write hasn't been sent
+	synced bool                // see ReplicaPeer.Synced
+	notify chan struct{}       // signalled when dirty changes
+}
+
+func (p *replicaPeer) markDirty(id string) {
+	p.mu.Lock()
+	p.dirty[id] = struct{}{}
+	p.mu.Unlock()
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// replicaFrame is a message exchanged by Replicators.
+// The frame is encoded as if it were the message
+//
+//	message ReplicaFrame {
+//	  string id = 1;
+//	  google.protobuf.Any value = 2; // absent for deleted items
+//	  google.protobuf.Timestamp change_time = 3; // the write time used for last-writer-wins
+//	  bool deleted = 4;
+//	  string origin = 5; // the node id that made the write
+//	  bool snapshot_end = 6; // all items known when the connection started have been sent
+//	  string collection = 7; // the name of the collection, sent by the client in the first frame
+//	  string node = 8; // the node id of the sender, sent in the first frame
+//	}
+type replicaFrame struct {
+	storeEntry
+	origin      string
+	snapshotEnd bool
+	collection  string
+	node        string
+}
+
+// Field numbers used when encoding a replicaFrame, in addition to those used by storeEntry.
+const (
+	replicaOriginField      protowire.Number = 5
+	replicaSnapshotEndField protowire.Number = 6
+	replicaCollectionField  protowire.Number = 7
+	replicaNodeField        protowire.Number = 8
+)
+
+func encodeReplicaFrame(e replicaFrame) ([]byte, error) {
+	b, err := encodeStoreEntry(e.storeEntry)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		num protowire.Number
+		v   string
+	}{
+		{replicaOriginField, e.origin},
+		{replicaCollectionField, e.collection},
+		{replicaNodeField, e.node},
+	} {
+		if f.v != "" {
+			b = protowire.AppendTag(b, f.num, protowire.BytesType)
+			b = protowire.AppendString(b, f.v)
+		}
+	}
+	if e.snapshotEnd {
+		b = protowire.AppendTag(b, replicaSnapshotEndField, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(true))
+	}
+	return b, nil
+}
+
+func decodeReplicaFrame(b []byte) (replicaFrame, error) {
+	var e replicaFrame
+	se, err := decodeStoreEntry(b) // ignores the fields specific to replicaFrame
+	if err != nil {
+		return e, err
+	}
+	e.storeEntry = se
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+		var dst *string
+		switch {
+		case num == replicaOriginField && typ == protowire.BytesType:
+			dst = &e.origin
+		case num == replicaCollectionField && typ == protowire.BytesType:
+			dst = &e.collection
+		case num == replicaNodeField && typ == protowire.BytesType:
+			dst = &e.node
+		case num == replicaSnapshotEndField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			e.snapshotEnd = protowire.DecodeBool(v)
+			b = b[n:]
+			continue
+		}
+		if dst != nil {
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			*dst = v
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return e, nil
+}
@@ -0,0 +1,157 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ReplicationServiceName is the name of the gRPC service used by Replicators.
+const ReplicationServiceName = "smartcore.go.resource.ReplicationApi"
+
+// replicationServiceDesc describes the gRPC service used by Replicators, as if defined by
+//
+//	service ReplicationApi {
+//	  rpc Replicate(stream google.protobuf.BytesValue) returns (stream google.protobuf.BytesValue);
+//	}
+//
+// where each BytesValue holds an encoded replicaFrame.
+var replicationServiceDesc = grpc.ServiceDesc{
+	ServiceName: ReplicationServiceName,
+	HandlerType: (*replicationServerInterface)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Replicate",
+			Handler:       replicateHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+const replicateMethod = "/" + ReplicationServiceName + "/Replicate"
+
+type replicationServerInterface interface {
+	replicate(stream grpc.ServerStream) error
+}
+
+// RegisterReplicationServer registers a gRPC service with s that peers can Connect to.
+// The keys of replicators are the names peers use to select the Replicator to sync with.
+func RegisterReplicationServer(s grpc.ServiceRegistrar, replicators map[string]*Replicator) {
+	s.RegisterService(&replicationServiceDesc, &replicationServer{replicators: replicators})
+}
+
+func replicateHandler(srv any, stream grpc.ServerStream) error {
+	return srv.(replicationServerInterface).replicate(stream)
+}
+
+type replicationServer struct {
+	replicators map[string]*Replicator
+}
+
+func (s *replicationServer) replicate(stream grpc.ServerStream) error {
+	hello, err := recvReplicaFrame(stream)
+	if err != nil {
+		return err
+	}
+	if hello.node == "" {
+		return status.Error(codes.InvalidArgument, "replicate: missing node id")
+	}
+	r, ok := s.replicators[hello.collection]
+	if !ok {
+		return status.Errorf(codes.NotFound, "replicate: unknown collection %q", hello.collection)
+	}
+	if err := sendReplicaFrame(stream, replicaFrame{node: r.node}); err != nil {
+		return err
+	}
+	err = r.sync(stream.Context(), hello.node,
+		func(e replicaFrame) error { return sendReplicaFrame(stream, e) },
+		func() (replicaFrame, error) { return recvReplicaFrame(stream) },
+	)
+	if err != nil && status.Code(err) == codes.Unknown {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return err
+}
+
+// Connect syncs r with the Replicator registered using name on the server at the other end of conn.
+// Connect blocks, reconnecting if the connection fails, until ctx is done, returning ctx.Err().
+// See WithReconnectDelay, the delay is measured using the Collection's Clock.
+func (r *Replicator) Connect(ctx context.Context, conn grpc.ClientConnInterface, name string) error {
+	delay := r.rc.minReconnectDelay
+	for {
+		connected, err := r.connectOnce(ctx, conn, name)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			delay = r.rc.minReconnectDelay
+		}
+		if err != nil {
+			log.Printf("WARN: replicate %q with %v: %v, reconnecting in %v", name, r.node, err, delay)
+		}
+		wait := make(chan struct{})
+		stop := afterFunc(r.c.clock, r.c.clock.Now().Add(delay), func() { close(wait) })
+		select {
+		case <-ctx.Done():
+			stop()
+			return ctx.Err()
+		case <-wait:
+		}
+		delay = min(delay*2, r.rc.maxReconnectDelay)
+	}
+}
+
+// connectOnce opens a single stream to the server, syncing until the stream fails.
+// Returns whether the server accepted the connection.
+func (r *Replicator) connectOnce(ctx context.Context, conn grpc.ClientConnInterface, name string) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := conn.NewStream(ctx, &replicationServiceDesc.Streams[0], replicateMethod)
+	if err != nil {
+		return false, err
+	}
+	if err := sendReplicaFrame(stream, replicaFrame{collection: name, node: r.node}); err != nil {
+		return false, err
+	}
+	hello, err := recvReplicaFrame(stream)
+	if err != nil {
+		return false, err
+	}
+	err = r.sync(ctx, hello.node,
+		func(e replicaFrame) error { return sendReplicaFrame(stream, e) },
+		func() (replicaFrame, error) { return recvReplicaFrame(stream) },
+	)
+	return true, err
+}
+
+// msgStream is implemented by both grpc.ClientStream and grpc.ServerStream.
+type msgStream interface {
+	SendMsg(m any) error
+	RecvMsg(m any) error
+}
+
+func sendReplicaFrame(stream msgStream, e replicaFrame) error {
+	b, err := encodeReplicaFrame(e)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", e.ID, err)
+	}
+	return stream.SendMsg(wrapperspb.Bytes(b))
+}
+
+func recvReplicaFrame(stream msgStream) (replicaFrame, error) {
+	msg := &wrapperspb.BytesValue{}
+	if err := stream.RecvMsg(msg); err != nil {
+		return replicaFrame{}, err
+	}
+	e, err := decodeReplicaFrame(msg.Value)
+	if err != nil {
+		return e, status.Errorf(codes.InvalidArgument, "decode frame: %v", err)
+	}
+	return e, nil
+}
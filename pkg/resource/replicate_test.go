@@ -0,0 +1,303 @@
+package resource
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+)
+
+func TestReplicator_snapshot(t *testing.T) {
+	a, b := NewCollection(), NewCollection()
+	add(t, a, "a1", &traits.OnOff{State: traits.OnOff_ON})
+	add(t, a, "shared", &traits.OnOff{State: traits.OnOff_ON})
+	add(t, b, "b1", &traits.OnOff{State: traits.OnOff_OFF})
+	time.Sleep(time.Millisecond) // b's write to shared happens after a's
+	add(t, b, "shared", &traits.OnOff{State: traits.OnOff_OFF})
+
+	ra, rb := NewReplicator(a, "a"), NewReplicator(b, "b")
+	connectReplicators(t, ra, rb)
+
+	want := map[string]proto.Message{
+		"a1":     &traits.OnOff{State: traits.OnOff_ON},
+		"b1":     &traits.OnOff{State: traits.OnOff_OFF},
+		"shared": &traits.OnOff{State: traits.OnOff_OFF},
+	}
+	waitForItems(t, a, want)
+	waitForItems(t, b, want)
+	waitForSynced(t, ra, "b")
+	waitForSynced(t, rb, "a")
+}
+
+func TestReplicator_changes(t *testing.T) {
+	a, b := NewCollection(), NewCollection()
+	ra, rb := NewReplicator(a, "a"), NewReplicator(b, "b")
+	connectReplicators(t, ra, rb)
+
+	add(t, a, "1", &traits.OnOff{State: traits.OnOff_ON})
+	waitForItems(t, b, map[string]proto.Message{"1": &traits.OnOff{State: traits.OnOff_ON}})
+	if _, err := b.Update("1", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+	add(t, b, "2", &traits.OnOff{State: traits.OnOff_ON})
+	waitForItems(t, a, map[string]proto.Message{
+		"1": &traits.OnOff{State: traits.OnOff_OFF},
+		"2": &traits.OnOff{State: traits.OnOff_ON},
+	})
+	if _, err := a.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForItems(t, b, map[string]proto.Message{"2": &traits.OnOff{State: traits.OnOff_ON}})
+}
+
+func TestReplicator_chain(t *testing.T) {
+	a, b, c := NewCollection(), NewCollection(), NewCollection()
+	ra, rb, rc := NewReplicator(a, "a"), NewReplicator(b, "b"), NewReplicator(c, "c")
+	connectReplicators(t, ra, rb)
+	connectReplicators(t, rc, rb)
+
+	add(t, a, "1", &traits.OnOff{State: traits.OnOff_ON})
+	waitForItems(t, c, map[string]proto.Message{"1": &traits.OnOff{State: traits.OnOff_ON}})
+	add(t, c, "2", &traits.OnOff{State: traits.OnOff_OFF})
+	waitForItems(t, a, map[string]proto.Message{
+		"1": &traits.OnOff{State: traits.OnOff_ON},
+		"2": &traits.OnOff{State: traits.OnOff_OFF},
+	})
+}
+
+func TestReplicator_applyRemote(t *testing.T) {
+	t0 := time.Unix(100, 0)
+	c := NewCollection(WithClock(newManualClock(t0)))
+	r := NewReplicator(c, "b")
+	add(t, c, "1", &traits.OnOff{State: traits.OnOff_ON})
+
+	remote := func(origin string, at time.Time, msg proto.Message) {
+		t.Helper()
+		e := replicaFrame{origin: origin}
+		e.ID, e.ChangeTime, e.Value, e.deleted = "1", at, msg, msg == nil
+		if err := r.applyRemote(nil, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertState := func(want traits.OnOff_State) {
+		t.Helper()
+		got, _ := c.Get("1")
+		if diff := cmp.Diff(&traits.OnOff{State: want}, got, protocmp.Transform()); diff != "" {
+			t.Fatalf("(-want,+got)\n%s", diff)
+		}
+	}
+
+	remote("a", t0.Add(-time.Second), &traits.OnOff{State: traits.OnOff_OFF})
+	assertState(traits.OnOff_ON) // older writes lose
+	remote("a", t0, &traits.OnOff{State: traits.OnOff_OFF})
+	assertState(traits.OnOff_ON) // same time, lower node id loses
+	remote("c", t0, &traits.OnOff{State: traits.OnOff_OFF})
+	assertState(traits.OnOff_OFF) // same time, higher node id wins
+
+	// our clock is behind the last write, but local writes still win
+	if _, err := c.Update("1", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	assertState(traits.OnOff_ON)
+	if got := r.meta["1"]; !got.writeTime.After(t0) || got.origin != "b" {
+		t.Fatalf("want local write after %v by b, got %v by %v", t0, got.writeTime, got.origin)
+	}
+
+	// deletes are remembered
+	remote("a", t0.Add(time.Minute), nil)
+	if _, ok := c.Get("1"); ok {
+		t.Fatalf("want deleted")
+	}
+	remote("a", t0.Add(30*time.Second), &traits.OnOff{State: traits.OnOff_OFF})
+	if _, ok := c.Get("1"); ok {
+		t.Fatalf("want older write ignored after delete")
+	}
+}
+
+func TestReplicator_existingItems(t *testing.T) {
+	t0 := time.Unix(100, 0)
+	c := NewCollection(WithClock(newManualClock(t0)), WithInitialRecord("1", &traits.OnOff{State: traits.OnOff_ON}))
+	r := NewReplicator(c, "b")
+	if got := r.meta["1"]; got.origin != "" {
+		t.Fatalf("want existing item with no origin, got %+v", got)
+	}
+	if _, err := c.Update("1", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Update("1", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.meta["1"]; got.origin != "b" {
+		t.Fatalf("want write by b, got %+v", got)
+	}
+}
+
+func TestWithTombstoneTTL(t *testing.T) {
+	clock := newManualClock(time.Unix(100, 0))
+	c := NewCollection(WithClock(clock))
+	r := NewReplicator(c, "a", WithTombstoneTTL(time.Minute))
+	add(t, c, "1", &traits.OnOff{})
+	add(t, c, "2", &traits.OnOff{})
+	if _, err := c.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.meta["1"]; !ok {
+		t.Fatalf("want tombstone for 1")
+	}
+
+	clock.advance(2 * time.Minute)
+	if _, err := c.Delete("2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.meta["1"]; ok {
+		t.Fatalf("want expired tombstone for 1 removed")
+	}
+	if _, ok := r.meta["2"]; !ok {
+		t.Fatalf("want tombstone for 2")
+	}
+}
+
+func TestReplicator_reconnect(t *testing.T) {
+	a, b := NewCollection(), NewCollection()
+	ra := NewReplicator(a, "a", WithReconnectDelay(time.Millisecond, 10*time.Millisecond))
+	rb := NewReplicator(b, "b")
+
+	var mu sync.Mutex
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	RegisterReplicationServer(server, map[string]*Replicator{"test": rb})
+	go server.Serve(lis)
+	conn := dialBufconn(t, func() *bufconn.Listener {
+		mu.Lock()
+		defer mu.Unlock()
+		return lis
+	})
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	go ra.Connect(ctx, conn, "test")
+
+	add(t, a, "1", &traits.OnOff{State: traits.OnOff_ON})
+	waitForItems(t, b, map[string]proto.Message{"1": &traits.OnOff{State: traits.OnOff_ON}})
+
+	server.Stop()
+	// changes made while disconnected
+	add(t, a, "2", &traits.OnOff{State: traits.OnOff_ON})
+	add(t, b, "3", &traits.OnOff{State: traits.OnOff_OFF})
+
+	mu.Lock()
+	lis = bufconn.Listen(1024 * 1024)
+	server = grpc.NewServer()
+	RegisterReplicationServer(server, map[string]*Replicator{"test": rb})
+	go server.Serve(lis)
+	mu.Unlock()
+	t.Cleanup(server.Stop)
+
+	want := map[string]proto.Message{
+		"1": &traits.OnOff{State: traits.OnOff_ON},
+		"2": &traits.OnOff{State: traits.OnOff_ON},
+		"3": &traits.OnOff{State: traits.OnOff_OFF},
+	}
+	waitForItems(t, a, want)
+	waitForItems(t, b, want)
+}
+
+func TestReplicator_unknownCollection(t *testing.T) {
+	rb := NewReplicator(NewCollection(), "b")
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	RegisterReplicationServer(server, map[string]*Replicator{"test": rb})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn := dialBufconn(t, func() *bufconn.Listener { return lis })
+
+	ra := NewReplicator(NewCollection(), "a")
+	_, err := ra.connectOnce(context.Background(), conn, "other")
+	if err == nil {
+		t.Fatalf("want error")
+	}
+}
+
+func TestReplicaFrame_encoding(t *testing.T) {
+	want := replicaFrame{origin: "a", snapshotEnd: true, collection: "c", node: "n"}
+	want.ID, want.Value, want.ChangeTime = "1", &traits.OnOff{State: traits.OnOff_ON}, time.Unix(10, 5)
+	b, err := encodeReplicaFrame(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeReplicaFrame(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform(), cmp.AllowUnexported(replicaFrame{}, storeEntry{})); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+}
+
+// connectReplicators connects client to server over an in-memory gRPC connection.
+func connectReplicators(t *testing.T, client, server *Replicator) {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	RegisterReplicationServer(s, map[string]*Replicator{"test": server})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn := dialBufconn(t, func() *bufconn.Listener { return lis })
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	go client.Connect(ctx, conn, "test")
+}
+
+func dialBufconn(t *testing.T, lis func() *bufconn.Listener) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis().DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitForItems waits for the items in c to equal want.
+func waitForItems(t *testing.T, c *Collection, want map[string]proto.Message) {
+	t.Helper()
+	var diff string
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		got := make(map[string]proto.Message)
+		c.mu.RLock()
+		for id, it := range c.byId {
+			got[id] = it.body
+		}
+		c.mu.RUnlock()
+		if diff = cmp.Diff(want, got, protocmp.Transform()); diff == "" {
+			return
+		}
+	}
+	t.Fatalf("items (-want,+got)\n%s", diff)
+}
+
+func waitForSynced(t *testing.T, r *Replicator, node string) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		for _, p := range r.Peers() {
+			if p.Node == node && p.Synced {
+				return
+			}
+		}
+	}
+	t.Fatalf("peer %v not synced, got %v", node, r.Peers())
+}
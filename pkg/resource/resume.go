@@ -0,0 +1,111 @@
+package resource
+
+import (
+	"context"
+)
+
+// WithResumeAfter instructs Collection.Pull to resume a previous Pull that last received a change with
+// CollectionChange.Seq equal to seq.
+// If all changes made after seq are still retained, they are replayed in order before switching to live updates and
+// no seed values are sent.
+// Otherwise, a full resync is required: all current items are sent as seed values with CollectionChange.Resync set,
+// and consumers should discard any state they hold for the collection before applying them.
+//
+// Changes are retained using the resources history, see WithHistoryCapacity and WithHistoryMaxAge.
+// Without history a Pull can only be resumed if no changes have been made since seq.
+// Applicable only to Collection.
+func WithResumeAfter(seq uint64) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.Resume = true
+		rr.ResumeAfter = seq
+	})
+}
+
+// initialSeq returns the sequence number a new Collection starts counting from.
+// Sequences start from the current time so that sequence numbers from a previous Collection,
+// say before a process restart, are older than any this Collection assigns and will require a resync.
+func initialSeq(c Clock) uint64 {
+	if n := c.Now().UnixNano(); n > 0 {
+		return uint64(n)
+	}
+	return 0
+}
+
+// nextSeq assigns the next sequence number to change.
+// c.mu must be held.
+func (c *Collection) nextSeq(change *CollectionChange) {
+	c.seq++
+	change.Seq = c.seq
+}
+
+// queueLocked queues change to be sent to all Pull subscribers by the next call to flush.
+// Queueing while c.mu is held means changes are sent in sequence order.
+// A nil change is ignored.
+// c.mu must be held.
+func (c *Collection) queueLocked(change *CollectionChange) {
+	if change == nil {
+		return
+	}
+	c.queueMu.Lock()
+	c.queue = append(c.queue, change)
+	c.queueMu.Unlock()
+}
+
+// queueGroupLocked is like queueLocked but changes are sent to subscribers as a single event.
+// c.mu must be held.
+func (c *Collection) queueGroupLocked(changes []*CollectionChange) {
+	if len(changes) == 0 {
+		return
+	}
+	c.queueMu.Lock()
+	c.queue = append(c.queue, changes)
+	c.queueMu.Unlock()
+}
+
+// flush sends all queued changes to Pull subscribers, in the order they were queued.
+// Changes queued by other writers are sent too, so a writer that fails to flush doesn't stop later changes being sent.
+// c.mu must not be held.
+func (c *Collection) flush() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	for {
+		c.queueMu.Lock()
+		events := c.queue
+		c.queue = nil
+		c.queueMu.Unlock()
+		if len(events) == 0 {
+			return
+		}
+		for _, event := range events {
+			c.bus.Send(context.TODO(), event)
+		}
+	}
+}
+
+// resumeChanges returns the retained changes made after seq, oldest first.
+// Returns false if any of those changes are no longer retained.
+// c.mu must be held.
+func (c *Collection) resumeChanges(seq uint64) ([]*CollectionChange, bool) {
+	if seq == c.seq {
+		return nil, true
+	}
+	if seq > c.seq || c.history == nil {
+		return nil, false
+	}
+	now := c.clock.Now()
+	for i := c.history.first(now); i < c.history.n; i++ {
+		change := c.history.at(i).change
+		if change.Seq <= seq {
+			continue
+		}
+		if change.Seq != seq+1 {
+			return nil, false // the change directly after seq has been discarded
+		}
+		var res []*CollectionChange
+		for ; i < c.history.n; i++ {
+			res = append(res, c.history.at(i).change)
+		}
+		return res, true
+	}
+	return nil, false
+}
@@ -0,0 +1,153 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestWithResumeAfter(t *testing.T) {
+	now := time.Unix(0, 0)
+	clock := clockFunc(func() time.Time {
+		return now
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		c := NewCollection(WithClock(clock), WithHistoryCapacity(10))
+		add(t, c, "a", &traits.OnOff{State: traits.OnOff_ON})
+
+		ctx, stop := context.WithCancel(context.Background())
+		changes := c.Pull(ctx)
+		seed := waitForChan(t, changes, time.Second)
+		stop()
+		if seed.Seq != 1 {
+			t.Fatalf("want seed Seq 1, got %v", seed.Seq)
+		}
+
+		// changes made while disconnected
+		_, _ = c.Update("a", &traits.OnOff{State: traits.OnOff_OFF})
+		add(t, c, "b", &traits.OnOff{State: traits.OnOff_ON})
+
+		ctx, stop = context.WithCancel(context.Background())
+		t.Cleanup(stop)
+		changes = c.Pull(ctx, WithResumeAfter(seed.Seq))
+		want := []*CollectionChange{
+			{Id: "a", ChangeTime: now, ChangeType: types.ChangeType_UPDATE, OldValue: &traits.OnOff{State: traits.OnOff_ON}, NewValue: &traits.OnOff{State: traits.OnOff_OFF}, Seq: 2, Version: "2"},
+			{Id: "b", ChangeTime: now, ChangeType: types.ChangeType_ADD, NewValue: &traits.OnOff{State: traits.OnOff_ON}, Seq: 3, Version: "3"},
+		}
+		for i, w := range want {
+			got := waitForChan(t, changes, time.Second)
+			if diff := cmp.Diff(w, got, protocmp.Transform()); diff != "" {
+				t.Fatalf("change %d (-want,+got)\n%s", i, diff)
+			}
+		}
+
+		_, _ = c.Delete("a")
+		got := waitForChan(t, changes, time.Second)
+		if got.Id != "a" || got.ChangeType != types.ChangeType_REMOVE || got.Seq != 4 {
+			t.Fatalf("want REMOVE a with Seq 4, got %v", got)
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		c := NewCollection(WithClock(clock))
+		add(t, c, "a", &traits.OnOff{})
+
+		ctx, stop := context.WithCancel(context.Background())
+		t.Cleanup(stop)
+		changes := c.Pull(ctx, WithResumeAfter(1))
+		noEmitWithin(t, changes, 50*time.Millisecond)
+	})
+
+	t.Run("resync", func(t *testing.T) {
+		c := NewCollection(WithClock(clock), WithHistoryCapacity(1))
+		add(t, c, "a", &traits.OnOff{})
+		add(t, c, "b", &traits.OnOff{})
+		add(t, c, "c", &traits.OnOff{})
+
+		ctx, stop := context.WithCancel(context.Background())
+		t.Cleanup(stop)
+		// the change with Seq 2 has been discarded from history
+		changes := c.Pull(ctx, WithResumeAfter(1))
+		for _, id := range []string{"a", "b", "c"} {
+			got := waitForChan(t, changes, time.Second)
+			if got.Id != id || !got.SeedValue || !got.Resync {
+				t.Fatalf("want resync seed %v, got %v", id, got)
+			}
+			if id == "c" && got.Seq != 3 {
+				t.Fatalf("want last seed Seq 3, got %v", got.Seq)
+			}
+		}
+	})
+
+	t.Run("resync empty", func(t *testing.T) {
+		c := NewCollection(WithClock(clock))
+		add(t, c, "a", &traits.OnOff{})
+		_, _ = c.Delete("a")
+
+		ctx, stop := context.WithCancel(context.Background())
+		t.Cleanup(stop)
+		changes := c.Pull(ctx, WithResumeAfter(1))
+		got := waitForChan(t, changes, time.Second)
+		want := &CollectionChange{SeedValue: true, LastSeedValue: true, Resync: true, Seq: 2}
+		if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+			t.Fatalf("(-want,+got)\n%s", diff)
+		}
+	})
+}
+
+func Test_mergeCollectionExcess_seq(t *testing.T) {
+	in := make(chan any)
+	out := mergeCollectionExcess(in, 1, nil, nil)
+	withSeq := func(s string, seq uint64) *CollectionChange {
+		change := parseCaseChange(s)
+		change.Seq = seq
+		return &change
+	}
+	for _, change := range []*CollectionChange{
+		withSeq("k0:foo", 1), // already seen
+		withSeq("k1:foo", 2),
+		withSeq("k2:foo", 3),
+		withSeq("k1:foo>bar", 4),
+	} {
+		in <- change
+	}
+	got := drain(out, 2)
+	// k1 has a change with Seq 2 that hasn't been sent yet, so it isn't safe to resume after 3
+	want := []CollectionChange{*withSeq("k2:foo", 1), *withSeq("k1:bar", 4)}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+}
+
+func TestCollection_unsentChange(t *testing.T) {
+	c := NewCollection()
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true))
+
+	// a change that is applied but never queued, say because the writer panicked, must not block later writers
+	c.mu.Lock()
+	c.apply("lost", &traits.OnOff{}, c.clock.Now())
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.Add("a", &traits.OnOff{})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("write blocked by an unsent change")
+	}
+	if got := waitForChan(t, changes, time.Second); got.Id != "a" {
+		t.Fatalf("want change for a, got %v", got)
+	}
+}
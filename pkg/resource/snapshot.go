@@ -0,0 +1,463 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Snapshot is the state of a set of named resources at a single point in time.
+// Use TakeSnapshot or SnapshotModel to create a Snapshot, and RestoreSnapshot or RestoreModel to apply it.
+//
+// A Snapshot can be encoded as binary protobuf, see MarshalBinary, or as JSON, see MarshalJSON.
+// Message types must be registered with protoregistry.GlobalTypes to be decoded.
+type Snapshot struct {
+	// Values holds the value of each *Value, keyed by resource name.
+	// StoreRecord.ID is empty.
+	Values map[string]StoreRecord
+	// Collections holds the items of each *Collection, keyed by resource name, sorted by ID.
+	Collections map[string][]StoreRecord
+}
+
+// ResourceModel is implemented by models that expose their resources, like the Model types of the trait packages.
+type ResourceModel interface {
+	// Resources returns the *Value and *Collection resources of the model keyed by name.
+	// The names identify the resources in a Snapshot, they should not change between releases.
+	Resources() map[string]Participant
+}
+
+// ModelResources returns the resources of model keyed by name, see ResourceModel.
+// Nil resources are skipped.
+// Returns an error if model does not implement ResourceModel.
+func ModelResources(model any) (map[string]Participant, error) {
+	rm, ok := model.(ResourceModel)
+	if !ok {
+		return nil, fmt.Errorf("model %T does not implement ResourceModel", model)
+	}
+	res := make(map[string]Participant)
+	for name, p := range rm.Resources() {
+		switch p := p.(type) {
+		case *Value:
+			if p == nil {
+				continue
+			}
+		case *Collection:
+			if p == nil {
+				continue
+			}
+		case nil:
+			continue
+		}
+		res[name] = p
+	}
+	return res, nil
+}
+
+// SnapshotModel takes a snapshot of all the resources of model, see ModelResources and TakeSnapshot.
+func SnapshotModel(model any) (*Snapshot, error) {
+	resources, err := ModelResources(model)
+	if err != nil {
+		return nil, err
+	}
+	return TakeSnapshot(resources)
+}
+
+// RestoreModel restores the resources of model from s, see ModelResources and RestoreSnapshot.
+func RestoreModel(model any, s *Snapshot) error {
+	resources, err := ModelResources(model)
+	if err != nil {
+		return err
+	}
+	return RestoreSnapshot(resources, s)
+}
+
+// TakeSnapshot captures the state of resources, all at the same point in time.
+// Each resource must be a *Value or *Collection.
+func TakeSnapshot(resources map[string]Participant) (*Snapshot, error) {
+	s := &Snapshot{
+		Values:      make(map[string]StoreRecord),
+		Collections: make(map[string][]StoreRecord),
+	}
+	// a transaction that makes no writes gives us a consistent view of all resources
+	err := Transact(func(tx *Transaction) error {
+		for name, p := range resources {
+			switch p := p.(type) {
+			case *Value:
+				if p.value == nil {
+					continue
+				}
+				s.Values[name] = StoreRecord{Value: proto.Clone(p.value), ChangeTime: p.changeTime}
+			case *Collection:
+				items := make([]StoreRecord, 0, len(p.byId))
+				for id, it := range p.byId {
+					items = append(items, StoreRecord{ID: id, Value: proto.Clone(it.body), ChangeTime: it.changeTime})
+				}
+				sort.Slice(items, func(i, j int) bool {
+					return items[i].ID < items[j].ID
+				})
+				s.Collections[name] = items
+			}
+		}
+		return nil
+	}, participants(resources)...)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// RestoreSnapshot replaces the state of resources with the state recorded in s.
+// Values are replaced, and Collections are made to contain exactly the items in s, keeping the ChangeTime of each
+// record. Resources not present in s are left unchanged.
+// All resources are updated in a single Transaction, if any resource can't be restored then none are.
+//
+// Returns an error if s contains a resource that is not in resources, or the type of a resource does not match.
+// Any write options configured on the resources, like WithValidation, apply to the restored values.
+func RestoreSnapshot(resources map[string]Participant, s *Snapshot) error {
+	for name := range s.Values {
+		if _, ok := resources[name].(*Value); !ok {
+			return status.Errorf(codes.InvalidArgument, "restore: no Value named %q", name)
+		}
+	}
+	for name := range s.Collections {
+		if _, ok := resources[name].(*Collection); !ok {
+			return status.Errorf(codes.InvalidArgument, "restore: no Collection named %q", name)
+		}
+	}
+
+	return Transact(func(tx *Transaction) error {
+		for name, r := range s.Values {
+			v := resources[name].(*Value)
+			if err := checkSameType(tx.valueState(v), r.Value); err != nil {
+				return fmt.Errorf("restore %s: %w", name, err)
+			}
+			if _, err := tx.Set(v, r.Value, WithAllFieldsWritable(), WithWriteTime(r.ChangeTime)); err != nil {
+				return fmt.Errorf("restore %s: %w", name, err)
+			}
+		}
+		for name, items := range s.Collections {
+			c := resources[name].(*Collection)
+			keep := make(map[string]struct{}, len(items))
+			for _, r := range items {
+				keep[r.ID] = struct{}{}
+				if old, ok := tx.itemState(c, r.ID); ok {
+					if err := checkSameType(old.body, r.Value); err != nil {
+						return fmt.Errorf("restore %s %s: %w", name, r.ID, err)
+					}
+				}
+				_, err := tx.UpdateItem(c, r.ID, r.Value, WithCreateIfAbsent(), WithAllFieldsWritable(), WithWriteTime(r.ChangeTime))
+				if err != nil {
+					return fmt.Errorf("restore %s: %w", name, err)
+				}
+			}
+			for id := range c.byId {
+				if _, ok := keep[id]; ok {
+					continue
+				}
+				if _, err := tx.DeleteItem(c, id, WithAllowMissing(true)); err != nil {
+					return fmt.Errorf("restore %s: %w", name, err)
+				}
+			}
+		}
+		return nil
+	}, participants(resources)...)
+}
+
+func participants(resources map[string]Participant) []Participant {
+	seen := make(map[Participant]struct{}, len(resources))
+	res := make([]Participant, 0, len(resources))
+	for _, p := range resources {
+		if _, ok := seen[p]; ok {
+			continue // the same resource with different names, we can only lock it once
+		}
+		seen[p] = struct{}{}
+		res = append(res, p)
+	}
+	return res
+}
+
+func checkSameType(old, msg proto.Message) error {
+	if msg == nil {
+		return status.Error(codes.InvalidArgument, "missing value")
+	}
+	if old == nil {
+		return nil
+	}
+	if want, got := old.ProtoReflect().Descriptor().FullName(), msg.ProtoReflect().Descriptor().FullName(); want != got {
+		return status.Errorf(codes.InvalidArgument, "want %s, got %s", want, got)
+	}
+	return nil
+}
+
+// MarshalBinary encodes s as if it were the message
+//
+//	message Snapshot {
+//	  repeated Resource resources = 1; // sorted by name
+//	}
+//	message Resource {
+//	  string name = 1;
+//	  Entry value = 2; // for Values
+//	  repeated Entry items = 3; // for Collections
+//	  bool collection = 4;
+//	}
+//
+// where Entry is encoded as per the file Store.
+func (s *Snapshot) MarshalBinary() ([]byte, error) {
+	var b []byte
+	for _, name := range s.names() {
+		var rb []byte
+		rb = protowire.AppendTag(rb, snapshotNameField, protowire.BytesType)
+		rb = protowire.AppendString(rb, name)
+		if r, ok := s.Values[name]; ok {
+			eb, err := encodeStoreEntry(storeEntry{StoreRecord: r})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			rb = protowire.AppendTag(rb, snapshotValueField, protowire.BytesType)
+			rb = protowire.AppendBytes(rb, eb)
+		} else {
+			for _, r := range s.Collections[name] {
+				eb, err := encodeStoreEntry(storeEntry{StoreRecord: r})
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", name, r.ID, err)
+				}
+				rb = protowire.AppendTag(rb, snapshotItemsField, protowire.BytesType)
+				rb = protowire.AppendBytes(rb, eb)
+			}
+			rb = protowire.AppendTag(rb, snapshotCollectionField, protowire.VarintType)
+			rb = protowire.AppendVarint(rb, protowire.EncodeBool(true))
+		}
+		b = protowire.AppendTag(b, snapshotResourcesField, protowire.BytesType)
+		b = protowire.AppendBytes(b, rb)
+	}
+	return b, nil
+}
+
+// Field numbers used by Snapshot.MarshalBinary.
+const (
+	snapshotResourcesField protowire.Number = 1
+
+	snapshotNameField       protowire.Number = 1
+	snapshotValueField      protowire.Number = 2
+	snapshotItemsField      protowire.Number = 3
+	snapshotCollectionField protowire.Number = 4
+)
+
+// UnmarshalBinary decodes data written by MarshalBinary into s, replacing any existing contents.
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	s.Values = make(map[string]StoreRecord)
+	s.Collections = make(map[string][]StoreRecord)
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != snapshotResourcesField || typ != protowire.BytesType {
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+		rb, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+		return n, s.unmarshalResource(rb)
+	})
+}
+
+func (s *Snapshot) unmarshalResource(b []byte) error {
+	var (
+		name       string
+		value      *StoreRecord
+		items      []StoreRecord
+		collection bool
+	)
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == snapshotNameField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			name = v
+			return n, nil
+		case (num == snapshotValueField || num == snapshotItemsField) && typ == protowire.BytesType:
+			eb, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			e, err := decodeStoreEntry(eb)
+			if err != nil {
+				return n, err
+			}
+			if num == snapshotValueField {
+				value = &e.StoreRecord
+			} else {
+				items = append(items, e.StoreRecord)
+			}
+			return n, nil
+		case num == snapshotCollectionField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			collection = protowire.DecodeBool(v)
+			return n, nil
+		default:
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	switch {
+	case collection:
+		s.Collections[name] = items
+	case value != nil:
+		s.Values[name] = *value
+	}
+	return nil
+}
+
+// consumeFields calls fn for each field in b, fn returns the number of bytes of b it consumed, or a negative
+// protowire error code.
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// snapshotJSON is the JSON form of a Snapshot.
+type snapshotJSON struct {
+	Values      map[string]snapshotRecordJSON   `json:"values,omitempty"`
+	Collections map[string][]snapshotRecordJSON `json:"collections,omitempty"`
+}
+
+type snapshotRecordJSON struct {
+	ID         string          `json:"id,omitempty"`
+	Value      json.RawMessage `json:"value"`                // google.protobuf.Any in protojson form
+	ChangeTime *time.Time      `json:"changeTime,omitempty"` // RFC 3339
+}
+
+// MarshalJSON encodes s as JSON, with each value in the protojson form of a google.protobuf.Any, for example
+//
+//	{
+//	  "values": {"brightness": {"value": {"@type": "type.googleapis.com/smartcore.traits.Brightness", "levelPercent": 50}, "changeTime": "2023-01-02T03:04:05Z"}},
+//	  "collections": {"modes": [{"id": "eco", "value": {"@type": "..."}, "changeTime": "..."}]}
+//	}
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	js := snapshotJSON{
+		Values:      make(map[string]snapshotRecordJSON, len(s.Values)),
+		Collections: make(map[string][]snapshotRecordJSON, len(s.Collections)),
+	}
+	for name, r := range s.Values {
+		rj, err := recordToJSON(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		js.Values[name] = rj
+	}
+	for name, items := range s.Collections {
+		rjs := make([]snapshotRecordJSON, 0, len(items))
+		for _, r := range items {
+			rj, err := recordToJSON(r)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", name, r.ID, err)
+			}
+			rjs = append(rjs, rj)
+		}
+		js.Collections[name] = rjs
+	}
+	return json.Marshal(js)
+}
+
+// UnmarshalJSON decodes data written by MarshalJSON into s, replacing any existing contents.
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var js snapshotJSON
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&js); err != nil {
+		return err
+	}
+	s.Values = make(map[string]StoreRecord, len(js.Values))
+	s.Collections = make(map[string][]StoreRecord, len(js.Collections))
+	for name, rj := range js.Values {
+		r, err := recordFromJSON(rj)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		s.Values[name] = r
+	}
+	for name, rjs := range js.Collections {
+		items := make([]StoreRecord, 0, len(rjs))
+		for _, rj := range rjs {
+			r, err := recordFromJSON(rj)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", name, rj.ID, err)
+			}
+			items = append(items, r)
+		}
+		s.Collections[name] = items
+	}
+	return nil
+}
+
+func recordToJSON(r StoreRecord) (snapshotRecordJSON, error) {
+	rj := snapshotRecordJSON{ID: r.ID}
+	if !r.ChangeTime.IsZero() {
+		t := r.ChangeTime
+		rj.ChangeTime = &t
+	}
+	if r.Value == nil {
+		return rj, errors.New("missing value")
+	}
+	anyValue, err := anypb.New(r.Value)
+	if err != nil {
+		return rj, err
+	}
+	rj.Value, err = protojson.Marshal(anyValue)
+	return rj, err
+}
+
+func recordFromJSON(rj snapshotRecordJSON) (StoreRecord, error) {
+	r := StoreRecord{ID: rj.ID}
+	if rj.ChangeTime != nil {
+		r.ChangeTime = *rj.ChangeTime
+	}
+	anyValue := &anypb.Any{}
+	if err := protojson.Unmarshal(rj.Value, anyValue); err != nil {
+		return r, err
+	}
+	msg, err := anyValue.UnmarshalNew()
+	if err != nil {
+		return r, err
+	}
+	r.Value = msg
+	return r, nil
+}
+
+// names returns the names of all resources in s, sorted.
+func (s *Snapshot) names() []string {
+	names := make([]string, 0, len(s.Values)+len(s.Collections))
+	for name := range s.Values {
+		names = append(names, name)
+	}
+	for name := range s.Collections {
+		if _, ok := s.Values[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,198 @@
+package resource
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+)
+
+type snapshotTestModel struct {
+	onOff    *Value
+	presets  *Collection
+	unused   *Value
+	Exported *Value
+	snapshotTestEmbedded
+}
+
+type snapshotTestEmbedded struct {
+	brightness *Value
+}
+
+func newSnapshotTestModel(t0 time.Time) *snapshotTestModel {
+	clock := newManualClock(t0)
+	return &snapshotTestModel{
+		onOff:    NewValue(WithClock(clock), WithInitialValue(&traits.OnOff{})),
+		presets:  NewCollection(WithClock(clock)),
+		Exported: NewValue(WithClock(clock), WithInitialValue(&traits.OnOff{})),
+		snapshotTestEmbedded: snapshotTestEmbedded{
+			brightness: NewValue(WithClock(clock), WithInitialValue(&traits.Brightness{})),
+		},
+	}
+}
+
+func (m *snapshotTestModel) Resources() map[string]Participant {
+	return map[string]Participant{
+		"onOff":      m.onOff,
+		"presets":    m.presets,
+		"unused":     m.unused,
+		"Exported":   m.Exported,
+		"brightness": m.brightness,
+	}
+}
+
+func TestModelResources(t *testing.T) {
+	m := newSnapshotTestModel(time.Unix(0, 0))
+	got, err := ModelResources(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Participant{
+		"onOff":      m.onOff,
+		"presets":    m.presets,
+		"Exported":   m.Exported,
+		"brightness": m.brightness,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d resources, got %v", len(want), got)
+	}
+	for name, p := range want {
+		if got[name] != p {
+			t.Errorf("%s: want %p, got %p", name, p, got[name])
+		}
+	}
+
+	if _, err := ModelResources(*m); err == nil {
+		t.Errorf("want error for a model without Resources")
+	}
+}
+
+func TestSnapshotModel(t *testing.T) {
+	t0, t1 := time.Unix(10, 0).UTC(), time.Unix(20, 0).UTC()
+	src := newSnapshotTestModel(t0)
+	if _, err := src.onOff.Set(&traits.OnOff{State: traits.OnOff_ON}, WithWriteTime(t1)); err != nil {
+		t.Fatal(err)
+	}
+	add(t, src.presets, "a", &traits.LightPreset{Name: "a", Title: "A"})
+	add(t, src.presets, "b", &traits.LightPreset{Name: "b", Title: "B"})
+	if _, err := src.brightness.Set(&traits.Brightness{LevelPercent: 40}); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := SnapshotModel(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot.Values["onOff"].ChangeTime; !got.Equal(t1) {
+		t.Fatalf("want onOff change time %v, got %v", t1, got)
+	}
+
+	dst := newSnapshotTestModel(time.Unix(30, 0))
+	add(t, dst.presets, "c", &traits.LightPreset{Name: "c"})
+	if err := RestoreModel(dst, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	assertSameState(t, src, dst)
+}
+
+func TestSnapshot_encoding(t *testing.T) {
+	src := newSnapshotTestModel(time.Unix(10, 0).UTC())
+	if _, err := src.brightness.Set(&traits.Brightness{LevelPercent: 40}); err != nil {
+		t.Fatal(err)
+	}
+	add(t, src.presets, "a", &traits.LightPreset{Name: "a", Title: "A"})
+	snapshot, err := SnapshotModel(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		b, err := snapshot.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &Snapshot{}
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(snapshot, got, protocmp.Transform()); diff != "" {
+			t.Fatalf("(-want,+got)\n%s", diff)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &Snapshot{}
+		if err := json.Unmarshal(b, got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(snapshot, got, protocmp.Transform()); diff != "" {
+			t.Fatalf("(-want,+got)\n%s", diff)
+		}
+	})
+	t.Run("empty collection", func(t *testing.T) {
+		s := &Snapshot{Collections: map[string][]StoreRecord{"presets": {}}, Values: map[string]StoreRecord{}}
+		b, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &Snapshot{}
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if items, ok := got.Collections["presets"]; !ok || len(items) != 0 {
+			t.Fatalf("want empty presets, got %v", got.Collections)
+		}
+	})
+}
+
+func TestRestoreSnapshot_errors(t *testing.T) {
+	m := newSnapshotTestModel(time.Unix(10, 0))
+	add(t, m.presets, "a", &traits.LightPreset{Name: "a"})
+
+	tests := map[string]*Snapshot{
+		"unknown value":      {Values: map[string]StoreRecord{"nope": {Value: &traits.OnOff{}}}},
+		"value is not Value": {Values: map[string]StoreRecord{"presets": {Value: &traits.OnOff{}}}},
+		"unknown collection": {Collections: map[string][]StoreRecord{"onOff": {}}},
+		"wrong value type":   {Values: map[string]StoreRecord{"onOff": {Value: &traits.Brightness{}}}},
+		"wrong item type": {
+			Values:      map[string]StoreRecord{"onOff": {Value: &traits.OnOff{State: traits.OnOff_ON}}},
+			Collections: map[string][]StoreRecord{"presets": {{ID: "a", Value: &traits.OnOff{}}}},
+		},
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := RestoreModel(m, s); err == nil {
+				t.Fatalf("want error")
+			}
+			// nothing was changed
+			if diff := cmp.Diff(&traits.OnOff{}, m.onOff.Get(), protocmp.Transform()); diff != "" {
+				t.Fatalf("onOff (-want,+got)\n%s", diff)
+			}
+			if got := m.presets.List(); len(got) != 1 {
+				t.Fatalf("want presets unchanged, got %v", got)
+			}
+		})
+	}
+}
+
+func assertSameState(t *testing.T, want, got *snapshotTestModel) {
+	t.Helper()
+	wantSnapshot, err := SnapshotModel(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotSnapshot, err := SnapshotModel(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(wantSnapshot, gotSnapshot, protocmp.Transform()); diff != "" {
+		t.Fatalf("(-want,+got)\n%s", diff)
+	}
+}
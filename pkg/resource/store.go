@@ -0,0 +1,37 @@
+package resource
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Store persists the state of a resource so it survives process restarts.
+// Resources configured WithStore load their state from the Store when created and save every committed change to it.
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Load returns all the records previously saved to the store.
+	// A Value uses a single record with an empty ID.
+	Load() ([]StoreRecord, error)
+	// Save durably records that the item identified by record.ID now has the value record.Value.
+	Save(record StoreRecord) error
+	// Delete durably records that the item identified by id no longer exists.
+	Delete(id string) error
+}
+
+// StoreRecord is a single persisted item in a Store.
+type StoreRecord struct {
+	ID         string
+	Value      proto.Message
+	ChangeTime time.Time
+}
+
+// WithStore configures the resource to load its initial state from s, and to save all committed changes to s.
+// State restored from s takes precedence over WithInitialValue and WithInitialRecord, these are only used, and saved,
+// when s has no records.
+func WithStore(s Store) Option {
+	return optionFunc(func(c *config) {
+		c.store = s
+	})
+}
@@ -0,0 +1,412 @@
+package resource
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FileStore is a Store that persists records to a single file on disk.
+// Changes are appended to the file and synced before Save or Delete return.
+// The file is periodically compacted so it only holds the latest value for each record.
+//
+// Values are stored alongside their type URL, message types must be registered with protoregistry.GlobalTypes to be
+// loaded.
+type FileStore struct {
+	path string
+
+	mu      sync.Mutex
+	f       *os.File
+	live    map[string][]byte // encoded entries for each live record, used during compaction
+	entries int               // number of entries in the file
+	size    int64             // offset of the end of the last complete entry in the file
+}
+
+// minCompactEntries is the minimum number of entries a FileStore will contain before it considers compaction.
+const minCompactEntries = 64
+
+// NewFileStore opens or creates a FileStore backed by the file at path.
+// Close the store when it is no longer needed.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path: path,
+		live: make(map[string][]byte),
+	}
+	valid, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// drop any partially written entry, likely from a crash during a previous write
+	if err := f.Truncate(valid); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	s.f = f
+	s.size = valid
+	return s, nil
+}
+
+// Load returns all the live records in the file, sorted by ID.
+func (s *FileStore) Load() ([]StoreRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]StoreRecord, 0, len(s.live))
+	for _, b := range s.live {
+		e, err := decodeStoreEntry(b)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e.StoreRecord)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+// Save appends record to the file, returning once it has been synced to disk.
+// A nil error means record is durable, even if the compaction that may follow fails.
+func (s *FileStore) Save(record StoreRecord) error {
+	b, err := encodeStoreEntry(storeEntry{StoreRecord: record})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(b); err != nil {
+		return err
+	}
+	s.live[record.ID] = b
+	s.maybeCompact()
+	return nil
+}
+
+// Delete appends the removal of the record with the given id to the file, returning once it has been synced to disk.
+// A nil error means the removal is durable, even if the compaction that may follow fails.
+func (s *FileStore) Delete(id string) error {
+	b, err := encodeStoreEntry(storeEntry{StoreRecord: StoreRecord{ID: id}, deleted: true})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(b); err != nil {
+		return err
+	}
+	delete(s.live, id)
+	s.maybeCompact()
+	return nil
+}
+
+// Close closes the underlying file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// read populates s.live from the file, returning the offset of the end of the last complete entry.
+func (s *FileStore) read() (int64, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		b, n, err := readDelimited(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return offset, err
+		}
+		e, err := decodeStoreEntry(b)
+		if err != nil {
+			return offset, fmt.Errorf("%s at offset %d: %w", s.path, offset, err)
+		}
+		offset += int64(n)
+		s.entries++
+		if e.deleted {
+			delete(s.live, e.ID)
+		} else {
+			s.live[e.ID] = b
+		}
+	}
+}
+
+// append writes b as a new entry at the end of the file.
+// If the write fails the file is truncated to remove any partial entry, so later appends can still be read.
+func (s *FileStore) append(b []byte) error {
+	if s.f == nil {
+		return os.ErrClosed
+	}
+	n, err := s.f.Write(protowire.AppendBytes(nil, b))
+	if err != nil {
+		if n > 0 {
+			// after compaction s.f isn't opened in append mode, so its offset needs resetting too
+			if terr := s.f.Truncate(s.size); terr != nil {
+				return errors.Join(err, fmt.Errorf("truncate partial entry: %w", terr))
+			}
+			if _, serr := s.f.Seek(s.size, io.SeekStart); serr != nil {
+				return errors.Join(err, fmt.Errorf("seek after truncate: %w", serr))
+			}
+		}
+		return err
+	}
+	s.size += int64(n)
+	s.entries++
+	return s.f.Sync()
+}
+
+// maybeCompact compacts the file if it contains enough superseded entries.
+// Compaction errors are logged and not returned, the appended entries are already durable and compaction will be
+// tried again after later writes.
+func (s *FileStore) maybeCompact() {
+	if s.entries < minCompactEntries || s.entries < 2*len(s.live) {
+		return
+	}
+	if err := s.compact(); err != nil {
+		log.Printf("WARN: resource.FileStore failed to compact %s: %v", s.path, err)
+	}
+}
+
+// compact rewrites the file to contain only the live entries.
+// The new file is written alongside the old, given the same permissions, and atomically renamed over it.
+// The handle used to write the new file is kept for later appends, so s.f never refers to a file that has been
+// replaced.
+func (s *FileStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	// CreateTemp uses mode 0600, keep the permissions of the file we're replacing
+	if fi, err := s.f.Stat(); err != nil {
+		_ = tmp.Close()
+		return err
+	} else if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	ids := make([]string, 0, len(s.live))
+	for id := range s.live {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for _, id := range ids {
+		n, err := w.Write(protowire.AppendBytes(nil, s.live[id]))
+		if err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		size += int64(n)
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	// tmp is positioned at the end of the file, later appends continue from there
+	_ = s.f.Close()
+	s.f = tmp
+	s.entries = len(ids)
+	s.size = size
+	// the rename is only durable once the directory has been synced.
+	// Either the old or new file is in place and both hold the live records, so failing here is not fatal
+	return syncDir(filepath.Dir(s.path))
+}
+
+// syncDir fsyncs the directory at path, making changes to its entries durable.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// storeEntry is a StoreRecord as written to a file.
+type storeEntry struct {
+	StoreRecord
+	deleted bool
+}
+
+// Field numbers used when encoding a storeEntry.
+// The entry is encoded as if it were the message
+//
+//	message Entry {
+//	  string id = 1;
+//	  google.protobuf.Any value = 2;
+//	  google.protobuf.Timestamp change_time = 3;
+//	  bool deleted = 4;
+//	}
+const (
+	entryIDField         protowire.Number = 1
+	entryValueField      protowire.Number = 2
+	entryChangeTimeField protowire.Number = 3
+	entryDeletedField    protowire.Number = 4
+)
+
+func encodeStoreEntry(e storeEntry) ([]byte, error) {
+	var b []byte
+	if e.ID != "" {
+		b = protowire.AppendTag(b, entryIDField, protowire.BytesType)
+		b = protowire.AppendString(b, e.ID)
+	}
+	if e.Value != nil {
+		anyValue, err := anypb.New(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := proto.Marshal(anyValue)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, entryValueField, protowire.BytesType)
+		b = protowire.AppendBytes(b, vb)
+	}
+	if !e.ChangeTime.IsZero() {
+		tb, err := proto.Marshal(timestamppb.New(e.ChangeTime))
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, entryChangeTimeField, protowire.BytesType)
+		b = protowire.AppendBytes(b, tb)
+	}
+	if e.deleted {
+		b = protowire.AppendTag(b, entryDeletedField, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(true))
+	}
+	return b, nil
+}
+
+func decodeStoreEntry(b []byte) (storeEntry, error) {
+	var e storeEntry
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == entryIDField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			e.ID = v
+			b = b[n:]
+		case num == entryValueField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			anyValue := &anypb.Any{}
+			if err := proto.Unmarshal(v, anyValue); err != nil {
+				return e, err
+			}
+			msg, err := anyValue.UnmarshalNew()
+			if err != nil {
+				return e, err
+			}
+			e.Value = msg
+			b = b[n:]
+		case num == entryChangeTimeField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			ts := &timestamppb.Timestamp{}
+			if err := proto.Unmarshal(v, ts); err != nil {
+				return e, err
+			}
+			e.ChangeTime = ts.AsTime()
+			b = b[n:]
+		case num == entryDeletedField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			e.deleted = protowire.DecodeBool(v)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return e, protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return e, nil
+}
+
+// readDelimited reads a single varint length-prefixed byte slice from r.
+// n is the total number of bytes consumed from r.
+func readDelimited(r *bufio.Reader) (b []byte, n int, err error) {
+	var size uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= 64 {
+			return nil, n, errors.New("invalid length prefix")
+		}
+		c, err := r.ReadByte()
+		if err != nil {
+			if n > 0 && errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, n, err
+		}
+		n++
+		size |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			break
+		}
+	}
+	b = make([]byte, size)
+	m, err := io.ReadFull(r, b)
+	n += m
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, n, err
+	}
+	return b, n, nil
+}
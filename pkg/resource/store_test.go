@@ -0,0 +1,198 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	now := time.UnixMilli(1000).UTC()
+
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustSave(t, s, StoreRecord{ID: "b", Value: &traits.OnOff{State: traits.OnOff_ON}, ChangeTime: now})
+	mustSave(t, s, StoreRecord{ID: "a", Value: &traits.OnOff{State: traits.OnOff_ON}, ChangeTime: now})
+	mustSave(t, s, StoreRecord{ID: "a", Value: &traits.OnOff{State: traits.OnOff_OFF}, ChangeTime: now.Add(time.Second)})
+	mustSave(t, s, StoreRecord{ID: "c", Value: &traits.OnOff{State: traits.OnOff_OFF}, ChangeTime: now})
+	if err := s.Delete("c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a crash part way through writing an entry
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0x10, 0x0a})
+	_ = f.Close()
+
+	s, err = NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	got, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StoreRecord{
+		{ID: "a", Value: &traits.OnOff{State: traits.OnOff_OFF}, ChangeTime: now.Add(time.Second)},
+		{ID: "b", Value: &traits.OnOff{State: traits.OnOff_ON}, ChangeTime: now},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("Load (-want,+got)\n%s", diff)
+	}
+
+	// writes after recovering from a partial entry should be readable
+	mustSave(t, s, StoreRecord{ID: "d", Value: &traits.OnOff{State: traits.OnOff_ON}, ChangeTime: now})
+	s2, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s2.Close() })
+	got, err = s2.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("want 3 records, got %v", got)
+	}
+}
+
+func TestFileStore_compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	for i := 0; i < minCompactEntries*3; i++ {
+		mustSave(t, s, StoreRecord{ID: "a", Value: &traits.Brightness{LevelPercent: float32(i)}})
+	}
+	if s.entries >= minCompactEntries {
+		t.Fatalf("store not compacted, has %d entries", s.entries)
+	}
+
+	s2, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s2.Close() })
+	got, err := s2.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StoreRecord{{ID: "a", Value: &traits.Brightness{LevelPercent: float32(minCompactEntries*3 - 1)}}}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("Load (-want,+got)\n%s", diff)
+	}
+}
+
+func TestFileStore_compactKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < minCompactEntries*3; i++ {
+		mustSave(t, s, StoreRecord{ID: "a", Value: &traits.Brightness{LevelPercent: float32(i)}})
+	}
+	if s.entries >= minCompactEntries {
+		t.Fatalf("store not compacted, has %d entries", s.entries)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fi.Mode().Perm(); got != 0o640 {
+		t.Fatalf("mode after compaction: want %v, got %v", os.FileMode(0o640), got)
+	}
+}
+
+func TestWithStore(t *testing.T) {
+	t.Run("Value", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "value.db")
+		s := openFileStore(t, path)
+		v := NewValue(WithStore(s), WithInitialValue(&traits.OnOff{State: traits.OnOff_ON}))
+		if _, err := v.Set(&traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+			t.Fatal(err)
+		}
+		s.Close()
+
+		v = NewValue(WithStore(openFileStore(t, path)), WithInitialValue(&traits.OnOff{State: traits.OnOff_ON}))
+		if diff := cmp.Diff(&traits.OnOff{State: traits.OnOff_OFF}, v.Get(), protocmp.Transform()); diff != "" {
+			t.Fatalf("Get (-want,+got)\n%s", diff)
+		}
+	})
+
+	t.Run("Value initial", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "value.db")
+		s := openFileStore(t, path)
+		NewValue(WithStore(s), WithInitialValue(&traits.OnOff{State: traits.OnOff_ON}))
+		s.Close()
+
+		v := NewValue(WithStore(openFileStore(t, path)))
+		if diff := cmp.Diff(&traits.OnOff{State: traits.OnOff_ON}, v.Get(), protocmp.Transform()); diff != "" {
+			t.Fatalf("Get (-want,+got)\n%s", diff)
+		}
+	})
+
+	t.Run("Collection", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "collection.db")
+		s := openFileStore(t, path)
+		c := NewCollection(WithStore(s), WithInitialRecord("a", &traits.OnOff{State: traits.OnOff_ON}))
+		add(t, c, "b", &traits.OnOff{State: traits.OnOff_ON})
+		if _, err := c.Update("b", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Delete("a"); err != nil {
+			t.Fatal(err)
+		}
+		add(t, c, "c", &traits.OnOff{State: traits.OnOff_ON})
+		s.Close()
+
+		c = NewCollection(WithStore(openFileStore(t, path)), WithInitialRecord("a", &traits.OnOff{State: traits.OnOff_ON}))
+		want := []proto.Message{
+			&traits.OnOff{State: traits.OnOff_OFF},
+			&traits.OnOff{State: traits.OnOff_ON},
+		}
+		if diff := cmp.Diff(want, c.List(), protocmp.Transform()); diff != "" {
+			t.Fatalf("List (-want,+got)\n%s", diff)
+		}
+	})
+}
+
+func openFileStore(t *testing.T, path string) *FileStore {
+	t.Helper()
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func mustSave(t *testing.T, s Store, record StoreRecord) {
+	t.Helper()
+	if err := s.Save(record); err != nil {
+		t.Fatal(err)
+	}
+}
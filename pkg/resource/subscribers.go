@@ -0,0 +1,177 @@
+package resource
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smart-core-os/sc-golang/internal/minibus"
+)
+
+// WithMaxSubscribers limits the number of concurrent Pull calls on the resource.
+// Pull calls made while n subscribers are active return a closed chan, TryPull returns TooManySubscribers, and
+// any callback registered via WithRejectedCallback is called.
+// Subscriptions made internally, by Derive, Combine, and a Recorder, do not count towards n and are never rejected.
+// Zero means no limit, the default.
+func WithMaxSubscribers(n int) Option {
+	return optionFunc(func(c *config) {
+		c.maxSubscribers = n
+	})
+}
+
+// WithSubscriberTimeout disconnects subscribers that do not accept a change within d, measured using the resources
+// Clock, see WithClock.
+// Disconnected subscribers have their Pull chan closed, writes are not held up waiting for them.
+// Without this option a slow subscriber delays every write until it accepts the change, or the write gives up.
+// Only subscribers using WithBackpressure(true) can block writes, others have excess changes combined or dropped.
+func WithSubscriberTimeout(d time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.subscriberTimeout = d
+	})
+}
+
+// WithSlowSubscriberFunc calls fn when a subscriber has not accepted a change within threshold, measured using the
+// resources Clock, see WithClock.
+// fn is called at most once per change and subscriber, it should not block.
+// Only subscribers using WithBackpressure(true) can be slow, others accept changes immediately and have any excess
+// combined or dropped until they catch up.
+func WithSlowSubscriberFunc(threshold time.Duration, fn func(SubscriberStats)) Option {
+	return optionFunc(func(c *config) {
+		c.slowSubscriberThreshold = threshold
+		c.slowSubscriberFunc = fn
+	})
+}
+
+// SubscriberStats describes a single active Pull on a resource.
+// Times are measured using the resources Clock, see WithClock.
+//
+// Sent, Pending, and BlockedSince describe how quickly the subscriber accepts changes from the resource.
+// Without WithBackpressure(true) changes are accepted immediately and buffered, combining excess changes, while they
+// wait for the subscriber, so Pending and BlockedSince stay zero and Sent includes changes that were later combined.
+type SubscriberStats struct {
+	// Since is when the Pull started.
+	Since time.Time
+	// Sent is the number of changes accepted by the subscriber.
+	// Changes combined or dropped before reaching the subscriber are not counted.
+	Sent uint64
+	// LastSend is when the subscriber last accepted a change, zero if it hasn't accepted any.
+	LastSend time.Time
+	// Pending is the number of changes waiting for the subscriber to accept them, how far the subscriber lags behind.
+	Pending int
+	// BlockedSince is when the oldest pending change started waiting, zero if nothing is pending.
+	BlockedSince time.Time
+}
+
+// Blocked returns how long the subscriber has been blocking a change as of now, zero if nothing is pending.
+func (s SubscriberStats) Blocked(now time.Time) time.Duration {
+	if s.BlockedSince.IsZero() {
+		return 0
+	}
+	return now.Sub(s.BlockedSince)
+}
+
+// configureBus applies subscriber settings from c to bus.
+func (c *config) configureBus(bus *minibus.Bus) {
+	bus.MaxListeners = c.maxSubscribers
+	bus.Clock = c.clock
+	bus.SendTimeout = c.subscriberTimeout
+	if fn := c.slowSubscriberFunc; fn != nil {
+		bus.SlowThreshold = c.slowSubscriberThreshold
+		bus.OnSlow = func(stats minibus.ListenerStats) {
+			fn(SubscriberStats(stats))
+		}
+	}
+}
+
+// TooManySubscribers is returned by TryPull, and passed to any WithRejectedCallback, when a Pull is rejected because
+// the resource already has the maximum number of subscribers, see WithMaxSubscribers.
+var TooManySubscribers = status.Error(codes.ResourceExhausted, "too many subscribers")
+
+// WithRejectedCallback calls fn if Pull is rejected, for example with TooManySubscribers.
+// fn is called before the Pull chan is closed, so anything fn records can be read once the chan is closed.
+// Model servers use this, via RejectedErr, to return an error to the client instead of ending the stream normally.
+func WithRejectedCallback(fn func(err error)) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		prev := rr.RejectedCallback
+		rr.RejectedCallback = func(err error) {
+			if prev != nil {
+				prev(err)
+			}
+			fn(err)
+		}
+	})
+}
+
+// RejectedErr returns a ReadOption that records why a Pull was rejected, and a func returning that error.
+// The returned func returns nil if the Pull was not rejected, call it once the Pull chan has closed.
+// Model servers use this to return an error, like TooManySubscribers, to the client instead of ending the stream
+// normally:
+//
+//	rejected, pullErr := resource.RejectedErr()
+//	for change := range model.PullThing(ctx, rejected) {
+//		// send change
+//	}
+//	return pullErr()
+func RejectedErr() (ReadOption, func() error) {
+	var err error
+	return WithRejectedCallback(func(e error) { err = e }), func() error { return err }
+}
+
+// internalSubscriber marks a Pull as made by this package, so it is exempt from WithMaxSubscribers.
+func internalSubscriber() ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.internal = true
+	})
+}
+
+// listen subscribes to bus, returning nil if bus has too many subscribers.
+func listen(ctx context.Context, bus *minibus.Bus, config *ReadRequest) <-chan any {
+	if config.internal {
+		return bus.ListenExempt(ctx)
+	}
+	ch, err := bus.TryListen(ctx)
+	if err != nil {
+		log.Printf("WARN: Pull rejected, %d subscribers already active", bus.MaxListeners)
+		if config.RejectedCallback != nil {
+			config.RejectedCallback(TooManySubscribers)
+		}
+		return nil
+	}
+	return ch
+}
+
+// TryPull is like Pull but returns TooManySubscribers if the Pull is rejected, see WithMaxSubscribers.
+func (r *Value) TryPull(ctx context.Context, opts ...ReadOption) (<-chan *ValueChange, error) {
+	rejected, err := RejectedErr()
+	ch := r.Pull(ctx, append(opts, rejected)...)
+	return ch, err()
+}
+
+// TryPull is like Pull but returns TooManySubscribers if the Pull is rejected, see WithMaxSubscribers.
+func (c *Collection) TryPull(ctx context.Context, opts ...ReadOption) (<-chan *CollectionChange, error) {
+	rejected, err := RejectedErr()
+	ch := c.Pull(ctx, append(opts, rejected)...)
+	return ch, err()
+}
+
+func subscriberStats(bus *minibus.Bus) []SubscriberStats {
+	listeners := bus.Listeners()
+	res := make([]SubscriberStats, len(listeners))
+	for i, l := range listeners {
+		res[i] = SubscriberStats(l)
+	}
+	return res
+}
+
+// Subscribers returns the stats of all active Pull calls on this Value, oldest first.
+func (r *Value) Subscribers() []SubscriberStats {
+	return subscriberStats(&r.bus)
+}
+
+// Subscribers returns the stats of all active Pull calls on this Collection, oldest first.
+func (c *Collection) Subscribers() []SubscriberStats {
+	return subscriberStats(&c.bus)
+}
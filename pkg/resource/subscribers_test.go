@@ -0,0 +1,191 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestValue_Subscribers(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.OnOff{}))
+	ctx1, stop1 := context.WithCancel(context.Background())
+	t.Cleanup(stop1)
+	ctx2, stop2 := context.WithCancel(context.Background())
+	t.Cleanup(stop2)
+
+	changes := v.Pull(ctx1, WithUpdatesOnly(true))
+	_ = v.Pull(ctx2)
+	if got := len(v.Subscribers()); got != 2 {
+		t.Fatalf("want 2 subscribers, got %d", got)
+	}
+
+	if _, err := v.Set(&traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	waitForChan(t, changes, time.Second)
+	stats := v.Subscribers()[0]
+	if stats.Sent != 1 || stats.LastSend.IsZero() || stats.Pending != 0 {
+		t.Fatalf("want 1 sent and nothing pending, got %+v", stats)
+	}
+
+	stop2()
+	if got := len(v.Subscribers()); got != 1 {
+		t.Fatalf("want 1 subscriber after cancel, got %d", got)
+	}
+}
+
+func TestWithMaxSubscribers(t *testing.T) {
+	c := NewCollection(WithMaxSubscribers(1))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	_ = c.Pull(ctx)
+	rejected := c.Pull(ctx)
+	select {
+	case _, ok := <-rejected:
+		if ok {
+			t.Fatalf("want closed chan, got a change")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("want closed chan")
+	}
+}
+
+func TestCollection_TryPull(t *testing.T) {
+	c := NewCollection(WithMaxSubscribers(1))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	if _, err := c.TryPull(ctx); err != nil {
+		t.Fatalf("want first Pull accepted, got %v", err)
+	}
+	var rejectedErr error
+	_ = c.Pull(ctx, WithRejectedCallback(func(err error) { rejectedErr = err }))
+	if rejectedErr != TooManySubscribers {
+		t.Fatalf("want callback with TooManySubscribers, got %v", rejectedErr)
+	}
+	if _, err := c.TryPull(ctx); err != TooManySubscribers {
+		t.Fatalf("want TooManySubscribers, got %v", err)
+	}
+}
+
+func TestWithMaxSubscribers_derive(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.OnOff{}), WithMaxSubscribers(1))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	_ = Derive(ctx, v, func(msg proto.Message) proto.Message { return msg })
+	if _, err := v.TryPull(ctx); err != nil {
+		t.Fatalf("want Derive exempt from the limit, got %v", err)
+	}
+	if _, err := v.TryPull(ctx); err != TooManySubscribers {
+		t.Fatalf("want TooManySubscribers, got %v", err)
+	}
+}
+
+func TestWithSubscriberTimeout(t *testing.T) {
+	c := NewCollection(WithSubscriberTimeout(50 * time.Millisecond))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true), WithBackpressure(true))
+
+	// the Pull accepts the first change but doesn't send it on, blocking the second
+	add(t, c, "a", &traits.OnOff{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.Add("b", &traits.OnOff{})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("write blocked by slow subscriber")
+	}
+	if got := len(c.Subscribers()); got != 0 {
+		t.Fatalf("want slow subscriber disconnected, got %d subscribers", got)
+	}
+
+	if change := waitForChan(t, changes, time.Second); change.Id != "a" {
+		t.Fatalf("want a, got %v", change)
+	}
+	select {
+	case change, ok := <-changes:
+		if ok {
+			t.Fatalf("want closed chan, got %v", change)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("want closed chan")
+	}
+}
+
+func TestWithSlowSubscriberFunc(t *testing.T) {
+	slow := make(chan SubscriberStats, 1)
+	c := NewCollection(WithSlowSubscriberFunc(10*time.Millisecond, func(stats SubscriberStats) {
+		slow <- stats
+	}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true), WithBackpressure(true))
+
+	add(t, c, "a", &traits.OnOff{})
+	go c.Add("b", &traits.OnOff{})
+	stats := waitForChan(t, slow, time.Second)
+	if stats.Pending != 1 || stats.BlockedSince.IsZero() {
+		t.Fatalf("want 1 pending change, got %+v", stats)
+	}
+	if stats.Blocked(time.Now()) < 10*time.Millisecond {
+		t.Fatalf("want blocked for at least 10ms, got %v", stats.Blocked(time.Now()))
+	}
+	waitForChan(t, changes, time.Second)
+	waitForChan(t, changes, time.Second)
+}
+
+func TestWithSubscriberTimeout_clock(t *testing.T) {
+	clock := newManualClock(time.Unix(0, 0))
+	slow := make(chan SubscriberStats, 1)
+	c := NewCollection(WithClock(clock), WithSubscriberTimeout(time.Minute), WithSlowSubscriberFunc(time.Second, func(stats SubscriberStats) {
+		slow <- stats
+	}))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, WithUpdatesOnly(true), WithBackpressure(true))
+
+	// the Pull accepts the first change but doesn't send it on, blocking the second
+	add(t, c, "a", &traits.OnOff{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.Add("b", &traits.OnOff{})
+	}()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if subs := c.Subscribers(); len(subs) == 1 && subs[0].Pending == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for the write to block, got %+v", c.Subscribers())
+		}
+	}
+
+	clock.advance(time.Second)
+	stats := waitForChan(t, slow, time.Second)
+	if !stats.Since.Equal(time.Unix(0, 0)) || !stats.BlockedSince.Equal(time.Unix(0, 0)) || stats.Blocked(clock.Now()) != time.Second {
+		t.Fatalf("want stats measured using the clock, got %+v", stats)
+	}
+	select {
+	case <-done:
+		t.Fatalf("want the write blocked until the subscriber times out")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.advance(time.Minute)
+	waitForChan(t, done, time.Second)
+	if got := len(c.Subscribers()); got != 0 {
+		t.Fatalf("want slow subscriber disconnected, got %d subscribers", got)
+	}
+	if change := waitForChan(t, changes, time.Second); change.Id != "a" {
+		t.Fatalf("want a, got %v", change)
+	}
+}
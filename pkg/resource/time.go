@@ -20,6 +20,34 @@ func (r realClock) Now() time.Time {
 	return time.Now()
 }
 
+// timerClock is implemented by clocks that can notify when a time has been reached, for example clock.Clock.
+type timerClock interface {
+	At(t time.Time) <-chan time.Time
+}
+
+// afterFunc calls f in its own goroutine once c reaches t.
+// The returned func cancels the call if it has not already happened.
+// If c implements timerClock it is used to wait for t, otherwise a time.Timer is used.
+func afterFunc(c Clock, t time.Time, f func()) (stop func()) {
+	tc, ok := c.(timerClock)
+	if !ok {
+		timer := time.AfterFunc(t.Sub(c.Now()), f)
+		return func() { timer.Stop() }
+	}
+	done := make(chan struct{})
+	at := tc.At(t)
+	go func() {
+		select {
+		case <-done:
+		case _, ok := <-at:
+			if ok {
+				f()
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
 type clockFunc func() time.Time
 
 func (c clockFunc) Now() time.Time {
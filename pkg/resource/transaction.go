@@ -0,0 +1,465 @@
+package resource
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Participant is a resource that can take part in a Transaction.
+// Both *Value and *Collection are participants.
+type Participant interface {
+	txOrder() uint64
+	txLock() *sync.RWMutex
+}
+
+// NotParticipantError is returned when a Transaction is asked to access a resource that was not passed to Transact.
+// Transact also returns NotParticipantError if any such access was made, even if fn returns nil.
+var NotParticipantError = status.Error(codes.FailedPrecondition, "resource is not a participant in this transaction")
+
+// Transact runs fn with exclusive access to all the given participants.
+// Reads made via tx see the writes already made via tx.
+// If fn returns nil, all writes made via tx are committed together and change events are emitted to Pull subscribers
+// of each participant.
+// If fn returns an error, or any write cannot be committed, no writes are applied and the error is returned.
+// If fn panics, no writes are applied and the participants are unlocked before the panic continues.
+//
+// Preconditions like WithExpectedValue are evaluated against the state of the transaction at the time of the write.
+// Calling methods on participants directly from within fn, including from any interceptors, will deadlock,
+// always use tx instead.
+//
+// Changes are persisted to each participants Store, if configured, before being applied.
+// If a Store fails, previously saved changes are reverted on a best effort basis.
+func Transact(fn func(tx *Transaction) error, participants ...Participant) error {
+	participants = sortParticipants(participants)
+	for _, p := range participants {
+		p.txLock().Lock()
+	}
+	events, err := runLocked(fn, participants)
+	if err != nil {
+		return err
+	}
+	for _, send := range events {
+		send()
+	}
+	return nil
+}
+
+// runLocked runs fn as a transaction over participants, returning the events to send once participants are unlocked.
+// All participants must be locked, they are unlocked before runLocked returns, or panics.
+func runLocked(fn func(tx *Transaction) error, participants []Participant) ([]txEvent, error) {
+	defer func() {
+		for _, p := range participants {
+			p.txLock().Unlock()
+		}
+	}()
+	tx := &Transaction{
+		values:      make(map[*Value]*txValue),
+		collections: make(map[*Collection]*txCollection),
+	}
+	for _, p := range participants {
+		switch p := p.(type) {
+		case *Value:
+			tx.values[p] = &txValue{}
+		case *Collection:
+			tx.collections[p] = &txCollection{items: make(map[string]*item)}
+		}
+	}
+
+	return tx.run(fn)
+}
+
+func sortParticipants(participants []Participant) []Participant {
+	res := make([]Participant, 0, len(participants))
+	seen := make(map[Participant]struct{}, len(participants))
+	for _, p := range participants {
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		res = append(res, p)
+	}
+	// a consistent lock order avoids deadlock between concurrent transactions
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].txOrder() < res[j].txOrder()
+	})
+	return res
+}
+
+// Transaction provides access to the participants of a call to Transact.
+// A Transaction is not safe for concurrent use and must not be used after Transact returns.
+type Transaction struct {
+	values      map[*Value]*txValue
+	collections map[*Collection]*txCollection
+	// order that participants were first written to, so events can be emitted in a predictable order
+	order []Participant
+	err   error // NotParticipantError if a non-participant was accessed
+}
+
+type txValue struct {
+	changed    bool
+	value      proto.Message
+	changeTime time.Time
+}
+
+type txCollection struct {
+	items map[string]*item // nil *item means deleted
+	ids   []string         // order ids were first written
+}
+
+// txEvent sends an event committed by a transaction to the participants subscribers.
+type txEvent func()
+
+// Get returns the value of v as seen by this transaction.
+// Returns nil if v is not a participant, see NotParticipantError.
+func (tx *Transaction) Get(v *Value, opts ...ReadOption) proto.Message {
+	if tx.checkParticipant(v) != nil {
+		return nil
+	}
+	return ComputeReadConfig(opts...).FilterClone(tx.valueState(v))
+}
+
+// Set updates v within this transaction, returning the new value.
+// See Value.Set.
+// Returns NotParticipantError if v is not a participant.
+func (tx *Transaction) Set(v *Value, value proto.Message, opts ...WriteOption) (proto.Message, error) {
+	if err := tx.checkParticipant(v); err != nil {
+		return nil, err
+	}
+	request := ComputeWriteConfig(opts...)
+	writer := request.fieldUpdater(v.writableFields)
+	if err := writer.Validate(value); err != nil {
+		return nil, err
+	}
+	old := tx.valueState(v)
+	if tx.values[v].changed {
+		if err := request.checkNoVersion(); err != nil {
+			return nil, err
+		}
+	} else if err := request.checkVersion(v.version); err != nil {
+		return nil, err
+	}
+	newValue, err := v.validateChange(request.changeFn(writer, value))(old, proto.Clone(old))
+	if err != nil {
+		return nil, err
+	}
+	state := tx.values[v]
+	if !state.changed {
+		tx.order = append(tx.order, v)
+	}
+	state.changed = true
+	state.value = newValue
+	state.changeTime = request.updateTime(v.clock)
+	return newValue, nil
+}
+
+// GetItem returns the item identified by id in c as seen by this transaction.
+// See Collection.Get.
+// Returns false if c is not a participant, see NotParticipantError.
+func (tx *Transaction) GetItem(c *Collection, id string, opts ...ReadOption) (proto.Message, bool) {
+	if tx.checkParticipant(c) != nil {
+		return nil, false
+	}
+	if c.idInterceptor != nil {
+		id = c.idInterceptor(id)
+	}
+	it, ok := tx.itemState(c, id)
+	if !ok {
+		return nil, false
+	}
+	return ComputeReadConfig(opts...).FilterClone(it.body), true
+}
+
+// ListItems returns all the items in c as seen by this transaction, sorted by id.
+// See Collection.List, ReadOptions like WithIndexLookup are supported.
+// Returns nil if c is not a participant, see NotParticipantError.
+func (tx *Transaction) ListItems(c *Collection, opts ...ReadOption) []proto.Message {
+	if tx.checkParticipant(c) != nil {
+		return nil
+	}
+	readConfig := ComputeReadConfig(opts...)
+	state := tx.collectionState(c)
+	ids := make([]string, 0, len(c.byId)+len(state.items))
+	for id := range c.byId {
+		if _, staged := state.items[id]; !staged {
+			ids = append(ids, id)
+		}
+	}
+	for id, it := range state.items {
+		if it != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	filter := readConfig.ResponseFilter()
+	// the indexes of c don't include staged items, so lookups test each item instead
+	include := c.includeFunc(readConfig)
+	res := make([]proto.Message, 0, len(ids))
+	for _, id := range ids {
+		it, _ := tx.itemState(c, id)
+		if include != nil && !include(id, it.body) {
+			continue
+		}
+		res = append(res, filter.FilterClone(it.body))
+	}
+	return res
+}
+
+// AddItem adds body to c within this transaction.
+// See Collection.Add.
+// Returns NotParticipantError if c is not a participant.
+func (tx *Transaction) AddItem(c *Collection, id string, body proto.Message, opts ...WriteOption) (proto.Message, error) {
+	opts = append([]WriteOption{
+		WithExpectAbsent(), WithCreateIfAbsent(),
+	}, opts...)
+	return tx.UpdateItem(c, id, body, opts...)
+}
+
+// UpdateItem updates the item identified by id in c within this transaction.
+// See Collection.Update.
+// Returns NotParticipantError if c is not a participant.
+func (tx *Transaction) UpdateItem(c *Collection, id string, msg proto.Message, opts ...WriteOption) (proto.Message, error) {
+	if err := tx.checkParticipant(c); err != nil {
+		return nil, err
+	}
+	if c.idInterceptor != nil {
+		id = c.idInterceptor(id)
+	}
+	state := tx.collectionState(c)
+
+	writeRequest := ComputeWriteConfig(opts...)
+	writer := writeRequest.fieldUpdater(c.writableFields)
+	if err := writer.Validate(msg); err != nil {
+		return nil, err
+	}
+
+	if id == "" && writeRequest.genEmptyID {
+		var err error
+		id, err = GenerateUniqueId(c.rng, func(candidate string) bool {
+			if c.idInterceptor != nil {
+				candidate = c.idInterceptor(candidate)
+			}
+			_, exists := tx.itemState(c, candidate)
+			return exists
+		})
+		if err != nil {
+			return nil, err
+		}
+		if writeRequest.idCallback != nil {
+			writeRequest.idCallback(id)
+		}
+	}
+
+	var old proto.Message
+	if it, exists := tx.itemState(c, id); exists {
+		if writeRequest.expectAbsent {
+			return nil, ExpectAbsentPreconditionFailed
+		}
+		if err := tx.checkItemVersion(c, state, id, writeRequest); err != nil {
+			return nil, status.Errorf(status.Code(err), "%v %v", status.Convert(err).Message(), id)
+		}
+		old = it.body
+	} else {
+		if err := writeRequest.checkNoVersion(); err != nil {
+			return nil, status.Errorf(status.Code(err), "%v %v", status.Convert(err).Message(), id)
+		}
+		if !writeRequest.createIfAbsent {
+			return nil, status.Errorf(codes.NotFound, "id %v not found", id)
+		}
+		old = msg.ProtoReflect().New().Interface()
+		if writeRequest.createdCallback != nil {
+			writeRequest.createdCallback()
+		}
+	}
+
+	newValue, err := c.validateChange(writeRequest.changeFn(writer, msg))(old, proto.Clone(old))
+	if err != nil {
+		return nil, errorWithID(err, id)
+	}
+	tx.stageItem(c, state, id, &item{body: newValue, changeTime: writeRequest.updateTime(c.clock)})
+	return newValue, nil
+}
+
+// DeleteItem removes the item identified by id from c within this transaction, returning the removed item.
+// See Collection.Delete.
+// Returns NotParticipantError if c is not a participant.
+func (tx *Transaction) DeleteItem(c *Collection, id string, opts ...WriteOption) (proto.Message, error) {
+	if err := tx.checkParticipant(c); err != nil {
+		return nil, err
+	}
+	if c.idInterceptor != nil {
+		id = c.idInterceptor(id)
+	}
+	state := tx.collectionState(c)
+	args := ComputeWriteConfig(opts...)
+
+	old, exists := tx.itemState(c, id)
+	if !exists {
+		if !args.allowMissing {
+			return nil, status.Error(codes.NotFound, "not found")
+		}
+		return nil, nil
+	}
+	if args.expectedCheck != nil {
+		if err := args.expectedCheck(old.body); err != nil {
+			return old.body, err
+		}
+	}
+	if args.expectedValue != nil && !proto.Equal(old.body, args.expectedValue) {
+		return old.body, ExpectedValuePreconditionFailed
+	}
+	if err := tx.checkItemVersion(c, state, id, args); err != nil {
+		return old.body, err
+	}
+	tx.stageItem(c, state, id, nil)
+	return old.body, nil
+}
+
+// checkParticipant returns NotParticipantError if p was not passed to Transact, remembering the error so the
+// transaction fails.
+func (tx *Transaction) checkParticipant(p Participant) error {
+	var ok bool
+	switch p := p.(type) {
+	case *Value:
+		_, ok = tx.values[p]
+	case *Collection:
+		_, ok = tx.collections[p]
+	}
+	if !ok {
+		tx.err = NotParticipantError
+		return NotParticipantError
+	}
+	return nil
+}
+
+// valueState returns the value of v as seen by tx.
+// v must be a participant, see checkParticipant.
+func (tx *Transaction) valueState(v *Value) proto.Message {
+	state := tx.values[v]
+	if state.changed {
+		return state.value
+	}
+	return v.value
+}
+
+// collectionState returns the changes staged for c.
+// c must be a participant, see checkParticipant.
+func (tx *Transaction) collectionState(c *Collection) *txCollection {
+	return tx.collections[c]
+}
+
+func (tx *Transaction) itemState(c *Collection, id string) (*item, bool) {
+	state := tx.collectionState(c)
+	if it, staged := state.items[id]; staged {
+		return it, it != nil
+	}
+	it, ok := c.byId[id]
+	return it, ok
+}
+
+// checkItemVersion checks the expected version of request against the existing item identified by id.
+// Items written earlier in tx have no version.
+func (tx *Transaction) checkItemVersion(c *Collection, state *txCollection, id string, request WriteRequest) error {
+	if _, staged := state.items[id]; staged {
+		return request.checkNoVersion()
+	}
+	return request.checkVersion(c.byId[id].version)
+}
+
+func (tx *Transaction) stageItem(c *Collection, state *txCollection, id string, it *item) {
+	if len(state.ids) == 0 {
+		tx.order = append(tx.order, c)
+	}
+	if _, staged := state.items[id]; !staged {
+		state.ids = append(state.ids, id)
+	}
+	state.items[id] = it
+}
+
+// run calls fn then commits the staged changes if fn succeeds.
+// All participant locks must be held.
+func (tx *Transaction) run(fn func(tx *Transaction) error) ([]txEvent, error) {
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	if tx.err != nil {
+		return nil, tx.err
+	}
+
+	// persist first so a failing store doesn't leave memory and storage inconsistent
+	var undo []func()
+	revert := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+	for _, p := range tx.order {
+		switch p := p.(type) {
+		case *Value:
+			state := tx.values[p]
+			if err := p.save(state.value, state.changeTime); err != nil {
+				revert()
+				return nil, status.Errorf(codes.Unavailable, "store: %v", err)
+			}
+			oldValue, oldTime := p.value, p.changeTime
+			undo = append(undo, func() { _ = p.save(oldValue, oldTime) })
+		case *Collection:
+			state := tx.collections[p]
+			for _, id := range state.ids {
+				newItem := state.items[id]
+				var body proto.Message
+				var changeTime time.Time
+				if newItem != nil {
+					body, changeTime = newItem.body, newItem.changeTime
+				} else {
+					changeTime = p.clock.Now()
+				}
+				if err := p.save(id, body, changeTime); err != nil {
+					revert()
+					return nil, status.Errorf(codes.Unavailable, "store: %v %v", err, id)
+				}
+				var oldBody proto.Message
+				var oldTime time.Time
+				if old, ok := p.byId[id]; ok {
+					oldBody, oldTime = old.body, old.changeTime
+				}
+				undo = append(undo, func() { _ = p.save(id, oldBody, oldTime) })
+			}
+		}
+	}
+
+	var events []txEvent
+	for _, p := range tx.order {
+		switch p := p.(type) {
+		case *Value:
+			state := tx.values[p]
+			event := p.apply(state.value, state.changeTime)
+			events = append(events, func() { p.bus.Send(context.TODO(), event) })
+		case *Collection:
+			state := tx.collections[p]
+			for _, id := range state.ids {
+				var change *CollectionChange
+				if it := state.items[id]; it != nil {
+					change = p.apply(id, it.body, it.changeTime)
+				} else {
+					change = p.apply(id, nil, p.clock.Now())
+				}
+				p.queueLocked(change)
+			}
+			events = append(events, p.flush)
+		}
+	}
+	return events, nil
+}
+
+var participantSeq atomic.Uint64
+
+func nextParticipantOrder() uint64 {
+	return participantSeq.Add(1)
+}
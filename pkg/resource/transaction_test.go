@@ -0,0 +1,203 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+)
+
+func TestTransact(t *testing.T) {
+	t.Run("commit", func(t *testing.T) {
+		v := NewValue(WithInitialValue(&traits.OnOff{State: traits.OnOff_OFF}))
+		c := NewCollection(WithInitialRecord("a", &traits.OnOff{State: traits.OnOff_OFF}))
+
+		ctx, stop := context.WithCancel(context.Background())
+		t.Cleanup(stop)
+		valueChanges := v.Pull(ctx, WithUpdatesOnly(true))
+		collectionChanges := c.Pull(ctx, WithUpdatesOnly(true))
+
+		err := Transact(func(tx *Transaction) error {
+			if _, err := tx.Set(v, &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+				return err
+			}
+			// reads see earlier writes
+			if got := tx.Get(v).(*traits.OnOff).State; got != traits.OnOff_ON {
+				t.Errorf("tx.Get want ON, got %v", got)
+			}
+			if _, err := tx.UpdateItem(c, "a", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+				return err
+			}
+			if _, err := tx.AddItem(c, "b", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+				return err
+			}
+			// added then removed within the tx means no event
+			if _, err := tx.AddItem(c, "c", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+				return err
+			}
+			if _, err := tx.DeleteItem(c, "c"); err != nil {
+				return err
+			}
+			if got := len(tx.ListItems(c)); got != 2 {
+				t.Errorf("tx.ListItems want 2 items, got %d", got)
+			}
+			return nil
+		}, v, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(&traits.OnOff{State: traits.OnOff_ON}, v.Get(), protocmp.Transform()); diff != "" {
+			t.Fatalf("Value (-want,+got)\n%s", diff)
+		}
+		want := []proto.Message{&traits.OnOff{State: traits.OnOff_ON}, &traits.OnOff{State: traits.OnOff_ON}}
+		if diff := cmp.Diff(want, c.List(), protocmp.Transform()); diff != "" {
+			t.Fatalf("Collection (-want,+got)\n%s", diff)
+		}
+
+		waitForChan(t, valueChanges, time.Second)
+		change := waitForChan(t, collectionChanges, time.Second)
+		if change.Id != "a" || change.ChangeType != types.ChangeType_UPDATE {
+			t.Fatalf("unexpected change %v", change)
+		}
+		change = waitForChan(t, collectionChanges, time.Second)
+		if change.Id != "b" || change.ChangeType != types.ChangeType_ADD {
+			t.Fatalf("unexpected change %v", change)
+		}
+		noEmitWithin(t, collectionChanges, 50*time.Millisecond)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		v := NewValue(WithInitialValue(&traits.OnOff{State: traits.OnOff_OFF}))
+		c := NewCollection()
+
+		ctx, stop := context.WithCancel(context.Background())
+		t.Cleanup(stop)
+		valueChanges := v.Pull(ctx, WithUpdatesOnly(true))
+
+		wantErr := errors.New("abort")
+		err := Transact(func(tx *Transaction) error {
+			if _, err := tx.Set(v, &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+				return err
+			}
+			if _, err := tx.AddItem(c, "a", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+				return err
+			}
+			return wantErr
+		}, v, c)
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("want %v, got %v", wantErr, err)
+		}
+		if diff := cmp.Diff(&traits.OnOff{State: traits.OnOff_OFF}, v.Get(), protocmp.Transform()); diff != "" {
+			t.Fatalf("Value (-want,+got)\n%s", diff)
+		}
+		if got := c.List(); len(got) != 0 {
+			t.Fatalf("Collection should be empty, got %v", got)
+		}
+		noEmitWithin(t, valueChanges, 50*time.Millisecond)
+	})
+
+	t.Run("preconditions", func(t *testing.T) {
+		v := NewValue(WithInitialValue(&traits.OnOff{State: traits.OnOff_OFF}))
+		err := Transact(func(tx *Transaction) error {
+			if _, err := tx.Set(v, &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+				return err
+			}
+			// the expected value is compared to the value written above, not the committed value
+			_, err := tx.Set(v, &traits.OnOff{State: traits.OnOff_OFF}, WithExpectedValue(&traits.OnOff{State: traits.OnOff_OFF}))
+			return err
+		}, v)
+		if !errors.Is(err, ExpectedValuePreconditionFailed) {
+			t.Fatalf("want ExpectedValuePreconditionFailed, got %v", err)
+		}
+	})
+
+	t.Run("not participant", func(t *testing.T) {
+		v := NewValue(WithInitialValue(&traits.OnOff{}))
+		other := NewValue(WithInitialValue(&traits.OnOff{State: traits.OnOff_OFF}))
+		err := Transact(func(tx *Transaction) error {
+			if got := tx.Get(v); got != nil {
+				t.Errorf("want nil for a non-participant, got %v", got)
+			}
+			_, _ = tx.Set(other, &traits.OnOff{State: traits.OnOff_ON})
+			return nil
+		}, other)
+		if err != NotParticipantError {
+			t.Fatalf("want NotParticipantError, got %v", err)
+		}
+		if got := other.Get().(*traits.OnOff).State; got != traits.OnOff_OFF {
+			t.Fatalf("want no writes applied, got %v", got)
+		}
+	})
+
+	t.Run("add existing", func(t *testing.T) {
+		c := NewCollection(WithInitialRecord("a", &traits.OnOff{}))
+		err := Transact(func(tx *Transaction) error {
+			_, err := tx.AddItem(c, "a", &traits.OnOff{})
+			return err
+		}, c)
+		if err != ExpectAbsentPreconditionFailed {
+			t.Fatalf("want ExpectAbsentPreconditionFailed, got %v", err)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		v := NewValue(WithInitialValue(&traits.OnOff{State: traits.OnOff_OFF}))
+		func() {
+			defer func() { _ = recover() }()
+			_ = Transact(func(tx *Transaction) error {
+				_, _ = tx.Set(v, &traits.OnOff{State: traits.OnOff_ON})
+				panic("boom")
+			}, v)
+		}()
+		// v is unlocked and unchanged
+		if got := v.Get().(*traits.OnOff).State; got != traits.OnOff_OFF {
+			t.Fatalf("want no writes applied, got %v", got)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// move a counter between a and b, the total should always be 10
+		a := NewValue(WithInitialValue(&traits.Brightness{LevelPercent: 10}))
+		b := NewValue(WithInitialValue(&traits.Brightness{LevelPercent: 0}))
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			from, to := a, b
+			if i%2 == 0 {
+				from, to = b, a
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := Transact(func(tx *Transaction) error {
+					fromVal := tx.Get(from).(*traits.Brightness).LevelPercent
+					toVal := tx.Get(to).(*traits.Brightness).LevelPercent
+					if fromVal == 0 {
+						return nil
+					}
+					if _, err := tx.Set(from, &traits.Brightness{LevelPercent: fromVal - 1}); err != nil {
+						return err
+					}
+					_, err := tx.Set(to, &traits.Brightness{LevelPercent: toVal + 1})
+					return err
+				}, to, from)
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		total := a.Get().(*traits.Brightness).LevelPercent + b.Get().(*traits.Brightness).LevelPercent
+		if total != 10 {
+			t.Fatalf("want total 10, got %v", total)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package typed
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/smart-core-os/sc-api/go/types"
+
+	"github.com/smart-core-os/sc-golang/pkg/resource"
+)
+
+// Collection is a resource.Collection that holds messages of type T.
+type Collection[T proto.Message] struct {
+	collection *resource.Collection
+}
+
+// NewCollection creates a new Collection holding messages of type T.
+// See resource.NewCollection.
+func NewCollection[T proto.Message](opts ...resource.Option) *Collection[T] {
+	return WrapCollection[T](resource.NewCollection(opts...))
+}
+
+// WrapCollection returns a Collection backed by c.
+// All messages stored in c must be of type T.
+func WrapCollection[T proto.Message](c *resource.Collection) *Collection[T] {
+	return &Collection[T]{collection: c}
+}
+
+// Untyped returns the resource.Collection backing this Collection.
+func (c *Collection[T]) Untyped() *resource.Collection {
+	return c.collection
+}
+
+// Get will find the entry with the given ID. If no such entry exists, returns false.
+// See resource.Collection.Get.
+func (c *Collection[T]) Get(id string, opts ...resource.ReadOption) (T, bool) {
+	msg, ok := c.collection.Get(id, opts...)
+	return cast[T](msg), ok
+}
+
+// List returns a list of all the entries, sorted by their ID.
+// See resource.Collection.List.
+func (c *Collection[T]) List(opts ...resource.ReadOption) []T {
+	msgs := c.collection.List(opts...)
+	res := make([]T, len(msgs))
+	for i, msg := range msgs {
+		res[i] = cast[T](msg)
+	}
+	return res
+}
+
+// Page is a single page of items returned by Collection.ListPage.
+// See resource.Page.
+type Page[T proto.Message] struct {
+	Items         []T
+	NextPageToken string
+	TotalSize     int
+}
+
+// ListPage returns a page of items in the collection.
+// See resource.Collection.ListPage.
+func (c *Collection[T]) ListPage(pageSize int, pageToken string, opts ...resource.ReadOption) (*Page[T], error) {
+	page, err := c.collection.ListPage(pageSize, pageToken, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &Page[T]{NextPageToken: page.NextPageToken, TotalSize: page.TotalSize}
+	res.Items = make([]T, len(page.Items))
+	for i, msg := range page.Items {
+		res.Items[i] = cast[T](msg)
+	}
+	return res, nil
+}
+
+// Add associates the given body with the id.
+// See resource.Collection.Add.
+func (c *Collection[T]) Add(id string, body T, opts ...resource.WriteOption) (T, error) {
+	return castReturn[T](c.collection.Add(id, body, opts...))
+}
+
+// Update updates the entry with the given id.
+// See resource.Collection.Update.
+func (c *Collection[T]) Update(id string, body T, opts ...resource.WriteOption) (T, error) {
+	return castReturn[T](c.collection.Update(id, body, opts...))
+}
+
+// Delete removes the entry with the given id, returning the removed entry.
+// See resource.Collection.Delete.
+func (c *Collection[T]) Delete(id string, opts ...resource.WriteOption) (T, error) {
+	return castReturn[T](c.collection.Delete(id, opts...))
+}
+
+// Pull emits a CollectionChange on the returned chan whenever the collection changes.
+// See resource.Collection.Pull.
+func (c *Collection[T]) Pull(ctx context.Context, opts ...resource.ReadOption) <-chan CollectionChange[T] {
+	return mapChan(ctx, c.collection.Pull(ctx, opts...), newCollectionChange[T])
+}
+
+// PullID subscribes to changes for a single item in the collection.
+// See resource.Collection.PullID.
+func (c *Collection[T]) PullID(ctx context.Context, id string, opts ...resource.ReadOption) <-chan ValueChange[T] {
+	return mapChan(ctx, c.collection.PullID(ctx, id, opts...), newValueChange[T])
+}
+
+// ListHistory returns the changes retained by this Collection that match req, oldest first.
+// See resource.Collection.ListHistory.
+func (c *Collection[T]) ListHistory(req resource.HistoryRequest, opts ...resource.ReadOption) ([]CollectionChange[T], resource.HistoryInfo, error) {
+	changes, info, err := c.collection.ListHistory(req, opts...)
+	res := make([]CollectionChange[T], len(changes))
+	for i, change := range changes {
+		res[i] = newCollectionChange[T](change)
+	}
+	return res, info, err
+}
+
+// Clock returns the clock used by this resource for reporting time.
+func (c *Collection[T]) Clock() resource.Clock {
+	return c.collection.Clock()
+}
+
+// Subscribers returns the stats of all active Pull calls on this resource.
+// See resource.Collection.Subscribers.
+func (c *Collection[T]) Subscribers() []resource.SubscriberStats {
+	return c.collection.Subscribers()
+}
+
+// CollectionChange contains information about a change to a Collection.
+// See resource.CollectionChange.
+type CollectionChange[T proto.Message] struct {
+	Id         string
+	ChangeTime time.Time
+	ChangeType types.ChangeType
+	OldValue   T
+	NewValue   T
+	// Deprecated, use LastSeedValue instead.
+	// SeedValue will be true if the change was part of sending initial data as opposed to an update.
+	SeedValue bool
+	// LastSeedValue will be true if this change is the last change as part of the seed values.
+	LastSeedValue bool
+	// Seq identifies the position of this change in the sequence of changes made to the Collection.
+	// See resource.CollectionChange.Seq.
+	Seq uint64
+	// Version is the version of the new value, see resource.WithExpectedVersion.
+	Version string
+	// Resync will be true for seed values sent because a Pull could not be resumed.
+	// See resource.CollectionChange.Resync.
+	Resync bool
+	// BatchEnd will be true for the last change caused by a batch write.
+	// See resource.CollectionChange.BatchEnd.
+	BatchEnd bool
+}
+
+func newCollectionChange[T proto.Message](change *resource.CollectionChange) CollectionChange[T] {
+	return CollectionChange[T]{
+		Id:            change.Id,
+		ChangeTime:    change.ChangeTime,
+		ChangeType:    change.ChangeType,
+		OldValue:      cast[T](change.OldValue),
+		NewValue:      cast[T](change.NewValue),
+		SeedValue:     change.SeedValue,
+		LastSeedValue: change.LastSeedValue,
+		Version:       change.Version,
+		Seq:           change.Seq,
+		Resync:        change.Resync,
+		BatchEnd:      change.BatchEnd,
+	}
+}
@@ -0,0 +1,9 @@
+// Package typed provides generic wrappers around resource.Value and resource.Collection.
+// The wrappers accept and return concrete proto message types, removing the need to cast proto.Message values and to
+// adapt change channels when implementing trait models.
+//
+//	brightness := typed.NewValue[*traits.Brightness](resource.WithInitialValue(&traits.Brightness{}))
+//	for change := range brightness.Pull(ctx) {
+//	  fmt.Println(change.Value.LevelPercent)
+//	}
+package typed
@@ -0,0 +1,104 @@
+package typed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-api/go/types"
+
+	"github.com/smart-core-os/sc-golang/pkg/resource"
+)
+
+func TestValue(t *testing.T) {
+	v := NewValue[*traits.Brightness](resource.WithInitialValue(&traits.Brightness{LevelPercent: 10}))
+	if got := v.Get().LevelPercent; got != 10 {
+		t.Fatalf("Get want 10, got %v", got)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := v.Pull(ctx)
+	seed := waitForChan(t, changes)
+	if seed.Value.LevelPercent != 10 || !seed.LastSeedValue {
+		t.Fatalf("unexpected seed %+v", seed)
+	}
+
+	res, err := v.Set(&traits.Brightness{LevelPercent: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.LevelPercent != 20 {
+		t.Fatalf("Set want 20, got %v", res.LevelPercent)
+	}
+	next := waitForChan(t, changes)
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 20}, next.Value, protocmp.Transform()); diff != "" {
+		t.Fatalf("Pull (-want,+got)\n%s", diff)
+	}
+
+	empty := NewValue[*traits.Brightness]()
+	if got := empty.Get(); got != nil {
+		t.Fatalf("Get on empty value want nil, got %v", got)
+	}
+}
+
+func TestCollection(t *testing.T) {
+	c := NewCollection[*traits.OnOff]()
+	if _, err := c.Add("b", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Add("a", &traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := c.Get("a")
+	if !ok || got.State != traits.OnOff_OFF {
+		t.Fatalf("Get want OFF, got %v %v", got, ok)
+	}
+	if got, ok := c.Get("missing"); ok || got != nil {
+		t.Fatalf("Get missing want nil,false got %v,%v", got, ok)
+	}
+	want := []*traits.OnOff{{State: traits.OnOff_OFF}, {State: traits.OnOff_ON}}
+	if diff := cmp.Diff(want, c.List(), protocmp.Transform()); diff != "" {
+		t.Fatalf("List (-want,+got)\n%s", diff)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	changes := c.Pull(ctx, resource.WithUpdatesOnly(true))
+	if _, err := c.Update("a", &traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	change := waitForChan(t, changes)
+	if change.ChangeType != types.ChangeType_UPDATE || change.OldValue.State != traits.OnOff_OFF || change.NewValue.State != traits.OnOff_ON {
+		t.Fatalf("unexpected change %+v", change)
+	}
+
+	deleted, err := c.Delete("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.State != traits.OnOff_ON {
+		t.Fatalf("Delete want ON, got %v", deleted)
+	}
+	change = waitForChan(t, changes)
+	if change.ChangeType != types.ChangeType_REMOVE || change.NewValue != nil {
+		t.Fatalf("unexpected change %+v", change)
+	}
+}
+
+func waitForChan[T any](t *testing.T, c <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(time.Second):
+		t.Fatalf("timeout waiting for chan")
+		var zero T
+		return zero
+	}
+}
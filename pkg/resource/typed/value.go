@@ -0,0 +1,125 @@
+package typed
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/smart-core-os/sc-golang/pkg/resource"
+)
+
+// Value is a resource.Value that holds messages of type T.
+type Value[T proto.Message] struct {
+	value *resource.Value
+}
+
+// NewValue creates a new Value holding messages of type T.
+// See resource.NewValue.
+func NewValue[T proto.Message](opts ...resource.Option) *Value[T] {
+	return WrapValue[T](resource.NewValue(opts...))
+}
+
+// WrapValue returns a Value backed by v.
+// All messages stored in v must be of type T.
+func WrapValue[T proto.Message](v *resource.Value) *Value[T] {
+	return &Value[T]{value: v}
+}
+
+// Untyped returns the resource.Value backing this Value.
+func (v *Value[T]) Untyped() *resource.Value {
+	return v.value
+}
+
+// Get returns the current value.
+// See resource.Value.Get.
+func (v *Value[T]) Get(opts ...resource.ReadOption) T {
+	return cast[T](v.value.Get(opts...))
+}
+
+// Set updates the current value, returning the new value.
+// See resource.Value.Set.
+func (v *Value[T]) Set(value T, opts ...resource.WriteOption) (T, error) {
+	return castReturn[T](v.value.Set(value, opts...))
+}
+
+// Pull emits a ValueChange on the returned chan whenever the underlying value changes.
+// See resource.Value.Pull.
+func (v *Value[T]) Pull(ctx context.Context, opts ...resource.ReadOption) <-chan ValueChange[T] {
+	return mapChan(ctx, v.value.Pull(ctx, opts...), newValueChange[T])
+}
+
+// ListHistory returns the changes retained by this Value that match req, oldest first.
+// See resource.Value.ListHistory.
+func (v *Value[T]) ListHistory(req resource.HistoryRequest, opts ...resource.ReadOption) ([]ValueChange[T], resource.HistoryInfo, error) {
+	changes, info, err := v.value.ListHistory(req, opts...)
+	res := make([]ValueChange[T], len(changes))
+	for i, change := range changes {
+		res[i] = newValueChange[T](change)
+	}
+	return res, info, err
+}
+
+// Clock returns the clock used by this resource for reporting time.
+func (v *Value[T]) Clock() resource.Clock {
+	return v.value.Clock()
+}
+
+// Subscribers returns the stats of all active Pull calls on this resource.
+// See resource.Value.Subscribers.
+func (v *Value[T]) Subscribers() []resource.SubscriberStats {
+	return v.value.Subscribers()
+}
+
+// ValueChange contains information about a change to a Value.
+// See resource.ValueChange.
+type ValueChange[T proto.Message] struct {
+	Value      T
+	ChangeTime time.Time
+	// Deprecated, use LastSeedValue instead.
+	// SeedValue will be true if the change was part of sending initial data as opposed to an update.
+	SeedValue bool
+	// LastSeedValue will be true if this change is the last change as part of the seed values.
+	LastSeedValue bool
+	// Version is the version of the new value, see resource.WithExpectedVersion.
+	Version string
+}
+
+func newValueChange[T proto.Message](change *resource.ValueChange) ValueChange[T] {
+	return ValueChange[T]{
+		Value:         cast[T](change.Value),
+		ChangeTime:    change.ChangeTime,
+		SeedValue:     change.SeedValue,
+		LastSeedValue: change.LastSeedValue,
+		Version:       change.Version,
+	}
+}
+
+func cast[T proto.Message](msg proto.Message) T {
+	if msg == nil {
+		var zero T
+		return zero
+	}
+	return msg.(T)
+}
+
+func castReturn[T proto.Message](msg proto.Message, err error) (T, error) {
+	return cast[T](msg), err
+}
+
+// mapChan converts each item received from in using fn, sending the result on the returned chan.
+// The returned chan is closed when in is closed or ctx is done.
+func mapChan[I, O any](ctx context.Context, in <-chan I, fn func(I) O) <-chan O {
+	out := make(chan O)
+	go func() {
+		defer close(out)
+		for i := range in {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- fn(i):
+			}
+		}
+	}()
+	return out
+}
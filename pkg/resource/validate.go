@@ -0,0 +1,262 @@
+package resource
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WithValidation checks every value written to the resource against rules.
+// Rules are evaluated after the written value has been merged with the existing value, and after any
+// InterceptAfter options, so they see the value that would be stored.
+// If any rule is violated the write fails with an InvalidArgument status that carries an errdetails.BadRequest
+// describing each violation.
+// Applies to Value.Set, Collection.Add and Collection.Update, and the equivalent Transaction and batch writes.
+// Initial values are not validated.
+// Multiple WithValidation options combine their rules.
+func WithValidation(rules ...ValidationRule) Option {
+	return optionFunc(func(c *config) {
+		c.validation = append(c.validation, rules...)
+	})
+}
+
+// ValidationRule checks a single field of a written value, see WithValidation.
+//
+// Rules are keyed by field path, a dot separated list of field names, for example "preset" or "source.name".
+// Elements of repeated fields are checked individually, and paths may pass through repeated message fields.
+// Except for FieldRequired, rules ignore fields that are not set, including proto3 scalar fields with their
+// default value, see WithFieldIndex.
+type ValidationRule struct {
+	path     string
+	required bool
+	// check returns a description of why v is not valid, or "".
+	check func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string
+}
+
+// FieldRequired returns a ValidationRule that requires the field at path to be set.
+// Repeated and map fields must have at least one entry.
+func FieldRequired(path string) ValidationRule {
+	return ValidationRule{path: path, required: true}
+}
+
+// FieldRange returns a ValidationRule that requires the numeric field at path to be between min and max inclusive.
+// NaN and infinite values are always rejected.
+func FieldRange(path string, min, max float64) ValidationRule {
+	return ValidationRule{path: path, check: func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+		n, ok := numericValue(fd, v)
+		if !ok {
+			return "is not a number"
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return "must be a finite number"
+		}
+		if n < min || n > max {
+			return fmt.Sprintf("must be between %v and %v", min, max)
+		}
+		return ""
+	}}
+}
+
+// FieldOneOf returns a ValidationRule that requires the field at path to be one of values.
+// Enum fields are compared using the name of their value, for example "ON", other scalars using their string form.
+func FieldOneOf(path string, values ...string) ValidationRule {
+	allowed := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		allowed[v] = struct{}{}
+	}
+	return ValidationRule{path: path, check: func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+		if fd == nil {
+			return "is not a scalar"
+		}
+		key, ok := scalarKey(fd, v)
+		if !ok {
+			return "is not a scalar"
+		}
+		if _, ok := allowed[key]; !ok {
+			return fmt.Sprintf("must be one of %v", values)
+		}
+		return ""
+	}}
+}
+
+// FieldPattern returns a ValidationRule that requires the field at path to match the regular expression pattern.
+// Values are matched using the same string form as FieldOneOf.
+// Panics if pattern is not a valid regular expression.
+func FieldPattern(path, pattern string) ValidationRule {
+	re := regexp.MustCompile(pattern)
+	return ValidationRule{path: path, check: func(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+		if fd == nil {
+			return "is not a scalar"
+		}
+		key, ok := scalarKey(fd, v)
+		if !ok {
+			return "is not a scalar"
+		}
+		if !re.MatchString(key) {
+			return fmt.Sprintf("must match %q", pattern)
+		}
+		return ""
+	}}
+}
+
+// FieldFunc returns a ValidationRule that calls fn with the value of the field at path, or each element of a
+// repeated field.
+// A non-nil error from fn is a violation, described using the errors message.
+// An empty path calls fn with the whole message.
+func FieldFunc(path string, fn func(v protoreflect.Value) error) ValidationRule {
+	return ValidationRule{path: path, check: func(_ protoreflect.FieldDescriptor, v protoreflect.Value) string {
+		if err := fn(v); err != nil {
+			return err.Error()
+		}
+		return ""
+	}}
+}
+
+// validate checks msg against the rules configured using WithValidation.
+func (c *config) validate(msg proto.Message) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, rule := range c.validation {
+		violations = rule.appendViolations(violations, msg.ProtoReflect())
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	first := violations[0]
+	msgText := strings.TrimSpace(first.Field + " " + first.Description)
+	if len(violations) > 1 {
+		msgText = fmt.Sprintf("%s (and %d more)", msgText, len(violations)-1)
+	}
+	st := status.New(codes.InvalidArgument, "invalid value: "+msgText)
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// validateChange wraps fn so the value it returns is checked using validate.
+func (c *config) validateChange(fn ChangeFn) ChangeFn {
+	if len(c.validation) == 0 {
+		return fn
+	}
+	return func(old, dst proto.Message) (proto.Message, error) {
+		newValue, err := fn(old, dst)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.validate(newValue); err != nil {
+			return nil, err
+		}
+		return newValue, nil
+	}
+}
+
+func (r ValidationRule) appendViolations(dst []*errdetails.BadRequest_FieldViolation, msg protoreflect.Message) []*errdetails.BadRequest_FieldViolation {
+	add := func(field, description string) {
+		dst = append(dst, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+	}
+	if r.path == "" {
+		if r.check != nil {
+			if d := r.check(nil, protoreflect.ValueOfMessage(msg)); d != "" {
+				add("", d)
+			}
+		}
+		return dst
+	}
+
+	walkFieldPath(msg, "", strings.Split(r.path, "."), func(field string, parent protoreflect.Message, fd protoreflect.FieldDescriptor) {
+		switch {
+		case fd == nil:
+			add(field, "is not a known field")
+			return
+		case !parent.Has(fd):
+			if r.required {
+				add(field, "is required")
+			}
+			return
+		case r.check == nil:
+			return
+		}
+		v := parent.Get(fd)
+		switch {
+		case fd.IsMap():
+			add(field, "is a map")
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				if d := r.check(fd, list.Get(i)); d != "" {
+					add(field+"["+strconv.Itoa(i)+"]", d)
+				}
+			}
+		default:
+			if d := r.check(fd, v); d != "" {
+				add(field, d)
+			}
+		}
+	})
+	return dst
+}
+
+// walkFieldPath calls fn with the message containing the last field of path, and that fields descriptor.
+// Repeated message fields along path are walked for each element.
+// Unset message fields along path are walked as empty messages so fn can report the missing field.
+// If a field along path doesn't exist, fn is called with a nil descriptor.
+func walkFieldPath(msg protoreflect.Message, prefix string, path []string, fn func(field string, parent protoreflect.Message, fd protoreflect.FieldDescriptor)) {
+	field := path[0]
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(path[0]))
+	if len(path) == 1 || fd == nil {
+		fn(field, msg, fd)
+		return
+	}
+	if fd.Kind() != protoreflect.MessageKind || fd.IsMap() {
+		fn(strings.Join(append([]string{field}, path[1:]...), "."), msg, nil)
+		return
+	}
+	if fd.IsList() {
+		list := msg.Get(fd).List()
+		for i := 0; i < list.Len(); i++ {
+			walkFieldPath(list.Get(i).Message(), field+"["+strconv.Itoa(i)+"]", path[1:], fn)
+		}
+		return
+	}
+	walkFieldPath(msg.Get(fd).Message(), field, path[1:], fn)
+}
+
+func numericValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (float64, bool) {
+	if fd == nil {
+		return 0, false
+	}
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return float64(v.Int()), true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return float64(v.Uint()), true
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// errorWithID appends id to the message of err, keeping the code and any details of gRPC status errors.
+func errorWithID(err error, id string) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	p := s.Proto()
+	p.Message = fmt.Sprintf("%v %v", p.Message, id)
+	return status.FromProto(p).Err()
+}
@@ -0,0 +1,128 @@
+package resource
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+)
+
+func TestWithValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  ValidationRule
+		value proto.Message
+		want  []*errdetails.BadRequest_FieldViolation // nil means valid
+	}{
+		{"range ok", FieldRange("level_percent", 0, 100), &traits.Brightness{LevelPercent: 50}, nil},
+		{"range high", FieldRange("level_percent", 0, 100), &traits.Brightness{LevelPercent: 101},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "level_percent", Description: "must be between 0 and 100"}}},
+		{"range unset", FieldRange("level_percent", 10, 100), &traits.Brightness{}, nil},
+		{"range NaN", FieldRange("level_percent", 0, 100), &traits.Brightness{LevelPercent: float32(math.NaN())},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "level_percent", Description: "must be a finite number"}}},
+		{"range Inf", FieldRange("level_percent", 0, math.Inf(1)), &traits.Brightness{LevelPercent: float32(math.Inf(1))},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "level_percent", Description: "must be a finite number"}}},
+		{"required", FieldRequired("level_percent"), &traits.Brightness{},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "level_percent", Description: "is required"}}},
+		{"required nested", FieldRequired("preset.name"), &traits.Brightness{},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "preset.name", Description: "is required"}}},
+		{"enum ok", FieldOneOf("state", "ON", "OFF"), &traits.OnOff{State: traits.OnOff_ON}, nil},
+		{"enum", FieldOneOf("state", "OFF"), &traits.OnOff{State: traits.OnOff_ON},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "state", Description: "must be one of [OFF]"}}},
+		{"pattern ok", FieldPattern("preset.name", "^[a-z]+$"), &traits.Brightness{Preset: &traits.LightPreset{Name: "warm"}}, nil},
+		{"pattern", FieldPattern("preset.name", "^[a-z]+$"), &traits.Brightness{Preset: &traits.LightPreset{Name: "Warm"}},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "preset.name", Description: `must match "^[a-z]+$"`}}},
+		{"repeated", FieldOneOf("traits", "a", "b"), &traits.Child{Traits: []*traits.Trait{{Name: "a"}}},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "traits[0]", Description: "is not a scalar"}}},
+		{"repeated path", FieldOneOf("traits.name", "a", "b"), &traits.Child{Traits: []*traits.Trait{{Name: "a"}, {Name: "c"}}},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "traits[1].name", Description: "must be one of [a b]"}}},
+		{"func", FieldFunc("", func(v protoreflect.Value) error {
+			return errors.New("always fails")
+		}), &traits.OnOff{},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "", Description: "always fails"}}},
+		{"unknown field", FieldRequired("nope"), &traits.OnOff{},
+			[]*errdetails.BadRequest_FieldViolation{{Field: "nope", Description: "is not a known field"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValue(WithInitialValue(tt.value.ProtoReflect().New().Interface()), WithValidation(tt.rule))
+			_, err := v.Set(tt.value)
+			if diff := cmp.Diff(tt.want, fieldViolations(t, err), protocmp.Transform()); diff != "" {
+				t.Fatalf("violations (-want,+got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithValidation_afterMerge(t *testing.T) {
+	v := NewValue(
+		WithInitialValue(&traits.Brightness{LevelPercent: 50}),
+		WithValidation(FieldRequired("level_percent"), FieldRequired("preset.name")),
+	)
+	// the merged value has a level_percent even though the update doesn't
+	update := &traits.Brightness{Preset: &traits.LightPreset{Name: "warm"}}
+	if _, err := v.Set(update, WithUpdatePaths("preset")); err != nil {
+		t.Fatal(err)
+	}
+	// but not when the update clears it
+	_, err := v.Set(&traits.Brightness{}, WithUpdateMask(&fieldmaskpb.FieldMask{Paths: []string{"level_percent"}}))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("want InvalidArgument, got %v", err)
+	}
+	if diff := cmp.Diff(&traits.Brightness{LevelPercent: 50, Preset: &traits.LightPreset{Name: "warm"}}, v.Get(), protocmp.Transform()); diff != "" {
+		t.Fatalf("value changed after failed write (-want,+got)\n%s", diff)
+	}
+}
+
+func TestWithValidation_collection(t *testing.T) {
+	c := NewCollection(WithValidation(FieldRange("level_percent", 0, 100)))
+	_, err := c.Add("a", &traits.Brightness{LevelPercent: 200})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("want InvalidArgument, got %v", err)
+	}
+	if got := fieldViolations(t, err); len(got) != 1 {
+		t.Fatalf("want details kept when id is added to the error, got %v", got)
+	}
+	if got := c.List(); len(got) != 0 {
+		t.Fatalf("want no items, got %v", got)
+	}
+
+	results := c.BatchAdd([]BatchItem{
+		{ID: "b", Value: &traits.Brightness{LevelPercent: 20}},
+		{ID: "c", Value: &traits.Brightness{LevelPercent: 120}},
+	})
+	if results[0].Err != nil {
+		t.Fatalf("b: %v", results[0].Err)
+	}
+	if status.Code(results[1].Err) != codes.InvalidArgument {
+		t.Fatalf("c: want InvalidArgument, got %v", results[1].Err)
+	}
+}
+
+func fieldViolations(t *testing.T, err error) []*errdetails.BadRequest_FieldViolation {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	s := status.Convert(err)
+	if s.Code() != codes.InvalidArgument {
+		t.Fatalf("want InvalidArgument, got %v", err)
+	}
+	for _, d := range s.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			return br.FieldViolations
+		}
+	}
+	t.Fatalf("no BadRequest details in %v", err)
+	return nil
+}
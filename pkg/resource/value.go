@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/smart-core-os/sc-golang/internal/minibus"
@@ -20,21 +22,56 @@ type Value struct {
 	mu         sync.RWMutex
 	value      proto.Message
 	changeTime time.Time
+	version    uint64 // incremented on every write, see WithExpectedVersion
+	history    *history[*ValueChange]
 
 	bus minibus.Bus
+
+	order uint64 // see Participant
 }
 
 func NewValue(opts ...Option) *Value {
 	c := computeConfig(opts...)
 	res := &Value{
-		config: c,
+		config:  c,
+		order:   nextParticipantOrder(),
+		version: initialSeq(c.clock),
 	}
 	res.value = c.initialValue
+	c.configureBus(&res.bus)
 	res.changeTime = c.clock.Now()
 	c.initialValue = nil // clear so it can be GC'd when the value changes
+	if c.store != nil {
+		res.restore()
+	}
+	if res.history = newHistory[*ValueChange](c); res.history != nil && res.value != nil {
+		res.history.add(res.changeTime, &ValueChange{Value: res.value, ChangeTime: res.changeTime, Version: formatVersion(res.version)})
+	}
 	return res
 }
 
+// restore loads the value from the configured store, or saves the initial value to the store if it is empty.
+func (r *Value) restore() {
+	records, err := r.store.Load()
+	if err != nil {
+		log.Printf("WARN: resource.Value failed to load from store, using initial value: %v", err)
+		return
+	}
+	for _, record := range records {
+		if record.ID != "" || record.Value == nil {
+			continue
+		}
+		r.value = record.Value
+		r.changeTime = record.ChangeTime
+		return
+	}
+	if r.value != nil {
+		if err := r.store.Save(StoreRecord{Value: r.value, ChangeTime: r.changeTime}); err != nil {
+			log.Printf("WARN: resource.Value failed to save initial value to store: %v", err)
+		}
+	}
+}
+
 func (r *Value) Get(opts ...ReadOption) proto.Message {
 	return r.get(ComputeReadConfig(opts...))
 }
@@ -42,6 +79,7 @@ func (r *Value) Get(opts ...ReadOption) proto.Message {
 func (r *Value) get(req *ReadRequest) proto.Message {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	req.reportVersion("", r.version)
 	return req.FilterClone(r.value)
 }
 
@@ -58,16 +96,35 @@ func (r *Value) set(value proto.Message, request WriteRequest) (proto.Message, e
 		return nil, err
 	}
 
+	var (
+		version    uint64
+		versionErr error
+		storeErr   error
+		change     *ValueChange
+	)
+	changeFn := r.validateChange(request.changeFn(writer, value))
 	disarm := timeoutAlarm(time.Second, "GetAndUpdate took too long")
 	_, newValue, err := GetAndUpdate(
 		&r.mu,
 		func() (proto.Message, error) {
+			version = r.version
 			return r.value, nil
 		},
-		request.changeFn(writer, value),
+		func(old, dst proto.Message) (proto.Message, error) {
+			if err := request.checkVersion(version); err != nil {
+				return nil, err
+			}
+			return changeFn(old, dst)
+		},
 		func(message proto.Message) {
-			r.value = message
-			r.changeTime = request.updateTime(r.clock)
+			if versionErr = request.checkVersion(r.version); versionErr != nil {
+				return
+			}
+			changeTime := request.updateTime(r.clock)
+			if storeErr = r.save(message, changeTime); storeErr != nil {
+				return
+			}
+			change = r.apply(message, changeTime)
 		},
 	)
 	disarm()
@@ -75,13 +132,16 @@ func (r *Value) set(value proto.Message, request WriteRequest) (proto.Message, e
 	if err != nil {
 		return nil, err
 	}
+	if versionErr != nil {
+		return nil, versionErr
+	}
+	if storeErr != nil {
+		return nil, status.Errorf(codes.Unavailable, "store: %v", storeErr)
+	}
 
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*5)
 	defer cancel()
-	r.bus.Send(ctx, &ValueChange{
-		Value:      newValue,
-		ChangeTime: request.updateTime(r.clock),
-	})
+	r.bus.Send(ctx, change)
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 		return nil, errors.New("bus.Send blocked for too long")
 	}
@@ -89,6 +149,29 @@ func (r *Value) set(value proto.Message, request WriteRequest) (proto.Message, e
 	return newValue, err
 }
 
+// save persists message to the configured store, if any.
+// r.mu must be held.
+func (r *Value) save(message proto.Message, changeTime time.Time) error {
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Save(StoreRecord{Value: message, ChangeTime: changeTime})
+}
+
+// apply makes message the current value, returning the change that was made.
+// r.mu must be held.
+func (r *Value) apply(message proto.Message, changeTime time.Time) *ValueChange {
+	r.value = message
+	r.changeTime = changeTime
+	r.version++
+	change := &ValueChange{Value: message, ChangeTime: changeTime, Version: formatVersion(r.version)}
+	if r.history != nil {
+		r.history.prune(r.clock.Now())
+		r.history.add(changeTime, change)
+	}
+	return change
+}
+
 // Pull emits a ValueChange on the returned chan whenever the underlying value changes.
 // The changes emitted can be adjusted using WithEquivalence.
 // The returned chan will be closed when no more events will be emitted, either because ctx was cancelled or for other
@@ -96,22 +179,38 @@ func (r *Value) set(value proto.Message, request WriteRequest) (proto.Message, e
 func (r *Value) Pull(ctx context.Context, opts ...ReadOption) <-chan *ValueChange {
 	readConfig := ComputeReadConfig(opts...)
 	filter := readConfig.ResponseFilter()
-	on, currentValue, changeTime := r.onUpdate(ctx, readConfig)
+	on, currentValue, changeTime, version, replay := r.onUpdate(ctx, readConfig)
 	typedEvents := make(chan *ValueChange)
 	go func() {
 		defer close(typedEvents)
+		if on == nil {
+			return // too many subscribers
+		}
 
+		var last proto.Message
+		// see WithReplaySince, replayed changes come before the seed value as if they were live changes
+		for _, change := range replay {
+			change = change.filter(filter)
+			if r.equivalence != nil && r.equivalence.Compare(last, change.Value) {
+				continue
+			}
+			last = change.Value
+			select {
+			case <-ctx.Done():
+				return // give up sending
+			case typedEvents <- change:
+			}
+		}
 		if currentValue != nil {
-			change := &ValueChange{Value: currentValue, ChangeTime: changeTime, SeedValue: true, LastSeedValue: true}
+			change := &ValueChange{Value: currentValue, ChangeTime: changeTime, SeedValue: true, LastSeedValue: true, Version: version}
 			change = change.filter(filter)
+			last = currentValue
 			select {
 			case <-ctx.Done():
 				return // give up sending
 			case typedEvents <- change:
 			}
 		}
-
-		last := currentValue
 		for event := range on {
 			change := event.(*ValueChange).filter(filter)
 			if r.equivalence != nil && r.equivalence.Compare(last, change.Value) {
@@ -125,27 +224,62 @@ func (r *Value) Pull(ctx context.Context, opts ...ReadOption) <-chan *ValueChang
 			}
 		}
 	}()
+	if readConfig.RateLimit.enabled() {
+		return rateLimit(ctx, r.clock, readConfig.RateLimit, typedEvents, isValueSeed, mergeLatest[*ValueChange], nil)
+	}
 	return typedEvents
 }
 
-func (r *Value) onUpdate(ctx context.Context, config *ReadRequest) (<-chan any, proto.Message, time.Time) {
+func isValueSeed(c *ValueChange) bool {
+	return c.SeedValue
+}
+
+func (r *Value) onUpdate(ctx context.Context, config *ReadRequest) (<-chan any, proto.Message, time.Time, string, []*ValueChange) {
 	var (
 		value      proto.Message
 		changeTime time.Time
+		version    string
+		replay     []*ValueChange
 	)
-	if !config.UpdatesOnly {
+	replaying := r.history != nil && !config.ReplaySince.IsZero()
+	if !config.UpdatesOnly || replaying {
 		r.mu.RLock()
 		defer r.mu.RUnlock()
+	}
+	if !config.UpdatesOnly {
 		value = r.value
 		changeTime = r.changeTime
+		version = formatVersion(r.version)
+	}
+	if replaying {
+		replay = r.history.since(r.clock.Now(), config.ReplaySince)
 	}
 
-	ch := r.bus.Listen(ctx)
-	if !config.Backpressure {
+	ch := listen(ctx, &r.bus, config)
+	if ch != nil && !config.Backpressure {
 		ch = minibus.DropExcess(ch)
 	}
 
-	return ch, value, changeTime
+	return ch, value, changeTime, version, replay
+}
+
+// ListHistory returns the changes retained by this Value that match req, oldest first.
+// Returns HistoryNotEnabled if the Value was not configured to retain history, see WithHistoryCapacity.
+func (r *Value) ListHistory(req HistoryRequest, opts ...ReadOption) ([]*ValueChange, HistoryInfo, error) {
+	if r.history == nil {
+		return nil, HistoryInfo{}, HistoryNotEnabled
+	}
+	filter := ComputeReadConfig(opts...).ResponseFilter()
+	r.mu.RLock()
+	changes, info, err := r.history.list(r.clock.Now(), req, nil)
+	r.mu.RUnlock()
+	if err != nil {
+		return nil, info, err
+	}
+	for i, change := range changes {
+		changes[i] = change.filter(filter)
+	}
+	return changes, info, nil
 }
 
 func timeoutAlarm(duration time.Duration, fmt string, args ...any) (disarm func()) {
@@ -165,3 +299,11 @@ func timeoutAlarm(duration time.Duration, fmt string, args ...any) (disarm func(
 func (r *Value) Clock() Clock {
 	return r.clock
 }
+
+func (r *Value) txOrder() uint64 {
+	return r.order
+}
+
+func (r *Value) txLock() *sync.RWMutex {
+	return &r.mu
+}
@@ -31,6 +31,7 @@ func TestValue_Pull(t *testing.T) {
 			Value:         &traits.OnOff{State: traits.OnOff_ON},
 			SeedValue:     true,
 			LastSeedValue: true,
+			Version:       "0",
 		}
 		if diff := cmp.Diff(want, seed, protocmp.Transform()); diff != "" {
 			t.Fatalf("Seed Value (-want,+got)\n%s", diff)
@@ -44,6 +45,7 @@ func TestValue_Pull(t *testing.T) {
 			Value:         &traits.OnOff{State: traits.OnOff_OFF},
 			SeedValue:     false,
 			LastSeedValue: false,
+			Version:       "1",
 		}
 		if diff := cmp.Diff(want, next, protocmp.Transform()); diff != "" {
 			t.Fatalf("Next Value (-want,+got)\n%s", diff)
@@ -73,6 +75,7 @@ func TestValue_Pull(t *testing.T) {
 			Value:         &traits.OnOff{State: traits.OnOff_OFF},
 			SeedValue:     false,
 			LastSeedValue: false,
+			Version:       "1",
 		}
 		if diff := cmp.Diff(want, change, protocmp.Transform()); diff != "" {
 			t.Fatalf("Value (-want,+got)\n%s", diff)
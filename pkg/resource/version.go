@@ -0,0 +1,71 @@
+package resource
+
+import (
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExpectedVersionMismatch is returned when a write configured WithExpectedVersion finds a different version.
+var ExpectedVersionMismatch = status.Error(codes.Aborted, "current version is not as expected")
+
+// WithExpectedVersion instructs the write to only proceed if the current version of the Value or Collection item
+// is version.
+// Versions are opaque strings, reported via ValueChange.Version, CollectionChange.Version, or WithVersionCallback.
+// Every write to a resource changes its version, even if the value stays the same.
+// If the precondition fails the write will return the error ExpectedVersionMismatch.
+// An item that does not exist, or has been written earlier in the same Transaction, never matches a version.
+// An empty version disables the check.
+func WithExpectedVersion(version string) WriteOption {
+	return writeOptionFunc(func(request *WriteRequest) {
+		request.expectedVersion = version
+	})
+}
+
+// WithVersionCallback calls fn with the id and version of each item returned from Collection Get or List,
+// or with an empty id and the version of a Value returned from Value Get.
+// The version can be used with WithExpectedVersion to make conditional writes.
+func WithVersionCallback(fn func(id, version string)) ReadOption {
+	return readOptionFunc(func(rr *ReadRequest) {
+		rr.VersionCallback = fn
+	})
+}
+
+// formatVersion returns the opaque form of version.
+func formatVersion(version uint64) string {
+	return strconv.FormatUint(version, 36)
+}
+
+// checkVersion returns ExpectedVersionMismatch if wr has an expected version that isn't version.
+func (wr WriteRequest) checkVersion(version uint64) error {
+	if wr.expectedVersion != "" && wr.expectedVersion != formatVersion(version) {
+		return ExpectedVersionMismatch
+	}
+	return nil
+}
+
+// checkNoVersion returns ExpectedVersionMismatch if wr has an expected version.
+// Use when the item being written has no version, for example if it doesn't exist.
+func (wr WriteRequest) checkNoVersion() error {
+	if wr.expectedVersion != "" {
+		return ExpectedVersionMismatch
+	}
+	return nil
+}
+
+// checkVersion checks the expected version of wr against the item identified by id.
+// c.mu must be held.
+func (c *Collection) checkVersion(id string, wr WriteRequest) error {
+	if it, exists := c.byId[id]; exists {
+		return wr.checkVersion(it.version)
+	}
+	return wr.checkNoVersion()
+}
+
+// reportVersion calls any VersionCallback with id and version.
+func (rr *ReadRequest) reportVersion(id string, version uint64) {
+	if rr.VersionCallback != nil {
+		rr.VersionCallback(id, formatVersion(version))
+	}
+}
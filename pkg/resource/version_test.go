@@ -0,0 +1,103 @@
+package resource
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+)
+
+func TestValue_WithExpectedVersion(t *testing.T) {
+	v := NewValue(WithInitialValue(&traits.OnOff{State: traits.OnOff_OFF}))
+	var version string
+	v.Get(WithVersionCallback(func(_, ver string) { version = ver }))
+	if version == "" {
+		t.Fatalf("expected a version")
+	}
+
+	if _, err := v.Set(&traits.OnOff{State: traits.OnOff_ON}, WithExpectedVersion(version)); err != nil {
+		t.Fatal(err)
+	}
+	// the version changed with the write above
+	_, err := v.Set(&traits.OnOff{State: traits.OnOff_OFF}, WithExpectedVersion(version))
+	if !errors.Is(err, ExpectedVersionMismatch) {
+		t.Fatalf("want ExpectedVersionMismatch, got %v", err)
+	}
+	if got := v.Get().(*traits.OnOff).State; got != traits.OnOff_ON {
+		t.Fatalf("want ON, got %v", got)
+	}
+
+	// writing the same value still changes the version
+	var newVersion string
+	v.Get(WithVersionCallback(func(_, ver string) { newVersion = ver }))
+	if _, err := v.Set(&traits.OnOff{State: traits.OnOff_ON}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Set(&traits.OnOff{}, WithExpectedVersion(newVersion)); !errors.Is(err, ExpectedVersionMismatch) {
+		t.Fatalf("want ExpectedVersionMismatch after same value write, got %v", err)
+	}
+}
+
+func TestCollection_WithExpectedVersion(t *testing.T) {
+	c := NewCollection(WithInitialRecord("a", &traits.OnOff{}))
+	versions := make(map[string]string)
+	recordVersion := WithVersionCallback(func(id, ver string) { versions[id] = ver })
+	add(t, c, "b", &traits.OnOff{})
+	c.List(recordVersion)
+	if versions["a"] == "" || versions["b"] == "" || versions["a"] == versions["b"] {
+		t.Fatalf("want distinct versions, got %v", versions)
+	}
+
+	if _, err := c.Update("a", &traits.OnOff{State: traits.OnOff_ON}, WithExpectedVersion(versions["a"])); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Update("a", &traits.OnOff{}, WithExpectedVersion(versions["a"]))
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("want Aborted, got %v", err)
+	}
+	// b wasn't changed by the update to a
+	if _, err := c.Delete("b", WithExpectedVersion(versions["a"])); status.Code(err) != codes.Aborted {
+		t.Fatalf("want Aborted deleting with wrong version, got %v", err)
+	}
+	if _, err := c.Delete("b", WithExpectedVersion(versions["b"])); err != nil {
+		t.Fatal(err)
+	}
+	// absent items have no version
+	_, err = c.Update("b", &traits.OnOff{}, WithCreateIfAbsent(), WithExpectedVersion(versions["b"]))
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("want Aborted creating with a version, got %v", err)
+	}
+
+	c.Get("a", recordVersion)
+	var pulled string
+	for change := range c.PullID(t.Context(), "a") {
+		pulled = change.Version
+		break
+	}
+	if pulled != versions["a"] {
+		t.Fatalf("Pull version %q differs from Get version %q", pulled, versions["a"])
+	}
+}
+
+func TestTransaction_WithExpectedVersion(t *testing.T) {
+	c := NewCollection(WithInitialRecord("a", &traits.OnOff{State: traits.OnOff_OFF}))
+	var version string
+	c.Get("a", WithVersionCallback(func(_, ver string) { version = ver }))
+	err := Transact(func(tx *Transaction) error {
+		if _, err := tx.UpdateItem(c, "a", &traits.OnOff{State: traits.OnOff_ON}, WithExpectedVersion(version)); err != nil {
+			return err
+		}
+		// a was written by this transaction so has no version
+		_, err := tx.UpdateItem(c, "a", &traits.OnOff{}, WithExpectedVersion(version))
+		return err
+	}, c)
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("want Aborted, got %v", err)
+	}
+	if got, _ := c.Get("a"); got.(*traits.OnOff).State != traits.OnOff_OFF {
+		t.Fatalf("transaction should have rolled back, got %v", got)
+	}
+}
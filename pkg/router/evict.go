@@ -0,0 +1,200 @@
+package router
+
+import (
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/smart-core-os/sc-golang/pkg/time/clock"
+)
+
+// WithIdleTimeout configures a Router to forget clients created via a Factory that have not been returned by Get for
+// the given duration.
+// Evicted clients are passed to the func configured via WithOnEvict, and reported via WithOnChange with
+// Change.Evicted set.
+// A later call to Get for the same name will invoke the Factory again.
+// Clients added via Router.Add are never evicted.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(r *router) {
+		r.idleTimeout = d
+	}
+}
+
+// WithMaxAutoClients configures a Router to remember at most n clients created via a Factory.
+// When a new client would exceed this limit, the least recently used client created via a Factory is evicted.
+// See WithIdleTimeout for details of eviction.
+func WithMaxAutoClients(n int) Option {
+	return func(r *router) {
+		r.maxAuto = n
+	}
+}
+
+// WithOnEvict configures a Router to call f with each client that is evicted, after it has been removed from the
+// Router and any func registered via WithOnChange has been called.
+// By default evicted clients are not closed, they may still be in use by an RPC that called Get before the eviction.
+// Use CloseOnEvict to close them anyway.
+func WithOnEvict(f func(name string, client any)) Option {
+	return func(r *router) {
+		r.onEvict = f
+	}
+}
+
+// WithClock configures the clock used to measure idle time, see WithIdleTimeout.
+// Defaults to clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(r *router) {
+		r.clock = c
+	}
+}
+
+// CloseOnEvict can be passed to WithOnEvict to close evicted clients.
+// It calls Close on client if it implements io.Closer, logging any error.
+// Closing a client can fail RPCs still using it, only use this if the clients can tolerate that, for example by
+// waiting for in-flight calls before closing.
+func CloseOnEvict(name string, client any) {
+	closer, ok := client.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		log.Printf("WARN: router: closing evicted client %q: %v", name, err)
+	}
+}
+
+// autoEntry tracks the use of a client created by a Factory.
+// The use fields are updated while holding r.mu for reading, so Get doesn't block other readers.
+type autoEntry struct {
+	name     string
+	client   any
+	lastUsed atomic.Int64  // UnixNano of when the client was last returned by Get
+	useSeq   atomic.Uint64 // orders uses of clients, higher means more recent, see router.uses
+}
+
+func (e *autoEntry) lastUsedTime() time.Time {
+	return time.Unix(0, e.lastUsed.Load())
+}
+
+// evicts returns true if r might evict clients created by a Factory.
+func (r *router) evicts() bool {
+	return r.idleTimeout > 0 || r.maxAuto > 0
+}
+
+// trackAutoLocked records that name refers to a client created by a Factory, evicting other clients if there are too
+// many. Returns the evicted clients.
+// r.mu must be held.
+func (r *router) trackAutoLocked(name string, client any) []*autoEntry {
+	if !r.evicts() {
+		return nil
+	}
+	if r.auto == nil {
+		r.auto = make(map[string]*autoEntry)
+	}
+	e := &autoEntry{name: name, client: client}
+	r.auto[name] = e
+	r.touchEntry(e)
+	var evicted []*autoEntry
+	for r.maxAuto > 0 && len(r.auto) > r.maxAuto {
+		evicted = append(evicted, r.evictLocked(r.leastRecentlyUsedLocked()))
+	}
+	r.scheduleSweepLocked()
+	return evicted
+}
+
+// untrackAutoLocked stops tracking name, for example if it has been removed or replaced by a call to Add.
+// r.mu must be held.
+func (r *router) untrackAutoLocked(name string) {
+	delete(r.auto, name)
+}
+
+// touchRLocked records that the client for name has been used, if it was created by a Factory.
+// r.mu must be held, for reading or writing.
+func (r *router) touchRLocked(name string) {
+	if e, ok := r.auto[name]; ok {
+		r.touchEntry(e)
+	}
+}
+
+func (r *router) touchEntry(e *autoEntry) {
+	e.lastUsed.Store(r.clock.Now().UnixNano())
+	e.useSeq.Store(r.uses.Add(1))
+}
+
+// leastRecentlyUsedLocked returns the tracked client that was used least recently, or nil if there are none.
+// r.mu must be held.
+func (r *router) leastRecentlyUsedLocked() *autoEntry {
+	var lru *autoEntry
+	for _, e := range r.auto {
+		if lru == nil || e.useSeq.Load() < lru.useSeq.Load() {
+			lru = e
+		}
+	}
+	return lru
+}
+
+// evictLocked removes e from r.
+// r.mu must be held.
+func (r *router) evictLocked(e *autoEntry) *autoEntry {
+	delete(r.auto, e.name)
+	delete(r.registry, e.name)
+	r.publishLocked(Change{Name: e.name, Old: e.client, Auto: true, Evicted: true})
+	return e
+}
+
+// scheduleSweepLocked starts a goroutine to evict idle clients, if one isn't already running and there are clients
+// that could become idle.
+// r.mu must be held.
+func (r *router) scheduleSweepLocked() {
+	if r.idleTimeout <= 0 || r.sweeping || len(r.auto) == 0 {
+		return
+	}
+	r.sweeping = true
+	go r.sweepIdle()
+}
+
+// sweepIdle evicts idle clients as they become idle, returning when there are no clients left to evict.
+func (r *router) sweepIdle() {
+	for {
+		r.mu.Lock()
+		var (
+			evicted []*autoEntry
+			next    time.Time // when the next client becomes idle, if not used before then
+		)
+		now := r.clock.Now()
+		for _, e := range r.auto {
+			idleAt := e.lastUsedTime().Add(r.idleTimeout)
+			if !now.Before(idleAt) {
+				evicted = append(evicted, r.evictLocked(e))
+			} else if next.IsZero() || idleAt.Before(next) {
+				next = idleAt
+			}
+		}
+		if len(r.auto) == 0 {
+			r.sweeping = false
+		}
+		r.mu.Unlock()
+		r.notifyEvicted(evicted)
+
+		if next.IsZero() {
+			return
+		}
+		// clients may be used before then, in which case we'll find nothing to evict and wait again
+		if _, ok := <-r.clock.At(next); !ok {
+			r.mu.Lock()
+			r.sweeping = false // the clock has stopped
+			r.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (r *router) notifyEvicted(evicted []*autoEntry) {
+	for _, e := range evicted {
+		if r.onChange != nil {
+			r.onChange(Change{Name: e.name, Old: e.client, Auto: true, Evicted: true})
+		}
+		if r.onEvict != nil {
+			r.onEvict(e.name, e.client)
+		}
+	}
+}
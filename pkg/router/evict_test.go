@@ -0,0 +1,143 @@
+package router
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smart-core-os/sc-golang/pkg/time/clock"
+)
+
+func TestWithMaxAutoClients(t *testing.T) {
+	var changes []Change
+	var evicted []string
+	r := NewRouter(
+		WithFactory(func(name string) (any, error) { return name, nil }),
+		WithMaxAutoClients(2),
+		WithOnChange(func(c Change) { changes = append(changes, c) }),
+		WithOnEvict(func(name string, _ any) { evicted = append(evicted, name) }),
+	)
+	r.Add("manual", "manual")
+	mustGet(t, r, "a")
+	mustGet(t, r, "b")
+	mustGet(t, r, "a") // b is now least recently used
+	mustGet(t, r, "c")
+
+	if !r.Has("a") || r.Has("b") || !r.Has("c") || !r.Has("manual") {
+		t.Fatalf("want a, c, and manual, got a=%v b=%v c=%v", r.Has("a"), r.Has("b"), r.Has("c"))
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Fatalf("want b evicted, got %v", evicted)
+	}
+	last := changes[len(changes)-1]
+	if want := (Change{Name: "b", Old: "b", Auto: true, Evicted: true}); last != want {
+		t.Fatalf("want %+v, got %+v", want, last)
+	}
+
+	// adding a client removes it from eviction
+	r.Add("a", "a2")
+	mustGet(t, r, "d")
+	mustGet(t, r, "e")
+	if got, _ := r.Get("a"); got != "a2" {
+		t.Fatalf("want manually added a kept, got %v", got)
+	}
+}
+
+func TestWithIdleTimeout(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	var mu sync.Mutex
+	closed := make(map[string]bool)
+	r := NewRouter(
+		WithFactory(func(name string) (any, error) {
+			return &testCloser{name: name, closed: closed, mu: &mu}, nil
+		}),
+		WithIdleTimeout(time.Minute),
+		WithClock(fake),
+		WithOnEvict(CloseOnEvict),
+	)
+	ctx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+
+	mustGet(t, r, "a")
+	if err := fake.BlockUntilAt(ctx, time.Unix(60, 0)); err != nil {
+		t.Fatal(err)
+	}
+	fake.Add(30 * time.Second)
+	mustGet(t, r, "b")
+	fake.Add(20 * time.Second)
+	mustGet(t, r, "a") // a last used at 50s, b at 30s
+
+	fake.Add(10 * time.Second) // now 60s, nothing idle yet
+	if err := fake.BlockUntilAt(ctx, time.Unix(90, 0)); err != nil {
+		t.Fatal(err)
+	}
+	fake.Add(30 * time.Second) // now 90s, b is idle
+	if err := fake.BlockUntilAt(ctx, time.Unix(110, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if r.Has("b") || !r.Has("a") {
+		t.Fatalf("want b evicted, got a=%v b=%v", r.Has("a"), r.Has("b"))
+	}
+	mu.Lock()
+	if !closed["b"] || closed["a"] {
+		t.Fatalf("want b closed, got %v", closed)
+	}
+	mu.Unlock()
+
+	fake.Add(20 * time.Second) // now 110s, a is idle
+	waitFor(t, func() bool { return !r.Has("a") })
+}
+
+func TestWithOnEvict_default(t *testing.T) {
+	var mu sync.Mutex
+	closed := make(map[string]bool)
+	r := NewRouter(
+		WithFactory(func(name string) (any, error) {
+			return &testCloser{name: name, closed: closed, mu: &mu}, nil
+		}),
+		WithMaxAutoClients(1),
+	)
+	mustGet(t, r, "a")
+	mustGet(t, r, "b")
+	if r.Has("a") {
+		t.Fatalf("want a evicted")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if closed["a"] {
+		t.Fatalf("want evicted client not closed by default")
+	}
+}
+
+type testCloser struct {
+	name   string
+	mu     *sync.Mutex
+	closed map[string]bool
+}
+
+func (c *testCloser) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed[c.name] = true
+	return nil
+}
+
+func mustGet(t *testing.T, r Router, name string) any {
+	t.Helper()
+	got, err := r.Get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("condition not met")
+}
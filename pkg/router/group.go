@@ -0,0 +1,263 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/smart-core-os/sc-golang/pkg/time/clock"
+)
+
+// Group holds redundant clients for the same name, for example the same device reachable via two gateways.
+// Add a Group to a Router in place of a single client, Router.Get returns one of the healthy members of the Group
+// chosen using the Groups Policy.
+//
+// Generated routers fail over between members using Failover: a unary call that fails with codes.Unavailable is
+// retried using the next healthy member, as is a streaming call that fails before it starts.
+// Once a streaming call has started it is not moved to another member, if the member fails the error is returned to
+// the caller who can call again to be routed to a healthy member.
+//
+// Members are checked in the background using their HealthCheck until Close is called.
+// Members are considered healthy until their first check fails.
+// A member that fails a call with codes.Unavailable is considered unhealthy until its next check, members without a
+// HealthCheck become healthy again at the next check.
+type Group struct {
+	policy   Policy
+	members  []*groupMember
+	next     atomic.Uint64 // for RoundRobin
+	interval time.Duration
+	timeout  time.Duration
+	clock    clock.Clock
+
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// Policy controls which member of a Group is used.
+type Policy int
+
+const (
+	// PrimaryBackup uses the first healthy member, in the order members were given to NewGroup.
+	PrimaryBackup Policy = iota
+	// RoundRobin uses each healthy member in turn.
+	RoundRobin
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PrimaryBackup:
+		return "PrimaryBackup"
+	case RoundRobin:
+		return "RoundRobin"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// HealthCheck returns nil if a client is healthy.
+type HealthCheck func(ctx context.Context) error
+
+// Member is a client that is part of a Group.
+type Member struct {
+	// Client is returned by Router.Get when this member is chosen.
+	Client any
+	// Check reports whether Client is healthy, if nil Client is healthy unless a call to it fails, see Group.
+	Check HealthCheck
+}
+
+type groupMember struct {
+	Member
+	healthy atomic.Bool
+}
+
+// GroupOption configures a Group.
+type GroupOption func(g *Group)
+
+// WithCheckInterval sets how often each members HealthCheck is called, defaults to 5 seconds.
+func WithCheckInterval(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.interval = d
+	}
+}
+
+// WithCheckTimeout sets how long a HealthCheck has to complete before the member is considered unhealthy,
+// defaults to 1 second.
+func WithCheckTimeout(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.timeout = d
+	}
+}
+
+// WithGroupClock configures the clock used to schedule health checks, see WithCheckInterval.
+// Defaults to clock.Real().
+func WithGroupClock(c clock.Clock) GroupOption {
+	return func(g *Group) {
+		g.clock = c
+	}
+}
+
+// NewGroup returns a Group of members, using policy to choose between them.
+// Health checks start immediately, call Close to stop them.
+// Panics if there are no members.
+func NewGroup(policy Policy, members []Member, opts ...GroupOption) *Group {
+	if len(members) == 0 {
+		panic("router: group has no members")
+	}
+	g := &Group{
+		policy:   policy,
+		interval: 5 * time.Second,
+		timeout:  time.Second,
+		clock:    clock.Real(),
+		stopped:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	for _, m := range members {
+		gm := &groupMember{Member: m}
+		gm.healthy.Store(true)
+		g.members = append(g.members, gm)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	g.stop = stop
+	go g.checkEvery(ctx)
+	return g
+}
+
+// Clients returns the client of each member, in the order given to NewGroup.
+func (g *Group) Clients() []any {
+	res := make([]any, len(g.members))
+	for i, m := range g.members {
+		res[i] = m.Client
+	}
+	return res
+}
+
+// Healthy returns whether each member is healthy, in the order given to NewGroup.
+func (g *Group) Healthy() []bool {
+	res := make([]bool, len(g.members))
+	for i, m := range g.members {
+		res[i] = m.healthy.Load()
+	}
+	return res
+}
+
+// Check runs the HealthCheck of all members now, waiting for them to complete.
+func (g *Group) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, m := range g.members {
+		if m.Check == nil {
+			m.healthy.Store(true) // it may have been marked unhealthy by Failover
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, g.timeout)
+			defer cancel()
+			m.healthy.Store(m.Check(ctx) == nil)
+		}()
+	}
+	wg.Wait()
+}
+
+// Close stops health checks.
+// Close implements io.Closer so a Group created by a Factory can stop checking when evicted, see CloseOnEvict.
+// Closing a Group does not close its members.
+func (g *Group) Close() error {
+	g.stop()
+	<-g.stopped
+	return nil
+}
+
+func (g *Group) checkEvery(ctx context.Context) {
+	defer close(g.stopped)
+	ticker := g.clock.Every(g.interval)
+	defer ticker.Stop()
+	for {
+		g.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-ticker.C():
+			if !ok {
+				return // the clock was stopped
+			}
+		}
+	}
+}
+
+// ErrNoHealthyMember is returned, wrapped in a status with codes.Unavailable, by Router.Get when no member of a
+// Group is healthy.
+var ErrNoHealthyMember = errors.New("no healthy member")
+
+// pick returns the client of the member chosen by g.policy.
+func (g *Group) pick(name string) (any, error) {
+	members := g.candidates()
+	if len(members) == 0 {
+		return nil, g.noHealthyMember(name)
+	}
+	return members[0].Client, nil
+}
+
+// candidates returns the healthy members of g, in the order they should be tried according to g.policy.
+func (g *Group) candidates() []*groupMember {
+	healthy := make([]*groupMember, 0, len(g.members))
+	for _, m := range g.members {
+		if m.healthy.Load() {
+			healthy = append(healthy, m)
+		}
+	}
+	if g.policy == RoundRobin && len(healthy) > 1 {
+		i := int((g.next.Add(1) - 1) % uint64(len(healthy)))
+		healthy = append(append(make([]*groupMember, 0, len(healthy)), healthy[i:]...), healthy[:i]...)
+	}
+	return healthy
+}
+
+func (g *Group) noHealthyMember(name string) error {
+	return status.Errorf(codes.Unavailable, "%s: %v", name, ErrNoHealthyMember)
+}
+
+// holdsType returns true if all members of g satisfy holdsType.
+func (g *Group) holdsType(holdsType func(client any) bool) bool {
+	for _, m := range g.members {
+		if !holdsType(m.Client) {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupHoldsType returns true if client is a Group whose members all satisfy holdsType.
+// Used by generated routers to allow a Group to be added in place of a client.
+func GroupHoldsType(client any, holdsType func(client any) bool) bool {
+	g, ok := client.(*Group)
+	return ok && g.holdsType(holdsType)
+}
+
+// GRPCHealthCheck returns a HealthCheck that calls the grpc.health.v1.Health service on conn for the given service
+// name, the empty service name checks the health of the server as a whole.
+// The check fails unless the status is SERVING.
+func GRPCHealthCheck(conn grpc.ClientConnInterface, service string) HealthCheck {
+	client := grpc_health_v1.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		res, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("health status %v", res.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,195 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/smart-core-os/sc-golang/pkg/time/clock"
+)
+
+func TestGroup_PrimaryBackup(t *testing.T) {
+	var primaryDown atomic.Bool
+	g := NewGroup(PrimaryBackup, []Member{
+		{Client: "primary", Check: func(ctx context.Context) error {
+			if primaryDown.Load() {
+				return errors.New("down")
+			}
+			return nil
+		}},
+		{Client: "backup"},
+	}, WithCheckInterval(time.Hour))
+	t.Cleanup(func() { g.Close() })
+
+	r := NewRouter()
+	r.Add("device", g)
+	assertGet(t, r, "device", "primary")
+	assertGet(t, r, "device", "primary")
+
+	primaryDown.Store(true)
+	g.Check(context.Background())
+	assertGet(t, r, "device", "backup")
+
+	primaryDown.Store(false)
+	g.Check(context.Background())
+	assertGet(t, r, "device", "primary")
+}
+
+func TestGroup_RoundRobin(t *testing.T) {
+	down := errors.New("down")
+	g := NewGroup(RoundRobin, []Member{
+		{Client: "a"},
+		{Client: "b", Check: func(ctx context.Context) error { return down }},
+		{Client: "c"},
+	}, WithCheckInterval(time.Hour))
+	t.Cleanup(func() { g.Close() })
+
+	r := NewRouter()
+	r.Add("device", g)
+	// b is healthy until it is checked
+	for _, want := range []string{"a", "b", "c", "a"} {
+		assertGet(t, r, "device", want)
+	}
+	g.Check(context.Background())
+	var got []any
+	for range 4 {
+		got = append(got, mustGet(t, r, "device"))
+	}
+	want := []any{"a", "c", "a", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestGroup_noHealthyMembers(t *testing.T) {
+	down := func(ctx context.Context) error { return errors.New("down") }
+	g := NewGroup(PrimaryBackup, []Member{{Client: "a", Check: down}, {Client: "b", Check: down}}, WithCheckInterval(time.Hour))
+	t.Cleanup(func() { g.Close() })
+	g.Check(context.Background())
+
+	r := NewRouter()
+	r.Add("device", g)
+	_, err := r.Get("device")
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("want Unavailable, got %v", err)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	g := NewGroup(PrimaryBackup, []Member{{Client: "a"}, {Client: "b"}, {Client: "c"}}, WithCheckInterval(time.Hour))
+	t.Cleanup(func() { g.Close() })
+	r := NewRouter()
+	r.Add("device", g)
+	r.Add("single", "s")
+
+	callUntil := func(name string, errs map[any]error) ([]any, error) {
+		g.Check(context.Background()) // members marked unhealthy by previous calls become healthy again
+		var called []any
+		err := Failover(r, name, func(client any, target string) error {
+			called = append(called, client)
+			return errs[client]
+		})
+		return called, err
+	}
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	called, err := callUntil("device", map[any]error{"a": unavailable})
+	if err != nil || len(called) != 2 || called[1] != "b" {
+		t.Fatalf("want a then b, got %v %v", called, err)
+	}
+	// only Unavailable fails over
+	notFound := status.Error(codes.NotFound, "missing")
+	called, err = callUntil("device", map[any]error{"a": notFound, "b": notFound})
+	if err != notFound || len(called) != 1 {
+		t.Fatalf("want one call returning NotFound, got %v %v", called, err)
+	}
+	// the last error is returned if every member fails
+	called, err = callUntil("device", map[any]error{"a": unavailable, "b": unavailable, "c": unavailable})
+	if err != unavailable || len(called) != 3 {
+		t.Fatalf("want three calls returning Unavailable, got %v %v", called, err)
+	}
+	// clients that aren't in a group are called once
+	called, err = callUntil("single", map[any]error{"s": unavailable})
+	if err != unavailable || len(called) != 1 {
+		t.Fatalf("want one call for a single client, got %v %v", called, err)
+	}
+}
+
+func TestGroup_checkEvery(t *testing.T) {
+	var healthy atomic.Bool
+	fake := clock.NewFake(time.Unix(0, 0))
+	g := NewGroup(PrimaryBackup, []Member{
+		{Client: "a", Check: func(ctx context.Context) error {
+			if !healthy.Load() {
+				return errors.New("down")
+			}
+			return nil
+		}},
+		{Client: "b"},
+	}, WithCheckInterval(time.Minute), WithGroupClock(fake))
+	t.Cleanup(func() { g.Close() })
+
+	// members are checked immediately
+	waitFor(t, func() bool { return !g.Healthy()[0] })
+	healthy.Store(true)
+	ctx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+	if err := fake.BlockUntil(ctx, 1); err != nil {
+		t.Fatalf("timeout waiting for the check ticker")
+	}
+	fake.Add(time.Minute - time.Nanosecond)
+	if g.Healthy()[0] {
+		t.Fatalf("want no check before the interval")
+	}
+	fake.Add(time.Nanosecond)
+	waitFor(t, func() bool { return g.Healthy()[0] })
+}
+
+func TestGRPCHealthCheck(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	hs := health.NewServer()
+	server := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, hs)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	check := GRPCHealthCheck(conn, "")
+	ctx := context.Background()
+	if err := check(ctx); err != nil {
+		t.Fatalf("want healthy, got %v", err)
+	}
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if err := check(ctx); err == nil {
+		t.Fatalf("want unhealthy")
+	}
+}
+
+func assertGet(t *testing.T, r Router, name string, want any) {
+	t.Helper()
+	if got := mustGet(t, r, name); got != want {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Puller is implemented by Routers that can emit changes to their contents.
+// See Pull.
+type Puller interface {
+	// Pull returns a channel that emits the contents of this Router followed by changes to those contents, until ctx
+	// is done.
+	// Changes are emitted in the order they were made, including the creation of clients via a Factory and their
+	// eviction.
+	// Changes for a name that have not been received yet are combined into one, keeping the oldest Old and newest
+	// New, so a slow receiver sees each name at most once however many times it changed.
+	// The channel is closed when ctx is done.
+	Pull(ctx context.Context, opts ...PullOption) <-chan Change
+}
+
+// Pull returns a channel of changes to the contents of r, see Puller.
+// Returns an error with codes.Unimplemented if r does not implement Puller.
+// Routers returned by NewRouter implement Puller, for generated routers pull their Router field.
+func Pull(ctx context.Context, r Router, opts ...PullOption) (<-chan Change, error) {
+	p, ok := r.(Puller)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "router %T does not support Pull", r)
+	}
+	return p.Pull(ctx, opts...), nil
+}
+
+// PullOption configures a call to Pull.
+type PullOption func(c *pullConfig)
+
+type pullConfig struct {
+	updatesOnly bool
+}
+
+// WithUpdatesOnly configures Pull to only emit changes made after Pull is called, skipping the seed changes
+// that describe the existing contents of the Router.
+func WithUpdatesOnly(updatesOnly bool) PullOption {
+	return func(c *pullConfig) {
+		c.updatesOnly = updatesOnly
+	}
+}
+
+// puller queues changes for a single call to Pull.
+// Queueing means a slow receiver does not block changes to the router.
+// Queued changes for the same name are combined so the queue holds at most one change per name.
+type puller struct {
+	mu     sync.Mutex
+	queue  []Change
+	byName map[string]int // index in queue of the change for each name
+	wake   chan struct{}  // signalled when queue is added to
+}
+
+func (p *puller) push(c Change) {
+	p.mu.Lock()
+	if i, ok := p.byName[c.Name]; ok {
+		p.queue[i] = combineChanges(p.queue[i], c)
+	} else {
+		if p.byName == nil {
+			p.byName = make(map[string]int)
+		}
+		p.byName[c.Name] = len(p.queue)
+		p.queue = append(p.queue, c)
+	}
+	p.mu.Unlock()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *puller) take() []Change {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q := p.queue
+	p.queue = nil
+	clear(p.byName)
+	return q
+}
+
+// combineChanges returns a single change equivalent to a followed by b, both for the same name.
+// The result keeps the Old of a and everything else from b, if a is a seed so is the result.
+// If the result has neither Old nor New, nothing changed and it should not be emitted.
+func combineChanges(a, b Change) Change {
+	b.Old = a.Old
+	if a.Seed {
+		b.Seed, b.Auto, b.Evicted = true, false, false
+	}
+	return b
+}
+
+// Pull returns a channel of changes to r.
+// Unless WithUpdatesOnly is used, the existing entries of r are emitted first, sorted by name, with Change.Seed set.
+func (r *router) Pull(ctx context.Context, opts ...PullOption) <-chan Change {
+	var conf pullConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	p := &puller{wake: make(chan struct{}, 1)}
+	r.mu.Lock()
+	if !conf.updatesOnly {
+		for name, client := range r.registry {
+			p.queue = append(p.queue, Change{Name: name, New: client, Seed: true})
+		}
+		sort.Slice(p.queue, func(i, j int) bool {
+			return p.queue[i].Name < p.queue[j].Name
+		})
+		p.byName = make(map[string]int, len(p.queue))
+		for i, c := range p.queue {
+			p.byName[c.Name] = i
+		}
+	}
+	if r.pulls == nil {
+		r.pulls = make(map[*puller]struct{})
+	}
+	r.pulls[p] = struct{}{}
+	r.mu.Unlock()
+
+	res := make(chan Change)
+	go func() {
+		defer close(res)
+		defer func() {
+			r.mu.Lock()
+			delete(r.pulls, p)
+			r.mu.Unlock()
+		}()
+		for {
+			for _, c := range p.take() {
+				if c.Old == nil && c.New == nil {
+					continue // added then removed before it was received
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case res <- c:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.wake:
+			}
+		}
+	}()
+	return res
+}
+
+// publishLocked queues c for all callers of Pull.
+// r.mu must be held, which guarantees changes are queued in the order they are made.
+func (r *router) publishLocked(c Change) {
+	for p := range r.pulls {
+		p.push(c)
+	}
+}
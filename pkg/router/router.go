@@ -1,14 +1,26 @@
 package router
 
 import (
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/smart-core-os/sc-golang/pkg/time/clock"
 )
 
 // Router tracks a registry of gRPC clients.
 // Typically used by code generated via the protoc-gen-router plugin.
+//
+// Clients are usually added for an exact name, but can also be added for all the descendants of a name by adding
+// them using a name ending in "/**", for example a client added as "site/building-a/**" is returned by Get for
+// "site/building-a/floor-1" and "site/building-a/floor-1/light-1", but not "site/building-a".
+// The name "**" matches all names.
+// If more than one such pattern matches a name the longest wins, exact names always win over patterns.
+// See WithPrefixStripping for changing the name requests are forwarded with.
 type Router interface {
 	// Add adds a named client to this Router.
 	// Add returns the old client associated with this name, or nil if there wasn't one.
@@ -19,6 +31,7 @@ type Router interface {
 	// Remove removes and returns a named client.
 	Remove(name string) any
 	// Has returns true if this Router has a client with the given name.
+	// Names are not matched against patterns, Has("a/**") is true only if a client was added with that name.
 	Has(name string) bool
 	// Get returns the client for the given name.
 	// An error will be returned if no such client exists.
@@ -31,7 +44,19 @@ type router struct {
 	factory  Factory
 	fallback Factory
 
+	stripPrefix bool // see WithPrefixStripping
+
+	// eviction of clients created by factory, see WithIdleTimeout and WithMaxAutoClients
+	idleTimeout time.Duration
+	maxAuto     int
+	onEvict     func(name string, client any)
+	clock       clock.Clock
+	auto        map[string]*autoEntry // keyed by name, protected by mu
+	uses        atomic.Uint64         // counts calls to Get that return a client in auto, orders autoEntry.useSeq
+	sweeping    bool                  // a sweepIdle goroutine is running, protected by mu
+
 	onChange func(Change)
+	pulls    map[*puller]struct{} // see Pull, protected by mu
 }
 type Factory func(string) (any, error) // returns the type MyServiceClient
 
@@ -39,6 +64,7 @@ type Factory func(string) (any, error) // returns the type MyServiceClient
 func NewRouter(opts ...Option) Router {
 	r := &router{
 		registry: make(map[string]any),
+		clock:    clock.Real(),
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -50,6 +76,8 @@ func (r *router) Add(name string, client any) any {
 	r.mu.Lock()
 	old := r.registry[name]
 	r.registry[name] = client
+	r.untrackAutoLocked(name)
+	r.publishLocked(Change{Name: name, Old: old, New: client})
 	r.mu.Unlock()
 
 	if r.onChange != nil {
@@ -70,6 +98,8 @@ func (r *router) Remove(name string) any {
 		return old
 	}
 	delete(r.registry, name)
+	r.untrackAutoLocked(name)
+	r.publishLocked(Change{Name: name, Old: old})
 	r.mu.Unlock()
 
 	if r.onChange != nil {
@@ -87,18 +117,50 @@ func (r *router) Has(name string) bool {
 
 // Get returns the client identified by the given name.
 // If the name is not recognised by r
-//  1. A fallback is checked, configured via WithFallback, if found it is returned, else
-//  2. The factory is invoked to create a new client, configured via WithFactory.
+//  1. The longest matching pattern is checked, see Router, if found it is returned, else
+//  2. A fallback is checked, configured via WithFallback, if found it is returned, else
+//  3. The factory is invoked to create a new client, configured via WithFactory.
 //     If the factory successfully creates a client, and no concurrent call already created a client, it is remembered
 //     and the callback registered via WithOnCommit is notified.
 //
+// If the client found is a Group, a healthy member of the group is returned instead.
+//
 // If no client can be found or created, this returns an error suitable for return by a gRPC method,
-// i.e. one representing codes.NotFound.
+// i.e. one representing codes.NotFound, or codes.Unavailable if no member of a Group is healthy.
 //
 // Note, no locks are held when invoking fallbacks, factories, or callbacks.
 func (r *router) Get(name string) (child any, err error) {
+	child, _, err = r.Resolve(name)
+	return child, err
+}
+
+// Resolve is like Get but also returns the name that requests for name should be forwarded with.
+// The returned name differs from name only if the client was found using a pattern and WithPrefixStripping is used.
+func (r *router) Resolve(name string) (child any, target string, err error) {
+	child, target, err = r.resolveEntry(name)
+	if err != nil {
+		return nil, target, err
+	}
+	if g, ok := child.(*Group); ok {
+		child, err = g.pick(name)
+	}
+	return
+}
+
+// resolveEntry is like Resolve but returns any Group as is, instead of one of its members.
+func (r *router) resolveEntry(name string) (child any, target string, err error) {
+	target = name
 	r.mu.RLock()
 	child, exists := r.registry[name]
+	if !exists {
+		var prefix string
+		if child, prefix, exists = r.matchLocked(name); exists && r.stripPrefix {
+			target = strings.TrimPrefix(name[len(prefix):], "/")
+		}
+	}
+	if exists && r.evicts() {
+		r.touchRLocked(name)
+	}
 	r.mu.RUnlock()
 	if !exists {
 		child, exists, err = invoke(name, r.fallback)
@@ -109,26 +171,122 @@ func (r *router) Get(name string) (child any, err error) {
 			r.mu.Lock()
 			// check again
 			var newChildRemembered bool
+			var evicted []*autoEntry
 			child2, exists2 := r.registry[name]
 			if exists2 {
 				child = child2
 			} else {
 				newChildRemembered = true
 				r.registry[name] = child
+				r.publishLocked(Change{Name: name, New: child, Auto: true})
+				evicted = r.trackAutoLocked(name, child)
 			}
 			r.mu.Unlock()
 
 			if newChildRemembered && r.onChange != nil {
 				r.onChange(Change{Name: name, New: child, Auto: true})
 			}
+			r.notifyEvicted(evicted)
 		}
 	}
 
 	if !exists {
-		return nil, status.Error(codes.NotFound, name)
+		return nil, name, status.Error(codes.NotFound, name)
 	}
 	return
 }
+
+// matchLocked finds the longest pattern, like "a/b/**", that matches name.
+// Returns the client and the prefix of the pattern, without the "/**".
+// r.mu must be held.
+func (r *router) matchLocked(name string) (any, string, bool) {
+	for prefix := name; ; {
+		i := strings.LastIndexByte(prefix, '/')
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+		if child, ok := r.registry[prefix+PatternSuffix]; ok {
+			return child, prefix, true
+		}
+	}
+	child, ok := r.registry[MatchAll]
+	return child, "", ok
+}
+
+const (
+	// PatternSuffix is added to a name to match all descendants of that name.
+	// See Router.
+	PatternSuffix = "/**"
+	// MatchAll is a name that matches all names.
+	// See Router.
+	MatchAll = "**"
+)
+
+// IsPattern returns true if name matches the descendants of a name, rather than being a name itself.
+// See Router.
+func IsPattern(name string) bool {
+	return name == MatchAll || strings.HasSuffix(name, PatternSuffix)
+}
+
+// Resolver is implemented by Routers that can change the name requests are forwarded with.
+// See Resolve.
+type Resolver interface {
+	// Resolve returns the client for name, like Router.Get, and the name requests should be forwarded with.
+	Resolve(name string) (client any, target string, err error)
+}
+
+// Resolve returns the client for name from r, and the name requests should be forwarded to that client with.
+// If r does not implement Resolver, this is the same as Router.Get with target equal to name.
+func Resolve(r Router, name string) (client any, target string, err error) {
+	if rr, ok := r.(Resolver); ok {
+		return rr.Resolve(name)
+	}
+	client, err = r.Get(name)
+	return client, name, err
+}
+
+// Failover calls call with the client for name from r, and the name requests should be forwarded with, see Resolve.
+// If the client is a member of a Group and call returns an error with codes.Unavailable, the member is marked
+// unhealthy until its next health check and call is made again using the next healthy member, until call returns any
+// other result or no healthy members remain.
+// The error from the last call is returned.
+//
+// Generated routers use Failover for unary calls, and to start streaming calls.
+// Calls must be safe to repeat, a failed call may have reached the member before it failed.
+func Failover(r Router, name string, call func(client any, target string) error) error {
+	er, ok := r.(interface {
+		resolveEntry(name string) (any, string, error)
+	})
+	if !ok {
+		client, target, err := Resolve(r, name)
+		if err != nil {
+			return err
+		}
+		return call(client, target)
+	}
+	entry, target, err := er.resolveEntry(name)
+	if err != nil {
+		return err
+	}
+	g, ok := entry.(*Group)
+	if !ok {
+		return call(entry, target)
+	}
+	members := g.candidates()
+	if len(members) == 0 {
+		return g.noHealthyMember(name)
+	}
+	for _, m := range members {
+		err = call(m.Client, target)
+		if status.Code(err) != codes.Unavailable {
+			return err
+		}
+		m.healthy.Store(false)
+	}
+	return err
+}
+
 func invoke(name string, f Factory) (any, bool, error) {
 	if f == nil {
 		return nil, false, nil
@@ -159,8 +317,19 @@ func WithFallback(f Factory) Option {
 	}
 }
 
+// WithPrefixStripping configures a Router to remove the matched prefix from names of requests routed using a pattern.
+// For example a request for "site/building-a/floor-1" routed to a client added as "site/building-a/**" is forwarded
+// to that client with the name "floor-1".
+// Requests routed using MatchAll, or to clients added for an exact name, are forwarded unchanged.
+func WithPrefixStripping() Option {
+	return func(r *router) {
+		r.stripPrefix = true
+	}
+}
+
 // WithOnChange registers a func that will be called whenever the contents of this router change.
-// Changes include calls to Router.Add, Router.Remove, or Router.Get with a configured Factory.
+// Changes include calls to Router.Add, Router.Remove, or Router.Get with a configured Factory, and the eviction of
+// clients created by a Factory, see WithIdleTimeout.
 func WithOnChange(onChange func(Change)) Option {
 	return func(r *router) {
 		r.onChange = onChange
@@ -168,6 +337,7 @@ func WithOnChange(onChange func(Change)) Option {
 }
 
 // Change represents a change to this routers contents.
+// See WithOnChange and Pull.
 type Change struct {
 	// Name is the name of the entry being changed.
 	Name string
@@ -175,6 +345,12 @@ type Change struct {
 	Old any
 	// New holds the new value, or nil if there is no new value.
 	New any
-	// Auto is true if New was created via a Factory.
+	// Auto is true if New was created via a Factory, or Old was created via a Factory and has been evicted.
 	Auto bool
+	// Evicted is true if Old was removed because it was idle or least recently used.
+	// See WithIdleTimeout and WithMaxAutoClients.
+	Evicted bool
+	// Seed is true if this change was emitted by Pull to describe an existing entry.
+	// Seed changes have a nil Old and Auto is not set.
+	Seed bool
 }
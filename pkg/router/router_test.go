@@ -0,0 +1,212 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRouter_patterns(t *testing.T) {
+	r := NewRouter()
+	r.Add("site/building-a/**", "building-a")
+	r.Add("site/building-a/floor-1/**", "floor-1")
+	r.Add("site/building-a/floor-1/light-1", "light-1")
+
+	tests := []struct {
+		name string
+		want any
+	}{
+		{"site/building-a/floor-1/light-1", "light-1"},
+		{"site/building-a/floor-1/light-2", "floor-1"},
+		{"site/building-a/floor-1/room/light-3", "floor-1"},
+		{"site/building-a/floor-2", "building-a"},
+		{"site/building-a/floor-1", "building-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Get(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	for _, name := range []string{"site/building-a", "site/building-b/floor-1", "site"} {
+		if _, err := r.Get(name); status.Code(err) != codes.NotFound {
+			t.Errorf("%s: want NotFound, got %v", name, err)
+		}
+	}
+	if r.Has("site/building-a/floor-2") {
+		t.Errorf("Has should not match patterns")
+	}
+
+	r.Add(MatchAll, "all")
+	if got, err := r.Get("site"); err != nil || got != "all" {
+		t.Errorf("want all, got %v %v", got, err)
+	}
+	r.Remove("site/building-a/floor-1/**")
+	if got, err := r.Get("site/building-a/floor-1/light-2"); err != nil || got != "building-a" {
+		t.Errorf("want building-a after remove, got %v %v", got, err)
+	}
+}
+
+func TestRouter_patternBeforeFactory(t *testing.T) {
+	var created []string
+	r := NewRouter(WithFactory(func(name string) (any, error) {
+		created = append(created, name)
+		return name, nil
+	}))
+	r.Add("a/**", "a")
+	if got, _ := r.Get("a/b"); got != "a" {
+		t.Errorf("want a, got %v", got)
+	}
+	if got, _ := r.Get("b/c"); got != "b/c" {
+		t.Errorf("want b/c from factory, got %v", got)
+	}
+	if len(created) != 1 {
+		t.Errorf("want factory called once, got %v", created)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		strip bool
+		name  string
+		want  string
+	}{
+		{false, "site/building-a/floor-1", "site/building-a/floor-1"},
+		{true, "site/building-a/floor-1", "floor-1"},
+		{true, "site/building-a/floor-1/light-1", "floor-1/light-1"},
+		{true, "site/exact", "site/exact"},
+		{true, "other/thing", "other/thing"}, // matched by MatchAll
+	}
+	for _, tt := range tests {
+		var opts []Option
+		if tt.strip {
+			opts = append(opts, WithPrefixStripping())
+		}
+		r := NewRouter(opts...)
+		r.Add("site/building-a/**", "building-a")
+		r.Add("site/exact", "exact")
+		r.Add(MatchAll, "all")
+		_, got, err := Resolve(r, tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("strip=%v %s: want %q, got %q", tt.strip, tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRouter_Pull(t *testing.T) {
+	r := NewRouter(WithFactory(func(name string) (any, error) { return name, nil }), WithMaxAutoClients(1))
+	r.Add("b", "b1")
+	r.Add("a", "a1")
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	changes := mustPull(t, ctx, r)
+	updates := mustPull(t, ctx, r, WithUpdatesOnly(true))
+	assertChanges(t, changes, []Change{
+		{Name: "a", New: "a1", Seed: true},
+		{Name: "b", New: "b1", Seed: true},
+	})
+
+	// receive each change before making the next, so none are combined
+	for _, step := range []struct {
+		change func()
+		want   []Change
+	}{
+		{func() { r.Add("a", "a2") }, []Change{{Name: "a", Old: "a1", New: "a2"}}},
+		{func() { r.Remove("b") }, []Change{{Name: "b", Old: "b1"}}},
+		{func() { mustGet(t, r, "c") }, []Change{{Name: "c", New: "c", Auto: true}}},
+		{func() { mustGet(t, r, "d") }, []Change{ // evicts c
+			{Name: "d", New: "d", Auto: true},
+			{Name: "c", Old: "c", Auto: true, Evicted: true},
+		}},
+	} {
+		step.change()
+		assertChanges(t, changes, step.want)
+		assertChanges(t, updates, step.want)
+	}
+
+	stop()
+	if _, ok := <-changes; ok {
+		t.Fatalf("want channel closed when ctx is done")
+	}
+	waitFor(t, func() bool {
+		r := r.(*router)
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return len(r.pulls) == 0
+	})
+}
+
+func TestPuller_combines(t *testing.T) {
+	p := &puller{wake: make(chan struct{}, 1)}
+	p.push(Change{Name: "a", New: "a1", Seed: true})
+	p.push(Change{Name: "b", Old: "b1", New: "b2"})
+	p.push(Change{Name: "a", Old: "a1", New: "a2"})
+	p.push(Change{Name: "c", New: "c", Auto: true})
+	p.push(Change{Name: "b", Old: "b2", New: "b3"})
+	p.push(Change{Name: "c", Old: "c", Auto: true, Evicted: true})
+	want := []Change{
+		{Name: "a", New: "a2", Seed: true},
+		{Name: "b", Old: "b1", New: "b3"},
+		{Name: "c", Auto: true, Evicted: true}, // skipped by Pull
+	}
+	got := p.take()
+	if len(got) != len(want) {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("change %d: want %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	p.push(Change{Name: "a", Old: "a2"})
+	if got := p.take(); len(got) != 1 || got[0] != (Change{Name: "a", Old: "a2"}) {
+		t.Fatalf("want changes after take not combined with earlier ones, got %+v", got)
+	}
+}
+
+func TestPull_unsupported(t *testing.T) {
+	_, err := Pull(context.Background(), getOnly{NewRouter()})
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("want Unimplemented, got %v", err)
+	}
+}
+
+// getOnly hides all but the Router methods of a Router.
+type getOnly struct {
+	Router
+}
+
+func mustPull(t *testing.T, ctx context.Context, r Router, opts ...PullOption) <-chan Change {
+	t.Helper()
+	changes, err := Pull(ctx, r, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+func assertChanges(t *testing.T, changes <-chan Change, want []Change) {
+	t.Helper()
+	for i, w := range want {
+		select {
+		case got := <-changes:
+			if got != w {
+				t.Fatalf("change %d: want %+v, got %+v", i, w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("change %d: timeout waiting for %+v", i, w)
+		}
+	}
+}
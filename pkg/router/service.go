@@ -0,0 +1,532 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/smart-core-os/sc-golang/pkg/trait"
+	"github.com/smart-core-os/sc-golang/pkg/wrap"
+)
+
+// TraitPackage is the proto package containing the trait services.
+const TraitPackage = "smartcore.traits"
+
+// ServiceRouter routes requests for many gRPC services using the name field of each request, replacing one generated
+// router per service.
+// Each name is associated with a grpc.ClientConnInterface for all services, or a connection per service.
+// Register a ServiceRouter with a grpc.Server to serve every trait service, or a chosen set of services.
+// Where a generated server interface like traits.OnOffApiServer is needed use the generated
+// NewXxxRouterFromServiceRouter constructors, like onoffpb.NewApiRouterFromServiceRouter, see ForService.
+//
+// A ServiceRouter is backed by a Router so patterns, Groups of connections, and the Options passed to NewServiceRouter
+// work as they do for generated routers.
+// Clients returned by a Factory or a Fallback must be a grpc.ClientConnInterface, which is used for all services.
+type ServiceRouter struct {
+	names *router // of grpc.ClientConnInterface, *serviceConns, or *Group of either
+
+	mu sync.Mutex // serialises read-modify-write of names entries
+}
+
+// serviceConns holds the connections for a name that supports specific services.
+// Never modified once added to a ServiceRouter.
+type serviceConns struct {
+	all       grpc.ClientConnInterface // used for services not in byService, may be nil
+	byService map[string]grpc.ClientConnInterface
+}
+
+// NewServiceRouter returns an empty ServiceRouter configured using opts.
+func NewServiceRouter(opts ...Option) *ServiceRouter {
+	return &ServiceRouter{names: NewRouter(opts...).(*router)}
+}
+
+// Router returns the Router that holds the connections of r.
+// Entries are grpc.ClientConnInterface, a Group of grpc.ClientConnInterface, or an unexported type for names added
+// with specific services.
+func (r *ServiceRouter) Router() Router {
+	return r.names
+}
+
+// AddConn associates conn with name for the given services, identified by their full name like
+// "smartcore.traits.OnOffApi".
+// If no services are given conn is used for all services that haven't been explicitly added for name.
+func (r *ServiceRouter) AddConn(name string, conn grpc.ClientConnInterface, services ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addConnLocked(name, conn, services...)
+}
+
+// addConnLocked is AddConn with r.mu held.
+func (r *ServiceRouter) addConnLocked(name string, conn grpc.ClientConnInterface, services ...string) {
+	if len(services) == 0 {
+		if old, ok := r.existingLocked(name); ok {
+			r.names.Add(name, &serviceConns{all: conn, byService: old.byService})
+		} else {
+			r.names.Add(name, conn)
+		}
+		return
+	}
+	old, _ := r.existingLocked(name)
+	entry := &serviceConns{all: old.all, byService: make(map[string]grpc.ClientConnInterface, len(old.byService)+len(services))}
+	for s, c := range old.byService {
+		entry.byService[s] = c
+	}
+	for _, s := range services {
+		entry.byService[s] = conn
+	}
+	r.names.Add(name, entry)
+}
+
+// AddService associates the service described by s with name.
+// All generated XxxWrapper types implement wrap.ServiceUnwrapper.
+func (r *ServiceRouter) AddService(name string, s wrap.ServiceUnwrapper) {
+	conn, desc := s.UnwrapService()
+	r.AddConn(name, conn, desc.ServiceName)
+}
+
+// Remove removes all services associated with name.
+func (r *ServiceRouter) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names.Remove(name)
+}
+
+// RemoveService removes a service added for name using AddConn or AddService.
+func (r *ServiceRouter) RemoveService(name, service string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeServiceLocked(name, service)
+}
+
+// removeServiceLocked is RemoveService with r.mu held.
+// Returns the connection that was removed, or nil if service was not added for name.
+func (r *ServiceRouter) removeServiceLocked(name, service string) grpc.ClientConnInterface {
+	old, ok := r.existingLocked(name)
+	conn, exists := old.byService[service]
+	if !ok || !exists {
+		return nil
+	}
+	if len(old.byService) == 1 && old.all == nil {
+		r.names.Remove(name)
+		return conn
+	}
+	entry := &serviceConns{all: old.all, byService: make(map[string]grpc.ClientConnInterface, len(old.byService))}
+	for s, c := range old.byService {
+		if s != service {
+			entry.byService[s] = c
+		}
+	}
+	r.names.Add(name, entry)
+	return conn
+}
+
+// existingLocked returns the entry added for name, as a serviceConns.
+// Returns false if there is no entry, or the entry is a Group.
+// r.mu must be held if the result is used to replace the entry.
+func (r *ServiceRouter) existingLocked(name string) (serviceConns, bool) {
+	r.names.mu.RLock()
+	old, ok := r.names.registry[name]
+	r.names.mu.RUnlock()
+	if !ok {
+		return serviceConns{}, false
+	}
+	switch old := old.(type) {
+	case *serviceConns:
+		return *old, true
+	case grpc.ClientConnInterface:
+		return serviceConns{all: old}, true
+	}
+	return serviceConns{}, false
+}
+
+// Services returns the services explicitly added for name, sorted.
+// A name added using AddConn without any services supports all services, but none are returned here.
+func (r *ServiceRouter) Services(name string) []string {
+	entry, ok := r.existingLocked(name)
+	if !ok {
+		return nil
+	}
+	res := make([]string, 0, len(entry.byService))
+	for s := range entry.byService {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Traits returns the traits whose services were explicitly added for name, sorted.
+// For example a name added with the "smartcore.traits.OnOffApi" and "smartcore.traits.OnOffInfo" services
+// supports the trait.OnOff trait.
+func (r *ServiceRouter) Traits(name string) []trait.Name {
+	var res []trait.Name
+	for _, s := range r.Services(name) {
+		t, ok := traitOfService(s)
+		if ok && (len(res) == 0 || res[len(res)-1] != t) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// traitOfService returns the trait a trait service belongs to, like trait.OnOff for "smartcore.traits.OnOffApi".
+func traitOfService(service string) (trait.Name, bool) {
+	local, ok := strings.CutPrefix(service, TraitPackage+".")
+	if !ok {
+		return "", false
+	}
+	for _, suffix := range []string{"Api", "Info"} {
+		if t, ok := strings.CutSuffix(local, suffix); ok && t != "" {
+			return trait.Name(TraitPackage + "." + t), true
+		}
+	}
+	return "", false
+}
+
+// ForService returns a Router of the clients for service, identified by its full name like "smartcore.traits.OnOffApi".
+// Clients are created from the connections of r using newClient, the returned Router shares its entries with r so
+// changes made via either are visible in both.
+// Generated routers use ForService so a ServiceRouter can be used as their server interface, for example
+// onoffpb.NewApiRouterFromServiceRouter returns a traits.OnOffApiServer backed by r.
+//
+// Only connections can be added to the returned Router, a client must be a grpc.ClientConnInterface or implement
+// wrap.ServiceUnwrapper, like all generated XxxWrapper types.
+// Remove only removes connections added for service, a connection added using AddConn without services is left in place.
+func (r *ServiceRouter) ForService(service string, newClient func(conn grpc.ClientConnInterface) any) Router {
+	return &serviceRouter{r: r, service: service, newClient: newClient}
+}
+
+// serviceRouter is a Router of the clients for one service of a ServiceRouter, see ServiceRouter.ForService.
+type serviceRouter struct {
+	r         *ServiceRouter
+	service   string
+	newClient func(conn grpc.ClientConnInterface) any
+}
+
+// Add extends Router.Add to panic if client is not a connection.
+func (v *serviceRouter) Add(name string, client any) any {
+	conn := connOf(client)
+	if conn == nil {
+		panic(fmt.Sprintf("not correct type: client of type %T is not a grpc.ClientConnInterface or wrap.ServiceUnwrapper", client))
+	}
+	v.r.mu.Lock()
+	defer v.r.mu.Unlock()
+	old, _ := v.r.existingLocked(name)
+	v.r.addConnLocked(name, conn, v.service)
+	return v.clientOf(connForService(&old, v.service))
+}
+
+func (v *serviceRouter) HoldsType(client any) bool {
+	return connOf(client) != nil
+}
+
+func (v *serviceRouter) Remove(name string) any {
+	v.r.mu.Lock()
+	defer v.r.mu.Unlock()
+	return v.clientOf(v.r.removeServiceLocked(name, v.service))
+}
+
+func (v *serviceRouter) Has(name string) bool {
+	v.r.names.mu.RLock()
+	entry, ok := v.r.names.registry[name]
+	v.r.names.mu.RUnlock()
+	return ok && v.entryConn(entry) != nil
+}
+
+func (v *serviceRouter) Get(name string) (any, error) {
+	client, _, err := v.Resolve(name)
+	return client, err
+}
+
+func (v *serviceRouter) Resolve(name string) (any, string, error) {
+	conn, target, err := v.r.resolveName(v.service, name)
+	if err != nil {
+		return nil, target, err
+	}
+	return v.newClient(conn), target, nil
+}
+
+// Pull emits changes to the entries of v.r that affect v.service.
+// Groups are emitted as is.
+func (v *serviceRouter) Pull(ctx context.Context, opts ...PullOption) <-chan Change {
+	res := make(chan Change)
+	go func() {
+		defer close(res)
+		for c := range v.r.names.Pull(ctx, opts...) {
+			before, after := v.entryConn(c.Old), v.entryConn(c.New)
+			if before == after {
+				continue // v.service is unchanged
+			}
+			c.Old, c.New = v.clientOf(before), v.clientOf(after)
+			select {
+			case <-ctx.Done():
+				return
+			case res <- c:
+			}
+		}
+	}()
+	return res
+}
+
+// entryConn returns the connection of entry used for v.service, or entry if it is a Group.
+// Returns nil if entry does not support v.service.
+func (v *serviceRouter) entryConn(entry any) any {
+	if g, ok := entry.(*Group); ok {
+		return g
+	}
+	if conn := connForService(entry, v.service); conn != nil {
+		return conn
+	}
+	return nil
+}
+
+// clientOf returns the client for conn, conn is returned as is if it is a Group.
+func (v *serviceRouter) clientOf(conn any) any {
+	switch conn := conn.(type) {
+	case *Group:
+		return conn
+	case grpc.ClientConnInterface:
+		return v.newClient(conn)
+	}
+	return nil
+}
+
+// connOf returns the connection client sends requests to, nil if client is not a connection.
+func connOf(client any) grpc.ClientConnInterface {
+	switch client := client.(type) {
+	case wrap.ServiceUnwrapper:
+		conn, _ := client.UnwrapService()
+		return conn
+	case grpc.ClientConnInterface:
+		return client
+	}
+	return nil
+}
+
+// Register registers r with s as the server for the given services, identified by their full name.
+// If no services are given, all services in the TraitPackage known to protoregistry.GlobalFiles are registered.
+// Returns an error if a service is not known to protoregistry.GlobalFiles.
+func (r *ServiceRouter) Register(s grpc.ServiceRegistrar, services ...string) error {
+	var descs []protoreflect.ServiceDescriptor
+	if len(services) == 0 {
+		protoregistry.GlobalFiles.RangeFilesByPackage(TraitPackage, func(fd protoreflect.FileDescriptor) bool {
+			for i := range fd.Services().Len() {
+				descs = append(descs, fd.Services().Get(i))
+			}
+			return true
+		})
+	}
+	for _, name := range services {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return err
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return status.Errorf(codes.InvalidArgument, "%s is not a service", name)
+		}
+		descs = append(descs, sd)
+	}
+	for _, sd := range descs {
+		s.RegisterService(r.serviceDesc(sd), r)
+	}
+	return nil
+}
+
+// serviceDesc returns a grpc.ServiceDesc that forwards all methods of sd using r.
+func (r *ServiceRouter) serviceDesc(sd protoreflect.ServiceDescriptor) *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: string(sd.FullName()),
+		HandlerType: (*any)(nil),
+		Metadata:    sd.ParentFile().Path(),
+	}
+	for i := range sd.Methods().Len() {
+		md := sd.Methods().Get(i)
+		if !md.IsStreamingClient() && !md.IsStreamingServer() {
+			desc.Methods = append(desc.Methods, grpc.MethodDesc{
+				MethodName: string(md.Name()),
+				Handler:    r.unaryHandler(md),
+			})
+			continue
+		}
+		desc.Streams = append(desc.Streams, grpc.StreamDesc{
+			StreamName:    string(md.Name()),
+			Handler:       r.streamHandler(md),
+			ServerStreams: md.IsStreamingServer(),
+			ClientStreams: md.IsStreamingClient(),
+		})
+	}
+	return desc
+}
+
+func (r *ServiceRouter) unaryHandler(md protoreflect.MethodDescriptor) func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	service := string(md.Parent().FullName())
+	fullMethod := "/" + service + "/" + string(md.Name())
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := newMessage(md.Input())
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			conn, err := r.resolve(service, req.(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			out := newMessage(md.Output())
+			if err := conn.Invoke(ctx, fullMethod, req, out); err != nil {
+				return nil, err
+			}
+			return out, nil
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, handler)
+	}
+}
+
+func (r *ServiceRouter) streamHandler(md protoreflect.MethodDescriptor) grpc.StreamHandler {
+	service := string(md.Parent().FullName())
+	fullMethod := "/" + service + "/" + string(md.Name())
+	streamDesc := &grpc.StreamDesc{
+		StreamName:    string(md.Name()),
+		ServerStreams: md.IsStreamingServer(),
+		ClientStreams: md.IsStreamingClient(),
+	}
+	return func(_ any, server grpc.ServerStream) error {
+		// the first request tells us where to send the stream
+		in := newMessage(md.Input())
+		if err := server.RecvMsg(in); err != nil {
+			return err
+		}
+		conn, err := r.resolve(service, in)
+		if err != nil {
+			return err
+		}
+
+		// so we can cancel our forwarding request if we can't send responses to our caller
+		ctx, cancel := context.WithCancel(server.Context())
+		defer cancel()
+		stream, err := conn.NewStream(ctx, streamDesc, fullMethod)
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(in); err != nil {
+			return err
+		}
+		if md.IsStreamingClient() {
+			// server.RecvMsg must not be called once we return, and can only be interrupted by the caller.
+			// Once the forwarded stream ends, requests are received and dropped until the caller closes its side.
+			recvDone := make(chan struct{})
+			defer func() {
+				cancel()
+				<-recvDone
+			}()
+			go func() {
+				defer close(recvDone)
+				forward := true
+				for {
+					msg := newMessage(md.Input())
+					if err := server.RecvMsg(msg); err != nil {
+						if errors.Is(err, io.EOF) {
+							_ = stream.CloseSend()
+						} else {
+							cancel()
+						}
+						return
+					}
+					if forward && stream.SendMsg(msg) != nil {
+						forward = false
+					}
+				}
+			}()
+		} else if err := stream.CloseSend(); err != nil {
+			return err
+		}
+
+		header, err := stream.Header()
+		if err != nil {
+			return err
+		}
+		if err := server.SendHeader(header); err != nil {
+			return err
+		}
+		for {
+			out := newMessage(md.Output())
+			if err := stream.RecvMsg(out); err != nil {
+				server.SetTrailer(stream.Trailer())
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+			if err := server.SendMsg(out); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// resolve returns the connection to use for a request to service, rewriting the name of req if needed.
+func (r *ServiceRouter) resolve(service string, req proto.Message) (grpc.ClientConnInterface, error) {
+	msg := req.ProtoReflect()
+	nameField := msg.Descriptor().Fields().ByName("name")
+	if nameField == nil || nameField.Kind() != protoreflect.StringKind || nameField.Cardinality() == protoreflect.Repeated {
+		return nil, status.Errorf(codes.Unimplemented, "%s has no name field", msg.Descriptor().FullName())
+	}
+	name := msg.Get(nameField).String()
+	conn, target, err := r.resolveName(service, name)
+	if err != nil {
+		return nil, err
+	}
+	if target != name {
+		msg.Set(nameField, protoreflect.ValueOfString(target))
+	}
+	return conn, nil
+}
+
+// resolveName returns the connection to use for requests to service for name, and the name to forward them with.
+func (r *ServiceRouter) resolveName(service, name string) (grpc.ClientConnInterface, string, error) {
+	entry, target, err := Resolve(r.names, name)
+	if err != nil {
+		return nil, target, err
+	}
+	conn := connForService(entry, service)
+	if conn == nil {
+		return nil, target, status.Errorf(codes.Unimplemented, "%s does not support %s", name, service)
+	}
+	return conn, target, nil
+}
+
+// connForService returns the connection of entry used for service, nil if entry does not support service.
+func connForService(entry any, service string) grpc.ClientConnInterface {
+	switch entry := entry.(type) {
+	case *serviceConns:
+		if conn := entry.byService[service]; conn != nil {
+			return conn
+		}
+		return entry.all
+	case grpc.ClientConnInterface:
+		return entry
+	}
+	return nil
+}
+
+// newMessage returns a new message of type md, using the generated type if one is registered.
+func newMessage(md protoreflect.MessageDescriptor) proto.Message {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt.New().Interface()
+	}
+	return dynamicpb.NewMessage(md)
+}
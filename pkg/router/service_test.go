@@ -0,0 +1,192 @@
+package router_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/smart-core-os/sc-api/go/traits"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/smart-core-os/sc-golang/pkg/router"
+	"github.com/smart-core-os/sc-golang/pkg/trait"
+	"github.com/smart-core-os/sc-golang/pkg/trait/onoffpb"
+)
+
+func TestServiceRouter(t *testing.T) {
+	r := router.NewServiceRouter(router.WithPrefixStripping())
+	light := onoffpb.NewModel(onoffpb.WithInitialOnOff(&traits.OnOff{State: traits.OnOff_ON}))
+	r.AddService("light", onoffpb.WrapApi(onoffpb.NewModelServer(light)))
+	r.AddService("light", onoffpb.WrapInfo(&traits.UnimplementedOnOffInfoServer{}))
+	gateway := onoffpb.NewModel(onoffpb.WithInitialOnOff(&traits.OnOff{State: traits.OnOff_OFF}))
+	r.AddService("gateway/**", onoffpb.WrapApi(onoffpb.NewModelServer(gateway)))
+
+	if got, want := r.Services("light"), []string{"smartcore.traits.OnOffApi", "smartcore.traits.OnOffInfo"}; !cmp.Equal(got, want) {
+		t.Fatalf("Services want %v, got %v", want, got)
+	}
+	if got, want := r.Traits("light"), []trait.Name{trait.OnOff}; !cmp.Equal(got, want) {
+		t.Fatalf("Traits want %v, got %v", want, got)
+	}
+
+	conn := serve(t, r)
+	client := traits.NewOnOffApiClient(conn)
+	ctx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+
+	got, err := client.GetOnOff(ctx, &traits.GetOnOffRequest{Name: "light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != traits.OnOff_ON {
+		t.Fatalf("light want ON, got %v", got.State)
+	}
+	got, err = client.GetOnOff(ctx, &traits.GetOnOffRequest{Name: "gateway/light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != traits.OnOff_OFF {
+		t.Fatalf("gateway/light want OFF, got %v", got.State)
+	}
+
+	stream, err := client.PullOnOff(ctx, &traits.PullOnOffRequest{Name: "light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Changes[0].OnOff.State; got != traits.OnOff_ON {
+		t.Fatalf("pull want ON, got %v", got)
+	}
+	if _, err := light.UpdateOnOff(&traits.OnOff{State: traits.OnOff_OFF}); err != nil {
+		t.Fatal(err)
+	}
+	res, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Changes[0].OnOff.State; got != traits.OnOff_OFF {
+		t.Fatalf("pull want OFF, got %v", got)
+	}
+
+	_, err = traits.NewOnOffInfoClient(conn).DescribeOnOff(ctx, &traits.DescribeOnOffRequest{Name: "gateway/light"})
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("want Unimplemented for a service not added, got %v", err)
+	}
+	_, err = client.GetOnOff(ctx, &traits.GetOnOffRequest{Name: "unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("want NotFound for an unknown name, got %v", err)
+	}
+
+	r.RemoveService("light", "smartcore.traits.OnOffApi")
+	_, err = client.GetOnOff(ctx, &traits.GetOnOffRequest{Name: "light"})
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("want Unimplemented after RemoveService, got %v", err)
+	}
+	r.RemoveService("light", "smartcore.traits.OnOffInfo")
+	if r.Router().Has("light") {
+		t.Fatalf("want light removed with its last service")
+	}
+}
+
+func TestServiceRouter_AddConn(t *testing.T) {
+	light := onoffpb.NewModel(onoffpb.WithInitialOnOff(&traits.OnOff{State: traits.OnOff_ON}))
+	backend := router.NewServiceRouter()
+	backend.AddService("light", onoffpb.WrapApi(onoffpb.NewModelServer(light)))
+
+	r := router.NewServiceRouter()
+	r.AddConn("light", serve(t, backend))
+	client := traits.NewOnOffApiClient(serve(t, r))
+	got, err := client.GetOnOff(context.Background(), &traits.GetOnOffRequest{Name: "light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != traits.OnOff_ON {
+		t.Fatalf("want ON, got %v", got.State)
+	}
+	if got := r.Services("light"); len(got) != 0 {
+		t.Fatalf("want no explicit services, got %v", got)
+	}
+}
+
+func TestServiceRouter_generatedRouter(t *testing.T) {
+	r := router.NewServiceRouter()
+	var api traits.OnOffApiServer = onoffpb.NewApiRouterFromServiceRouter(r)
+	apiRouter := api.(*onoffpb.ApiRouter)
+
+	light := onoffpb.NewModel(onoffpb.WithInitialOnOff(&traits.OnOff{State: traits.OnOff_ON}))
+	apiRouter.Add("light", onoffpb.WrapApi(onoffpb.NewModelServer(light)))
+	if got, want := r.Services("light"), []string{"smartcore.traits.OnOffApi"}; !cmp.Equal(got, want) {
+		t.Fatalf("Services want %v, got %v", want, got)
+	}
+	lamp := onoffpb.NewModel(onoffpb.WithInitialOnOff(&traits.OnOff{State: traits.OnOff_OFF}))
+	r.AddService("lamp", onoffpb.WrapApi(onoffpb.NewModelServer(lamp)))
+	r.AddService("lamp", onoffpb.WrapInfo(&traits.UnimplementedOnOffInfoServer{}))
+
+	for name, want := range map[string]traits.OnOff_State{"light": traits.OnOff_ON, "lamp": traits.OnOff_OFF} {
+		got, err := api.GetOnOff(context.Background(), &traits.GetOnOffRequest{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.State != want {
+			t.Fatalf("%s want %v, got %v", name, want, got.State)
+		}
+	}
+
+	if old := apiRouter.RemoveOnOffApiClient("lamp"); old == nil {
+		t.Fatalf("want the removed client")
+	}
+	if apiRouter.Has("lamp") {
+		t.Fatalf("want lamp removed from the OnOffApi router")
+	}
+	if got, want := r.Services("lamp"), []string{"smartcore.traits.OnOffInfo"}; !cmp.Equal(got, want) {
+		t.Fatalf("Services want %v, got %v", want, got)
+	}
+}
+
+func TestServiceRouter_Register(t *testing.T) {
+	r := router.NewServiceRouter()
+	if err := r.Register(grpc.NewServer(), "smartcore.traits.NotAService"); err == nil {
+		t.Fatalf("want error registering an unknown service")
+	}
+	s := grpc.NewServer()
+	if err := r.Register(s); err != nil {
+		t.Fatal(err)
+	}
+	info := s.GetServiceInfo()
+	for _, want := range []string{"smartcore.traits.OnOffApi", "smartcore.traits.OnOffInfo", "smartcore.traits.LightApi"} {
+		if _, ok := info[want]; !ok {
+			t.Errorf("want %s registered", want)
+		}
+	}
+}
+
+// serve serves r over an in-memory connection, returning a connection to it.
+func serve(t *testing.T, r *router.ServiceRouter) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	if err := r.Register(server); err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
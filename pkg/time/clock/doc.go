@@ -1,5 +1,6 @@
 // Package clock contains the Clock interface, an abstraction over the functions in the standard time package.
 // A trivial implementation of the Clock interface using the time package is provided by Real.
+// Scaled runs faster or slower than real time, and Fake only changes when told to, for use in tests.
 // Other packages can provide alternative implementations of the Clock interface that behave differently,
 // for example to implement simulations at arbitrary speeds.
 package clock
@@ -0,0 +1,207 @@
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only changes when Set or Add is called, useful for tests.
+// Timers and tickers fire in order as time is advanced past them, with Now reporting the time they are due.
+// Use BlockUntil or BlockUntilAt to wait for code under test to start waiting on the clock before advancing it.
+//
+// Stop closes all open channels, after which the Fake cannot be used further.
+// The zero value is a Fake whose time starts at the zero time.
+// All methods are thread-safe.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	stopped bool
+	changed chan struct{} // closed and replaced when waiters change
+}
+
+// NewFake returns a Fake clock whose time starts at now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+var _ Clock = (*Fake)(nil)
+
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration // zero for a one-shot timer
+	ch     chan time.Time
+}
+
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Fake) At(t time.Time) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	switch {
+	case c.stopped:
+		close(ch)
+	case !t.After(c.now):
+		ch <- c.now
+	default:
+		c.addWaiterLocked(&fakeWaiter{at: t, ch: ch})
+	}
+	return ch
+}
+
+func (c *Fake) After(d time.Duration) <-chan time.Time {
+	return c.At(c.Now().Add(d))
+}
+
+func (c *Fake) Every(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for Fake.Every")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{at: c.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	if c.stopped {
+		close(w.ch)
+	} else {
+		c.addWaiterLocked(w)
+	}
+	return &fakeTicker{clock: c, w: w}
+}
+
+// Add advances the clock by d, firing any timers and tickers that become due.
+func (c *Fake) Add(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set advances the clock to t, firing any timers and tickers that become due, in the order they are due.
+// Setting a time before Now changes Now but doesn't fire anything.
+func (c *Fake) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) > 0 && !c.waiters[0].at.After(t) {
+		w := c.waiters[0]
+		c.waiters = c.waiters[1:]
+		c.now = w.at
+		select {
+		case w.ch <- w.at:
+		default: // tickers drop ticks for slow receivers, like time.Ticker
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+			c.addWaiterLocked(w)
+		}
+	}
+	c.now = t
+	c.notifyLocked()
+}
+
+// Waiters returns the number of timers and tickers waiting for the clock to advance.
+func (c *Fake) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until at least n timers or tickers are waiting for the clock to advance, or ctx is done.
+func (c *Fake) BlockUntil(ctx context.Context, n int) error {
+	return c.blockUntil(ctx, func() bool {
+		return len(c.waiters) >= n
+	})
+}
+
+// BlockUntilAt blocks until a timer or ticker is due at exactly t, or ctx is done.
+func (c *Fake) BlockUntilAt(ctx context.Context, t time.Time) error {
+	return c.blockUntil(ctx, func() bool {
+		for _, w := range c.waiters {
+			if w.at.Equal(t) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (c *Fake) blockUntil(ctx context.Context, done func() bool) error {
+	for {
+		c.mu.Lock()
+		if c.changed == nil {
+			c.changed = make(chan struct{})
+		}
+		ok, changed := done(), c.changed
+		c.mu.Unlock()
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Stop stops the clock, closing the channels of all timers and tickers.
+// Stop may be called more than once.
+func (c *Fake) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return
+	}
+	c.stopped = true
+	for _, w := range c.waiters {
+		close(w.ch)
+	}
+	c.waiters = nil
+	c.notifyLocked()
+}
+
+// addWaiterLocked adds w to c.waiters, keeping them ordered by when they are due.
+func (c *Fake) addWaiterLocked(w *fakeWaiter) {
+	i := sort.Search(len(c.waiters), func(i int) bool {
+		return c.waiters[i].at.After(w.at)
+	})
+	c.waiters = append(c.waiters, nil)
+	copy(c.waiters[i+1:], c.waiters[i:])
+	c.waiters[i] = w
+	c.notifyLocked()
+}
+
+func (c *Fake) removeWaiter(w *fakeWaiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.notifyLocked()
+			return
+		}
+	}
+}
+
+func (c *Fake) notifyLocked() {
+	if c.changed != nil {
+		close(c.changed)
+	}
+	c.changed = make(chan struct{})
+}
+
+type fakeTicker struct {
+	clock *Fake
+	w     *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.removeWaiter(t.w)
+}
@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFake_At(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+	at10 := c.At(time.Unix(10, 0))
+	at5 := c.After(5 * time.Second)
+	if got := c.Waiters(); got != 2 {
+		t.Fatalf("want 2 waiters, got %d", got)
+	}
+
+	c.Add(4 * time.Second)
+	assertNoTick(t, at5)
+	c.Add(7 * time.Second)
+	if got := <-at5; !got.Equal(time.Unix(5, 0)) {
+		t.Fatalf("want at5 sent at 5s, got %v", got)
+	}
+	if got := <-at10; !got.Equal(time.Unix(10, 0)) {
+		t.Fatalf("want at10 sent at 10s, got %v", got)
+	}
+	if got := c.Now(); !got.Equal(time.Unix(11, 0)) {
+		t.Fatalf("want now 11s, got %v", got)
+	}
+
+	past := c.At(time.Unix(1, 0))
+	if got := <-past; !got.Equal(time.Unix(11, 0)) {
+		t.Fatalf("want past time sent immediately, got %v", got)
+	}
+}
+
+func TestFake_Every(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+	ticker := c.Every(time.Second)
+
+	c.Add(time.Second)
+	if got := <-ticker.C(); !got.Equal(time.Unix(1, 0)) {
+		t.Fatalf("want tick at 1s, got %v", got)
+	}
+	// ticks are dropped if not received
+	c.Add(3 * time.Second)
+	if got := <-ticker.C(); !got.Equal(time.Unix(2, 0)) {
+		t.Fatalf("want tick at 2s, got %v", got)
+	}
+	assertNoTick(t, ticker.C())
+
+	ticker.Stop()
+	if got := c.Waiters(); got != 0 {
+		t.Fatalf("want no waiters after Stop, got %d", got)
+	}
+	c.Add(time.Second)
+	assertNoTick(t, ticker.C())
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+	ctx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+
+	go func() {
+		<-c.After(time.Second)
+		<-c.After(time.Second)
+	}()
+	if err := c.BlockUntil(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	c.Add(time.Second)
+	if err := c.BlockUntilAt(ctx, time.Unix(2, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	short, stopShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer stopShort()
+	if err := c.BlockUntil(short, 2); err == nil {
+		t.Fatalf("want error waiting for more waiters than exist")
+	}
+}
+
+func TestFake_Stop(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+	at := c.After(time.Second)
+	ticker := c.Every(time.Second)
+	c.Stop()
+	if _, ok := <-at; ok {
+		t.Fatalf("want At chan closed")
+	}
+	if _, ok := <-ticker.C(); ok {
+		t.Fatalf("want ticker chan closed")
+	}
+	ticker.Stop()
+	if _, ok := <-c.After(time.Second); ok {
+		t.Fatalf("want After chan closed once stopped")
+	}
+}
+
+func TestScaled(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := Scaled(start, 1000)
+	got := <-c.After(10 * time.Second) // 10ms real time
+	if got.Before(start.Add(10 * time.Second)) {
+		t.Fatalf("want after 10s, got %v", got.Sub(start))
+	}
+
+	ticker := c.Every(5 * time.Second)
+	defer ticker.Stop()
+	first, second := <-ticker.C(), <-ticker.C()
+	if d := second.Sub(first); d < 4*time.Second {
+		t.Fatalf("want ticks about 5s apart, got %v", d)
+	}
+}
+
+func assertNoTick(t *testing.T, ch <-chan time.Time) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		t.Fatalf("unexpected send %v", got)
+	default:
+	}
+}
@@ -0,0 +1,92 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Scaled returns a Clock whose time starts at start and runs scale times faster than real time.
+// For example a scale of 60 advances the clock an hour for every real minute.
+// Timers and tickers wait in real time for the equivalent scaled duration to pass.
+// Panics if scale is not positive.
+// This clock cannot be stopped.
+// All methods are thread-safe.
+func Scaled(start time.Time, scale float64) Clock {
+	if scale <= 0 {
+		panic("clock: non-positive scale")
+	}
+	return &scaledClock{start: start, realStart: time.Now(), scale: scale}
+}
+
+type scaledClock struct {
+	start     time.Time
+	realStart time.Time
+	scale     float64
+}
+
+func (c *scaledClock) Now() time.Time {
+	return c.fromReal(time.Now())
+}
+
+func (c *scaledClock) At(t time.Time) <-chan time.Time {
+	return c.After(t.Sub(c.Now()))
+}
+
+func (c *scaledClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	time.AfterFunc(c.toReal(d), func() {
+		ch <- c.Now()
+	})
+	return ch
+}
+
+func (c *scaledClock) Every(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for Every")
+	}
+	t := &scaledTicker{
+		ticker: time.NewTicker(max(c.toReal(d), 1)),
+		ch:     make(chan time.Time, 1),
+		stop:   make(chan struct{}),
+	}
+	go func() {
+		for {
+			select {
+			case <-t.stop:
+				return
+			case now := <-t.ticker.C:
+				select {
+				case t.ch <- c.fromReal(now):
+				default: // drop ticks for slow receivers, like time.Ticker
+				}
+			}
+		}
+	}()
+	return t
+}
+
+func (c *scaledClock) fromReal(t time.Time) time.Time {
+	return c.start.Add(time.Duration(float64(t.Sub(c.realStart)) * c.scale))
+}
+
+func (c *scaledClock) toReal(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.scale)
+}
+
+type scaledTicker struct {
+	ticker   *time.Ticker
+	ch       chan time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (t *scaledTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *scaledTicker) Stop() {
+	t.stopOnce.Do(func() {
+		t.ticker.Stop()
+		close(t.stop)
+	})
+}
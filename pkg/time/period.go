@@ -41,6 +41,21 @@ func PeriodsIntersect(p1, p2 *time.Period) bool {
 		p2lower.CompareTo(p1upper) < 0
 }
 
+// PeriodContains returns true if t is enclosed by p.
+// A nil p is treated like AllTime.
+//
+// For example
+//  * `[2, 4)` contains 2 and 3, but not 4
+//  * `[-, 4)` contains everything before 4
+func PeriodContains(p *time.Period, t *timestamppb.Timestamp) bool {
+	if p == nil {
+		return true
+	}
+	lower, upper := cutPeriod(p)
+	return lower.CompareTo(cutBelow(t)) <= 0 &&
+		upper.CompareTo(cutBelow(t)) > 0
+}
+
 func AllTime() *time.Period {
 	return &time.Period{}
 }
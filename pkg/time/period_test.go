@@ -113,3 +113,31 @@ func TestPeriodsIntersect(t *testing.T) {
 		}
 	}
 }
+
+func TestPeriodContains(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *time.Period
+		t    int64
+		want bool
+	}{
+		{name: "nil", p: nil, t: 1, want: true},
+		{name: "all time", p: AllTime(), t: 1, want: true},
+		{name: "[1,3) 0", p: between(1, 3), t: 0, want: false},
+		{name: "[1,3) 1", p: between(1, 3), t: 1, want: true},
+		{name: "[1,3) 2", p: between(1, 3), t: 2, want: true},
+		{name: "[1,3) 3", p: between(1, 3), t: 3, want: false},
+		{name: "[-,3) 0", p: before(3), t: 0, want: true},
+		{name: "[-,3) 3", p: before(3), t: 3, want: false},
+		{name: "[1,-) 0", p: onOrAfter(1), t: 0, want: false},
+		{name: "[1,-) 1", p: onOrAfter(1), t: 1, want: true},
+		{name: "[1,-) 100", p: onOrAfter(1), t: 100, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PeriodContains(tt.p, ts(tt.t)); got != tt.want {
+				t.Errorf("PeriodContains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,6 +20,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"accessAttempt": m.accessAttempt,
+	}
+}
+
 func (m *Model) GetLastAccessAttempt(opts ...resource.ReadOption) (*traits.AccessAttempt, error) {
 	v := m.accessAttempt.Get(opts...)
 	return v.(*traits.AccessAttempt), nil
@@ -32,7 +32,8 @@ func (m *ModelServer) GetLastAccessAttempt(ctx context.Context, request *traits.
 }
 
 func (m *ModelServer) PullAccessAttempts(request *traits.PullAccessAttemptsRequest, server traits.AccessApi_PullAccessAttemptsServer) error {
-	for change := range m.model.PullAccessAttempts(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.GetUpdatesOnly())) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullAccessAttempts(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.GetUpdatesOnly()), rejected) {
 		msg := &traits.PullAccessAttemptsResponse{Changes: []*traits.PullAccessAttemptsResponse_Change{{
 			Name:          request.Name,
 			ChangeTime:    timestamppb.New(change.ChangeTime),
@@ -42,5 +43,5 @@ func (m *ModelServer) PullAccessAttempts(request *traits.PullAccessAttemptsReque
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.AirQualitySensorApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewAirQualitySensorApiClient(conn)
+		}),
+	}
+}
+
 // WithAirQualitySensorApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithAirQualitySensorApiClientFactory(f func(name string) (traits.AirQualitySensorApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.AirQualitySensorApiClient)
 	return ok
 }
 
+// AddAirQualitySensorApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.AirQualitySensorApiClient, for example a router.Group.
 func (r *ApiRouter) AddAirQualitySensorApiClient(name string, client traits.AirQualitySensorApiClient) traits.AirQualitySensorApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirQualitySensorApiClient)
+	res, _ := r.Add(name, client).(traits.AirQualitySensorApiClient)
+	return res
 }
 
+// RemoveAirQualitySensorApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.AirQualitySensorApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveAirQualitySensorApiClient(name string) traits.AirQualitySensorApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirQualitySensorApiClient)
+	res, _ := r.Remove(name).(traits.AirQualitySensorApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetAirQualitySensorApiClient(name string) (traits.AirQualitySensorApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetAirQualitySensorApiClient(name string) (traits.AirQuality
 	return res.(traits.AirQualitySensorApiClient), nil
 }
 
-func (r *ApiRouter) GetAirQuality(ctx context.Context, request *traits.GetAirQualityRequest) (*traits.AirQuality, error) {
-	child, err := r.GetAirQualitySensorApiClient(request.Name)
+// ResolveAirQualitySensorApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveAirQualitySensorApiClient(name string) (traits.AirQualitySensorApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetAirQuality(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.AirQualitySensorApiClient), target, nil
 }
 
-func (r *ApiRouter) PullAirQuality(request *traits.PullAirQualityRequest, server traits.AirQualitySensorApi_PullAirQualityServer) error {
-	child, err := r.GetAirQualitySensorApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetAirQuality(ctx context.Context, request *traits.GetAirQualityRequest) (*traits.AirQuality, error) {
+	var res *traits.AirQuality
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetAirQualityRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.AirQualitySensorApiClient).GetAirQuality(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullAirQuality(request *traits.PullAirQualityRequest, server traits.AirQualitySensorApi_PullAirQualityServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullAirQuality(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.AirQualitySensorApi_PullAirQualityClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullAirQualityRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.AirQualitySensorApiClient).PullAirQuality(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
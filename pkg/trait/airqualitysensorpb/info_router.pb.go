@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.AirQualitySensorInfoServer that allows routing named requests to specific traits.AirQualitySensorInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.AirQualitySensorInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewAirQualitySensorInfoClient(conn)
+		}),
+	}
+}
+
 // WithAirQualitySensorInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithAirQualitySensorInfoClientFactory(f func(name string) (traits.AirQualitySensorInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.AirQualitySensorInfoClient)
 	return ok
 }
 
+// AddAirQualitySensorInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.AirQualitySensorInfoClient, for example a router.Group.
 func (r *InfoRouter) AddAirQualitySensorInfoClient(name string, client traits.AirQualitySensorInfoClient) traits.AirQualitySensorInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirQualitySensorInfoClient)
+	res, _ := r.Add(name, client).(traits.AirQualitySensorInfoClient)
+	return res
 }
 
+// RemoveAirQualitySensorInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.AirQualitySensorInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveAirQualitySensorInfoClient(name string) traits.AirQualitySensorInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirQualitySensorInfoClient)
+	res, _ := r.Remove(name).(traits.AirQualitySensorInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetAirQualitySensorInfoClient(name string) (traits.AirQualitySensorInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetAirQualitySensorInfoClient(name string) (traits.AirQuali
 	return res.(traits.AirQualitySensorInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeAirQuality(ctx context.Context, request *traits.DescribeAirQualityRequest) (*traits.AirQualitySupport, error) {
-	child, err := r.GetAirQualitySensorInfoClient(request.Name)
+// ResolveAirQualitySensorInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveAirQualitySensorInfoClient(name string) (traits.AirQualitySensorInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.AirQualitySensorInfoClient), target, nil
+}
 
-	return child.DescribeAirQuality(ctx, request)
+func (r *InfoRouter) DescribeAirQuality(ctx context.Context, request *traits.DescribeAirQualityRequest) (*traits.AirQualitySupport, error) {
+	var res *traits.AirQualitySupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeAirQualityRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.AirQualitySensorInfoClient).DescribeAirQuality(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -19,6 +19,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"airQuality": m.airQuality,
+	}
+}
+
 func (m *Model) UpdateAirQuality(airQuality *traits.AirQuality, opts ...resource.WriteOption) (*traits.AirQuality, error) {
 	res, err := m.airQuality.Set(airQuality, opts...)
 	if err != nil {
@@ -36,7 +36,8 @@ func (s *ModelServer) GetAirQuality(ctx context.Context, req *traits.GetAirQuali
 }
 
 func (s *ModelServer) PullAirQuality(request *traits.PullAirQualityRequest, server traits.AirQualitySensorApi_PullAirQualityServer) error {
-	for update := range s.model.PullAirQuality(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for update := range s.model.PullAirQuality(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullAirQualityResponse_Change{
 			Name:       request.Name,
 			ChangeTime: timestamppb.New(update.ChangeTime),
@@ -50,6 +51,9 @@ func (s *ModelServer) PullAirQuality(request *traits.PullAirQualityRequest, serv
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 
 	return server.Context().Err()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.AirTemperatureApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewAirTemperatureApiClient(conn)
+		}),
+	}
+}
+
 // WithAirTemperatureApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithAirTemperatureApiClientFactory(f func(name string) (traits.AirTemperatureApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.AirTemperatureApiClient)
 	return ok
 }
 
+// AddAirTemperatureApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.AirTemperatureApiClient, for example a router.Group.
 func (r *ApiRouter) AddAirTemperatureApiClient(name string, client traits.AirTemperatureApiClient) traits.AirTemperatureApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirTemperatureApiClient)
+	res, _ := r.Add(name, client).(traits.AirTemperatureApiClient)
+	return res
 }
 
+// RemoveAirTemperatureApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.AirTemperatureApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveAirTemperatureApiClient(name string) traits.AirTemperatureApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirTemperatureApiClient)
+	res, _ := r.Remove(name).(traits.AirTemperatureApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetAirTemperatureApiClient(name string) (traits.AirTemperatureApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetAirTemperatureApiClient(name string) (traits.AirTemperatu
 	return res.(traits.AirTemperatureApiClient), nil
 }
 
-func (r *ApiRouter) GetAirTemperature(ctx context.Context, request *traits.GetAirTemperatureRequest) (*traits.AirTemperature, error) {
-	child, err := r.GetAirTemperatureApiClient(request.Name)
+// ResolveAirTemperatureApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveAirTemperatureApiClient(name string) (traits.AirTemperatureApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.AirTemperatureApiClient), target, nil
+}
 
-	return child.GetAirTemperature(ctx, request)
+func (r *ApiRouter) GetAirTemperature(ctx context.Context, request *traits.GetAirTemperatureRequest) (*traits.AirTemperature, error) {
+	var res *traits.AirTemperature
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetAirTemperatureRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.AirTemperatureApiClient).GetAirTemperature(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateAirTemperature(ctx context.Context, request *traits.UpdateAirTemperatureRequest) (*traits.AirTemperature, error) {
-	child, err := r.GetAirTemperatureApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateAirTemperature(ctx, request)
+	var res *traits.AirTemperature
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateAirTemperatureRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.AirTemperatureApiClient).UpdateAirTemperature(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullAirTemperature(request *traits.PullAirTemperatureRequest, server traits.AirTemperatureApi_PullAirTemperatureServer) error {
-	child, err := r.GetAirTemperatureApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullAirTemperature(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.AirTemperatureApi_PullAirTemperatureClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullAirTemperatureRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.AirTemperatureApiClient).PullAirTemperature(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
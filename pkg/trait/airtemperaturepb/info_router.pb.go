@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.AirTemperatureInfoServer that allows routing named requests to specific traits.AirTemperatureInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.AirTemperatureInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewAirTemperatureInfoClient(conn)
+		}),
+	}
+}
+
 // WithAirTemperatureInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithAirTemperatureInfoClientFactory(f func(name string) (traits.AirTemperatureInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.AirTemperatureInfoClient)
 	return ok
 }
 
+// AddAirTemperatureInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.AirTemperatureInfoClient, for example a router.Group.
 func (r *InfoRouter) AddAirTemperatureInfoClient(name string, client traits.AirTemperatureInfoClient) traits.AirTemperatureInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirTemperatureInfoClient)
+	res, _ := r.Add(name, client).(traits.AirTemperatureInfoClient)
+	return res
 }
 
+// RemoveAirTemperatureInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.AirTemperatureInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveAirTemperatureInfoClient(name string) traits.AirTemperatureInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.AirTemperatureInfoClient)
+	res, _ := r.Remove(name).(traits.AirTemperatureInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetAirTemperatureInfoClient(name string) (traits.AirTemperatureInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetAirTemperatureInfoClient(name string) (traits.AirTempera
 	return res.(traits.AirTemperatureInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeAirTemperature(ctx context.Context, request *traits.DescribeAirTemperatureRequest) (*traits.AirTemperatureSupport, error) {
-	child, err := r.GetAirTemperatureInfoClient(request.Name)
+// ResolveAirTemperatureInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveAirTemperatureInfoClient(name string) (traits.AirTemperatureInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.AirTemperatureInfoClient), target, nil
+}
 
-	return child.DescribeAirTemperature(ctx, request)
+func (r *InfoRouter) DescribeAirTemperature(ctx context.Context, request *traits.DescribeAirTemperatureRequest) (*traits.AirTemperatureSupport, error) {
+	var res *traits.AirTemperatureSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeAirTemperatureRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.AirTemperatureInfoClient).DescribeAirTemperature(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -54,7 +54,8 @@ func (t *MemoryDevice) UpdateAirTemperature(_ context.Context, request *traits.U
 }
 
 func (t *MemoryDevice) PullAirTemperature(request *traits.PullAirTemperatureRequest, server traits.AirTemperatureApi_PullAirTemperatureServer) error {
-	for event := range t.airTemperature.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for event := range t.airTemperature.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullAirTemperatureResponse_Change{
 			Name:           request.Name,
 			AirTemperature: event.Value.(*traits.AirTemperature),
@@ -67,5 +68,8 @@ func (t *MemoryDevice) PullAirTemperature(request *traits.PullAirTemperatureRequ
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 	return server.Context().Err()
 }
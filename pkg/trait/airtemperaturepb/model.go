@@ -19,6 +19,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"airTemperature": m.airTemperature,
+	}
+}
+
 func (m *Model) UpdateAirTemperature(airTemperature *traits.AirTemperature, opts ...resource.WriteOption) (*traits.AirTemperature, error) {
 	res, err := m.airTemperature.Set(airTemperature, opts...)
 	if err != nil {
@@ -40,7 +40,8 @@ func (s *ModelServer) UpdateAirTemperature(_ context.Context, request *traits.Up
 }
 
 func (s *ModelServer) PullAirTemperature(request *traits.PullAirTemperatureRequest, server traits.AirTemperatureApi_PullAirTemperatureServer) error {
-	for update := range s.model.PullAirTemperature(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for update := range s.model.PullAirTemperature(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullAirTemperatureResponse_Change{
 			Name:           request.Name,
 			ChangeTime:     timestamppb.New(update.ChangeTime),
@@ -54,6 +55,9 @@ func (s *ModelServer) PullAirTemperature(request *traits.PullAirTemperatureReque
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 
 	return server.Context().Err()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.BookingApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewBookingApiClient(conn)
+		}),
+	}
+}
+
 // WithBookingApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithBookingApiClientFactory(f func(name string) (traits.BookingApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.BookingApiClient)
 	return ok
 }
 
+// AddBookingApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.BookingApiClient, for example a router.Group.
 func (r *ApiRouter) AddBookingApiClient(name string, client traits.BookingApiClient) traits.BookingApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.BookingApiClient)
+	res, _ := r.Add(name, client).(traits.BookingApiClient)
+	return res
 }
 
+// RemoveBookingApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.BookingApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveBookingApiClient(name string) traits.BookingApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.BookingApiClient)
+	res, _ := r.Remove(name).(traits.BookingApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetBookingApiClient(name string) (traits.BookingApiClient, error) {
@@ -79,70 +92,129 @@ func (r *ApiRouter) GetBookingApiClient(name string) (traits.BookingApiClient, e
 	return res.(traits.BookingApiClient), nil
 }
 
-func (r *ApiRouter) ListBookings(ctx context.Context, request *traits.ListBookingsRequest) (*traits.ListBookingsResponse, error) {
-	child, err := r.GetBookingApiClient(request.Name)
+// ResolveBookingApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveBookingApiClient(name string) (traits.BookingApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.BookingApiClient), target, nil
+}
 
-	return child.ListBookings(ctx, request)
+func (r *ApiRouter) ListBookings(ctx context.Context, request *traits.ListBookingsRequest) (*traits.ListBookingsResponse, error) {
+	var res *traits.ListBookingsResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListBookingsRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.BookingApiClient).ListBookings(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) CheckInBooking(ctx context.Context, request *traits.CheckInBookingRequest) (*traits.CheckInBookingResponse, error) {
-	child, err := r.GetBookingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.CheckInBooking(ctx, request)
+	var res *traits.CheckInBookingResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.CheckInBookingRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.BookingApiClient).CheckInBooking(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) CheckOutBooking(ctx context.Context, request *traits.CheckOutBookingRequest) (*traits.CheckOutBookingResponse, error) {
-	child, err := r.GetBookingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.CheckOutBooking(ctx, request)
+	var res *traits.CheckOutBookingResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.CheckOutBookingRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.BookingApiClient).CheckOutBooking(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) CreateBooking(ctx context.Context, request *traits.CreateBookingRequest) (*traits.CreateBookingResponse, error) {
-	child, err := r.GetBookingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.CreateBooking(ctx, request)
+	var res *traits.CreateBookingResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.CreateBookingRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.BookingApiClient).CreateBooking(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateBooking(ctx context.Context, request *traits.UpdateBookingRequest) (*traits.UpdateBookingResponse, error) {
-	child, err := r.GetBookingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateBooking(ctx, request)
+	var res *traits.UpdateBookingResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateBookingRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.BookingApiClient).UpdateBooking(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullBookings(request *traits.ListBookingsRequest, server traits.BookingApi_PullBookingsServer) error {
-	child, err := r.GetBookingApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullBookings(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.BookingApi_PullBookingsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListBookingsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.BookingApiClient).PullBookings(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.BookingInfoServer that allows routing named requests to specific traits.BookingInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.BookingInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewBookingInfoClient(conn)
+		}),
+	}
+}
+
 // WithBookingInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithBookingInfoClientFactory(f func(name string) (traits.BookingInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.BookingInfoClient)
 	return ok
 }
 
+// AddBookingInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.BookingInfoClient, for example a router.Group.
 func (r *InfoRouter) AddBookingInfoClient(name string, client traits.BookingInfoClient) traits.BookingInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.BookingInfoClient)
+	res, _ := r.Add(name, client).(traits.BookingInfoClient)
+	return res
 }
 
+// RemoveBookingInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.BookingInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveBookingInfoClient(name string) traits.BookingInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.BookingInfoClient)
+	res, _ := r.Remove(name).(traits.BookingInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetBookingInfoClient(name string) (traits.BookingInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetBookingInfoClient(name string) (traits.BookingInfoClient
 	return res.(traits.BookingInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeBooking(ctx context.Context, request *traits.DescribeBookingRequest) (*traits.BookingSupport, error) {
-	child, err := r.GetBookingInfoClient(request.Name)
+// ResolveBookingInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveBookingInfoClient(name string) (traits.BookingInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.BookingInfoClient), target, nil
+}
 
-	return child.DescribeBooking(ctx, request)
+func (r *InfoRouter) DescribeBooking(ctx context.Context, request *traits.DescribeBookingRequest) (*traits.BookingSupport, error) {
+	var res *traits.BookingSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeBookingRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.BookingInfoClient).DescribeBooking(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -25,6 +25,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"bookings": m.bookings,
+	}
+}
+
 func (m *Model) ListBookings(opts ...resource.ReadOption) []*traits.Booking {
 	msgs := m.bookings.List(opts...)
 	res := make([]*traits.Booking, len(msgs))
@@ -129,6 +129,8 @@ func (m *ModelServer) PullBookings(request *traits.ListBookingsRequest, server t
 		}))
 	}
 
+	rejected, pullErr := resource.RejectedErr()
+	opts = append(opts, rejected)
 	for change := range m.model.PullBookings(server.Context(), opts...) {
 		err := server.Send(&traits.PullBookingsResponse{Changes: []*traits.PullBookingsResponse_Change{
 			{
@@ -143,5 +145,5 @@ func (m *ModelServer) PullBookings(request *traits.ListBookingsRequest, server t
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
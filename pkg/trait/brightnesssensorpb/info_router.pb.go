@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.BrightnessSensorInfoServer that allows routing named requests to specific traits.BrightnessSensorInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.BrightnessSensorInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewBrightnessSensorInfoClient(conn)
+		}),
+	}
+}
+
 // WithBrightnessSensorInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithBrightnessSensorInfoClientFactory(f func(name string) (traits.BrightnessSensorInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.BrightnessSensorInfoClient)
 	return ok
 }
 
+// AddBrightnessSensorInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.BrightnessSensorInfoClient, for example a router.Group.
 func (r *InfoRouter) AddBrightnessSensorInfoClient(name string, client traits.BrightnessSensorInfoClient) traits.BrightnessSensorInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.BrightnessSensorInfoClient)
+	res, _ := r.Add(name, client).(traits.BrightnessSensorInfoClient)
+	return res
 }
 
+// RemoveBrightnessSensorInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.BrightnessSensorInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveBrightnessSensorInfoClient(name string) traits.BrightnessSensorInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.BrightnessSensorInfoClient)
+	res, _ := r.Remove(name).(traits.BrightnessSensorInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetBrightnessSensorInfoClient(name string) (traits.BrightnessSensorInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetBrightnessSensorInfoClient(name string) (traits.Brightne
 	return res.(traits.BrightnessSensorInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeAmbientBrightness(ctx context.Context, request *traits.DescribeAmbientBrightnessRequest) (*traits.AmbientBrightnessSupport, error) {
-	child, err := r.GetBrightnessSensorInfoClient(request.Name)
+// ResolveBrightnessSensorInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveBrightnessSensorInfoClient(name string) (traits.BrightnessSensorInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.BrightnessSensorInfoClient), target, nil
+}
 
-	return child.DescribeAmbientBrightness(ctx, request)
+func (r *InfoRouter) DescribeAmbientBrightness(ctx context.Context, request *traits.DescribeAmbientBrightnessRequest) (*traits.AmbientBrightnessSupport, error) {
+	var res *traits.AmbientBrightnessSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeAmbientBrightnessRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.BrightnessSensorInfoClient).DescribeAmbientBrightness(ctx, request)
+		return err
+	})
+	return res, err
 }
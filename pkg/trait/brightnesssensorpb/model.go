@@ -19,6 +19,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"ambientBrightness": m.ambientBrightness,
+	}
+}
+
 func (m *Model) GetAmbientBrightness(opts ...resource.ReadOption) (*traits.AmbientBrightness, error) {
 	res := m.ambientBrightness.Get(opts...)
 	return res.(*traits.AmbientBrightness), nil
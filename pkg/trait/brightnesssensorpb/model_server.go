@@ -37,7 +37,8 @@ func (s *ModelServer) GetAmbientBrightness(_ context.Context, request *traits.Ge
 }
 
 func (s *ModelServer) PullAmbientBrightness(request *traits.PullAmbientBrightnessRequest, server traits.BrightnessSensorApi_PullAmbientBrightnessServer) error {
-	for update := range s.model.PullAmbientBrightness(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for update := range s.model.PullAmbientBrightness(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullAmbientBrightnessResponse_Change{
 			Name:              request.Name,
 			ChangeTime:        timestamppb.New(update.ChangeTime),
@@ -51,6 +52,9 @@ func (s *ModelServer) PullAmbientBrightness(request *traits.PullAmbientBrightnes
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 
 	return server.Context().Err()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.ChannelApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewChannelApiClient(conn)
+		}),
+	}
+}
+
 // WithChannelApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithChannelApiClientFactory(f func(name string) (traits.ChannelApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ChannelApiClient)
 	return ok
 }
 
+// AddChannelApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ChannelApiClient, for example a router.Group.
 func (r *ApiRouter) AddChannelApiClient(name string, client traits.ChannelApiClient) traits.ChannelApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ChannelApiClient)
+	res, _ := r.Add(name, client).(traits.ChannelApiClient)
+	return res
 }
 
+// RemoveChannelApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ChannelApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveChannelApiClient(name string) traits.ChannelApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ChannelApiClient)
+	res, _ := r.Remove(name).(traits.ChannelApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetChannelApiClient(name string) (traits.ChannelApiClient, error) {
@@ -79,61 +92,114 @@ func (r *ApiRouter) GetChannelApiClient(name string) (traits.ChannelApiClient, e
 	return res.(traits.ChannelApiClient), nil
 }
 
-func (r *ApiRouter) GetChosenChannel(ctx context.Context, request *traits.GetChosenChannelRequest) (*traits.Channel, error) {
-	child, err := r.GetChannelApiClient(request.Name)
+// ResolveChannelApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveChannelApiClient(name string) (traits.ChannelApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ChannelApiClient), target, nil
+}
 
-	return child.GetChosenChannel(ctx, request)
+func (r *ApiRouter) GetChosenChannel(ctx context.Context, request *traits.GetChosenChannelRequest) (*traits.Channel, error) {
+	var res *traits.Channel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetChosenChannelRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ChannelApiClient).GetChosenChannel(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) ChooseChannel(ctx context.Context, request *traits.ChooseChannelRequest) (*traits.Channel, error) {
-	child, err := r.GetChannelApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ChooseChannel(ctx, request)
+	var res *traits.Channel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ChooseChannelRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ChannelApiClient).ChooseChannel(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) AdjustChannel(ctx context.Context, request *traits.AdjustChannelRequest) (*traits.Channel, error) {
-	child, err := r.GetChannelApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.AdjustChannel(ctx, request)
+	var res *traits.Channel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.AdjustChannelRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ChannelApiClient).AdjustChannel(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) ReturnChannel(ctx context.Context, request *traits.ReturnChannelRequest) (*traits.Channel, error) {
-	child, err := r.GetChannelApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ReturnChannel(ctx, request)
+	var res *traits.Channel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ReturnChannelRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ChannelApiClient).ReturnChannel(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullChosenChannel(request *traits.PullChosenChannelRequest, server traits.ChannelApi_PullChosenChannelServer) error {
-	child, err := r.GetChannelApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullChosenChannel(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.ChannelApi_PullChosenChannelClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullChosenChannelRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.ChannelApiClient).PullChosenChannel(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.ChannelInfoServer that allows routing named requests to specific traits.ChannelInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.ChannelInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewChannelInfoClient(conn)
+		}),
+	}
+}
+
 // WithChannelInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithChannelInfoClientFactory(f func(name string) (traits.ChannelInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ChannelInfoClient)
 	return ok
 }
 
+// AddChannelInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ChannelInfoClient, for example a router.Group.
 func (r *InfoRouter) AddChannelInfoClient(name string, client traits.ChannelInfoClient) traits.ChannelInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ChannelInfoClient)
+	res, _ := r.Add(name, client).(traits.ChannelInfoClient)
+	return res
 }
 
+// RemoveChannelInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ChannelInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveChannelInfoClient(name string) traits.ChannelInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ChannelInfoClient)
+	res, _ := r.Remove(name).(traits.ChannelInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetChannelInfoClient(name string) (traits.ChannelInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetChannelInfoClient(name string) (traits.ChannelInfoClient
 	return res.(traits.ChannelInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeChosenChannel(ctx context.Context, request *traits.DescribeChosenChannelRequest) (*traits.ChosenChannelSupport, error) {
-	child, err := r.GetChannelInfoClient(request.Name)
+// ResolveChannelInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveChannelInfoClient(name string) (traits.ChannelInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ChannelInfoClient), target, nil
+}
 
-	return child.DescribeChosenChannel(ctx, request)
+func (r *InfoRouter) DescribeChosenChannel(ctx context.Context, request *traits.DescribeChosenChannelRequest) (*traits.ChosenChannelSupport, error) {
+	var res *traits.ChosenChannelSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeChosenChannelRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ChannelInfoClient).DescribeChosenChannel(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.ColorApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewColorApiClient(conn)
+		}),
+	}
+}
+
 // WithColorApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithColorApiClientFactory(f func(name string) (traits.ColorApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ColorApiClient)
 	return ok
 }
 
+// AddColorApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ColorApiClient, for example a router.Group.
 func (r *ApiRouter) AddColorApiClient(name string, client traits.ColorApiClient) traits.ColorApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ColorApiClient)
+	res, _ := r.Add(name, client).(traits.ColorApiClient)
+	return res
 }
 
+// RemoveColorApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ColorApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveColorApiClient(name string) traits.ColorApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ColorApiClient)
+	res, _ := r.Remove(name).(traits.ColorApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetColorApiClient(name string) (traits.ColorApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetColorApiClient(name string) (traits.ColorApiClient, error
 	return res.(traits.ColorApiClient), nil
 }
 
-func (r *ApiRouter) GetColor(ctx context.Context, request *traits.GetColorRequest) (*traits.Color, error) {
-	child, err := r.GetColorApiClient(request.Name)
+// ResolveColorApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveColorApiClient(name string) (traits.ColorApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ColorApiClient), target, nil
+}
 
-	return child.GetColor(ctx, request)
+func (r *ApiRouter) GetColor(ctx context.Context, request *traits.GetColorRequest) (*traits.Color, error) {
+	var res *traits.Color
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetColorRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ColorApiClient).GetColor(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateColor(ctx context.Context, request *traits.UpdateColorRequest) (*traits.Color, error) {
-	child, err := r.GetColorApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateColor(ctx, request)
+	var res *traits.Color
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateColorRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ColorApiClient).UpdateColor(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullColor(request *traits.PullColorRequest, server traits.ColorApi_PullColorServer) error {
-	child, err := r.GetColorApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullColor(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.ColorApi_PullColorClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullColorRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.ColorApiClient).PullColor(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.ColorInfoServer that allows routing named requests to specific traits.ColorInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.ColorInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewColorInfoClient(conn)
+		}),
+	}
+}
+
 // WithColorInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithColorInfoClientFactory(f func(name string) (traits.ColorInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ColorInfoClient)
 	return ok
 }
 
+// AddColorInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ColorInfoClient, for example a router.Group.
 func (r *InfoRouter) AddColorInfoClient(name string, client traits.ColorInfoClient) traits.ColorInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ColorInfoClient)
+	res, _ := r.Add(name, client).(traits.ColorInfoClient)
+	return res
 }
 
+// RemoveColorInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ColorInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveColorInfoClient(name string) traits.ColorInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ColorInfoClient)
+	res, _ := r.Remove(name).(traits.ColorInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetColorInfoClient(name string) (traits.ColorInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetColorInfoClient(name string) (traits.ColorInfoClient, er
 	return res.(traits.ColorInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeColor(ctx context.Context, request *traits.DescribeColorRequest) (*traits.ColorSupport, error) {
-	child, err := r.GetColorInfoClient(request.Name)
+// ResolveColorInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveColorInfoClient(name string) (traits.ColorInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ColorInfoClient), target, nil
+}
 
-	return child.DescribeColor(ctx, request)
+func (r *InfoRouter) DescribeColor(ctx context.Context, request *traits.DescribeColorRequest) (*traits.ColorSupport, error) {
+	var res *traits.ColorSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeColorRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ColorInfoClient).DescribeColor(ctx, request)
+		return err
+	})
+	return res, err
 }
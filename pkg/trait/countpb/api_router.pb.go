@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.CountApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewCountApiClient(conn)
+		}),
+	}
+}
+
 // WithCountApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithCountApiClientFactory(f func(name string) (traits.CountApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.CountApiClient)
 	return ok
 }
 
+// AddCountApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.CountApiClient, for example a router.Group.
 func (r *ApiRouter) AddCountApiClient(name string, client traits.CountApiClient) traits.CountApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.CountApiClient)
+	res, _ := r.Add(name, client).(traits.CountApiClient)
+	return res
 }
 
+// RemoveCountApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.CountApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveCountApiClient(name string) traits.CountApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.CountApiClient)
+	res, _ := r.Remove(name).(traits.CountApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetCountApiClient(name string) (traits.CountApiClient, error) {
@@ -79,52 +92,99 @@ func (r *ApiRouter) GetCountApiClient(name string) (traits.CountApiClient, error
 	return res.(traits.CountApiClient), nil
 }
 
-func (r *ApiRouter) GetCount(ctx context.Context, request *traits.GetCountRequest) (*traits.Count, error) {
-	child, err := r.GetCountApiClient(request.Name)
+// ResolveCountApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveCountApiClient(name string) (traits.CountApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.CountApiClient), target, nil
+}
 
-	return child.GetCount(ctx, request)
+func (r *ApiRouter) GetCount(ctx context.Context, request *traits.GetCountRequest) (*traits.Count, error) {
+	var res *traits.Count
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetCountRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.CountApiClient).GetCount(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) ResetCount(ctx context.Context, request *traits.ResetCountRequest) (*traits.Count, error) {
-	child, err := r.GetCountApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ResetCount(ctx, request)
+	var res *traits.Count
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ResetCountRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.CountApiClient).ResetCount(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateCount(ctx context.Context, request *traits.UpdateCountRequest) (*traits.Count, error) {
-	child, err := r.GetCountApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateCount(ctx, request)
+	var res *traits.Count
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateCountRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.CountApiClient).UpdateCount(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullCounts(request *traits.PullCountsRequest, server traits.CountApi_PullCountsServer) error {
-	child, err := r.GetCountApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullCounts(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.CountApi_PullCountsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullCountsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.CountApiClient).PullCounts(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.CountInfoServer that allows routing named requests to specific traits.CountInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.CountInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewCountInfoClient(conn)
+		}),
+	}
+}
+
 // WithCountInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithCountInfoClientFactory(f func(name string) (traits.CountInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.CountInfoClient)
 	return ok
 }
 
+// AddCountInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.CountInfoClient, for example a router.Group.
 func (r *InfoRouter) AddCountInfoClient(name string, client traits.CountInfoClient) traits.CountInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.CountInfoClient)
+	res, _ := r.Add(name, client).(traits.CountInfoClient)
+	return res
 }
 
+// RemoveCountInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.CountInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveCountInfoClient(name string) traits.CountInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.CountInfoClient)
+	res, _ := r.Remove(name).(traits.CountInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetCountInfoClient(name string) (traits.CountInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetCountInfoClient(name string) (traits.CountInfoClient, er
 	return res.(traits.CountInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeCount(ctx context.Context, request *traits.DescribeCountRequest) (*traits.CountSupport, error) {
-	child, err := r.GetCountInfoClient(request.Name)
+// ResolveCountInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveCountInfoClient(name string) (traits.CountInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.CountInfoClient), target, nil
+}
 
-	return child.DescribeCount(ctx, request)
+func (r *InfoRouter) DescribeCount(ctx context.Context, request *traits.DescribeCountRequest) (*traits.CountSupport, error) {
+	var res *traits.CountSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeCountRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.CountInfoClient).DescribeCount(ctx, request)
+		return err
+	})
+	return res, err
 }
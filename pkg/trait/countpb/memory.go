@@ -60,7 +60,8 @@ func (t *MemoryDevice) UpdateCount(_ context.Context, request *traits.UpdateCoun
 }
 
 func (t *MemoryDevice) PullCounts(request *traits.PullCountsRequest, server traits.CountApi_PullCountsServer) error {
-	for event := range t.count.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for event := range t.count.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullCountsResponse_Change{
 			Name:  request.Name,
 			Count: event.Value.(*traits.Count),
@@ -72,5 +73,8 @@ func (t *MemoryDevice) PullCounts(request *traits.PullCountsRequest, server trai
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 	return server.Context().Err()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.ElectricApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewElectricApiClient(conn)
+		}),
+	}
+}
+
 // WithElectricApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithElectricApiClientFactory(f func(name string) (traits.ElectricApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ElectricApiClient)
 	return ok
 }
 
+// AddElectricApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ElectricApiClient, for example a router.Group.
 func (r *ApiRouter) AddElectricApiClient(name string, client traits.ElectricApiClient) traits.ElectricApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ElectricApiClient)
+	res, _ := r.Add(name, client).(traits.ElectricApiClient)
+	return res
 }
 
+// RemoveElectricApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ElectricApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveElectricApiClient(name string) traits.ElectricApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ElectricApiClient)
+	res, _ := r.Remove(name).(traits.ElectricApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetElectricApiClient(name string) (traits.ElectricApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetElectricApiClient(name string) (traits.ElectricApiClient,
 	return res.(traits.ElectricApiClient), nil
 }
 
-func (r *ApiRouter) GetDemand(ctx context.Context, request *traits.GetDemandRequest) (*traits.ElectricDemand, error) {
-	child, err := r.GetElectricApiClient(request.Name)
+// ResolveElectricApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveElectricApiClient(name string) (traits.ElectricApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetDemand(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.ElectricApiClient), target, nil
 }
 
-func (r *ApiRouter) PullDemand(request *traits.PullDemandRequest, server traits.ElectricApi_PullDemandServer) error {
-	child, err := r.GetElectricApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetDemand(ctx context.Context, request *traits.GetDemandRequest) (*traits.ElectricDemand, error) {
+	var res *traits.ElectricDemand
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetDemandRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ElectricApiClient).GetDemand(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullDemand(request *traits.PullDemandRequest, server traits.ElectricApi_PullDemandServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullDemand(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.ElectricApi_PullDemandClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullDemandRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.ElectricApiClient).PullDemand(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -148,51 +196,85 @@ func (r *ApiRouter) PullDemand(request *traits.PullDemandRequest, server traits.
 }
 
 func (r *ApiRouter) GetActiveMode(ctx context.Context, request *traits.GetActiveModeRequest) (*traits.ElectricMode, error) {
-	child, err := r.GetElectricApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.GetActiveMode(ctx, request)
+	var res *traits.ElectricMode
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetActiveModeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ElectricApiClient).GetActiveMode(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateActiveMode(ctx context.Context, request *traits.UpdateActiveModeRequest) (*traits.ElectricMode, error) {
-	child, err := r.GetElectricApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateActiveMode(ctx, request)
+	var res *traits.ElectricMode
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateActiveModeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ElectricApiClient).UpdateActiveMode(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) ClearActiveMode(ctx context.Context, request *traits.ClearActiveModeRequest) (*traits.ElectricMode, error) {
-	child, err := r.GetElectricApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ClearActiveMode(ctx, request)
+	var res *traits.ElectricMode
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ClearActiveModeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ElectricApiClient).ClearActiveMode(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullActiveMode(request *traits.PullActiveModeRequest, server traits.ElectricApi_PullActiveModeServer) error {
-	child, err := r.GetElectricApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullActiveMode(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.ElectricApi_PullActiveModeClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullActiveModeRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.ElectricApiClient).PullActiveMode(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -234,33 +316,55 @@ func (r *ApiRouter) PullActiveMode(request *traits.PullActiveModeRequest, server
 }
 
 func (r *ApiRouter) ListModes(ctx context.Context, request *traits.ListModesRequest) (*traits.ListModesResponse, error) {
-	child, err := r.GetElectricApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ListModes(ctx, request)
+	var res *traits.ListModesResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListModesRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ElectricApiClient).ListModes(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullModes(request *traits.PullModesRequest, server traits.ElectricApi_PullModesServer) error {
-	child, err := r.GetElectricApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullModes(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.ElectricApi_PullModesClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullModesRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.ElectricApiClient).PullModes(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -25,6 +25,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.ElectricInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewElectricInfoClient(conn)
+		}),
+	}
+}
+
 // WithElectricInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithElectricInfoClientFactory(f func(name string) (traits.ElectricInfoClient, error)) router.Option {
@@ -46,24 +56,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ElectricInfoClient)
 	return ok
 }
 
+// AddElectricInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ElectricInfoClient, for example a router.Group.
 func (r *InfoRouter) AddElectricInfoClient(name string, client traits.ElectricInfoClient) traits.ElectricInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ElectricInfoClient)
+	res, _ := r.Add(name, client).(traits.ElectricInfoClient)
+	return res
 }
 
+// RemoveElectricInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ElectricInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveElectricInfoClient(name string) traits.ElectricInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ElectricInfoClient)
+	res, _ := r.Remove(name).(traits.ElectricInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetElectricInfoClient(name string) (traits.ElectricInfoClient, error) {
@@ -76,3 +87,16 @@ func (r *InfoRouter) GetElectricInfoClient(name string) (traits.ElectricInfoClie
 	}
 	return res.(traits.ElectricInfoClient), nil
 }
+
+// ResolveElectricInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveElectricInfoClient(name string) (traits.ElectricInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
+	if err != nil {
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.ElectricInfoClient), target, nil
+}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -27,6 +28,16 @@ func NewMemorySettingsApiRouter(opts ...router.Option) *MemorySettingsApiRouter
 	}
 }
 
+// NewMemorySettingsApiRouterFromServiceRouter returns a MemorySettingsApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewMemorySettingsApiRouterFromServiceRouter(services *router.ServiceRouter) *MemorySettingsApiRouter {
+	return &MemorySettingsApiRouter{
+		Router: services.ForService(MemorySettingsApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return NewMemorySettingsApiClient(conn)
+		}),
+	}
+}
+
 // WithMemorySettingsApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMemorySettingsApiClientFactory(f func(name string) (MemorySettingsApiClient, error)) router.Option {
@@ -48,24 +59,25 @@ func (r *MemorySettingsApiRouter) Add(name string, client any) any {
 }
 
 func (r *MemorySettingsApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(MemorySettingsApiClient)
 	return ok
 }
 
+// AddMemorySettingsApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a MemorySettingsApiClient, for example a router.Group.
 func (r *MemorySettingsApiRouter) AddMemorySettingsApiClient(name string, client MemorySettingsApiClient) MemorySettingsApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(MemorySettingsApiClient)
+	res, _ := r.Add(name, client).(MemorySettingsApiClient)
+	return res
 }
 
+// RemoveMemorySettingsApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a MemorySettingsApiClient, for example a router.Group.
 func (r *MemorySettingsApiRouter) RemoveMemorySettingsApiClient(name string) MemorySettingsApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(MemorySettingsApiClient)
+	res, _ := r.Remove(name).(MemorySettingsApiClient)
+	return res
 }
 
 func (r *MemorySettingsApiRouter) GetMemorySettingsApiClient(name string) (MemorySettingsApiClient, error) {
@@ -79,38 +91,75 @@ func (r *MemorySettingsApiRouter) GetMemorySettingsApiClient(name string) (Memor
 	return res.(MemorySettingsApiClient), nil
 }
 
-func (r *MemorySettingsApiRouter) UpdateDemand(ctx context.Context, request *UpdateDemandRequest) (*traits.ElectricDemand, error) {
-	child, err := r.GetMemorySettingsApiClient(request.Name)
+// ResolveMemorySettingsApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *MemorySettingsApiRouter) ResolveMemorySettingsApiClient(name string) (MemorySettingsApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(MemorySettingsApiClient), target, nil
+}
 
-	return child.UpdateDemand(ctx, request)
+func (r *MemorySettingsApiRouter) UpdateDemand(ctx context.Context, request *UpdateDemandRequest) (*traits.ElectricDemand, error) {
+	var res *traits.ElectricDemand
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*UpdateDemandRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(MemorySettingsApiClient).UpdateDemand(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *MemorySettingsApiRouter) CreateMode(ctx context.Context, request *CreateModeRequest) (*traits.ElectricMode, error) {
-	child, err := r.GetMemorySettingsApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.CreateMode(ctx, request)
+	var res *traits.ElectricMode
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*CreateModeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(MemorySettingsApiClient).CreateMode(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *MemorySettingsApiRouter) UpdateMode(ctx context.Context, request *UpdateModeRequest) (*traits.ElectricMode, error) {
-	child, err := r.GetMemorySettingsApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateMode(ctx, request)
+	var res *traits.ElectricMode
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*UpdateModeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(MemorySettingsApiClient).UpdateMode(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *MemorySettingsApiRouter) DeleteMode(ctx context.Context, request *DeleteModeRequest) (*emptypb.Empty, error) {
-	child, err := r.GetMemorySettingsApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.DeleteMode(ctx, request)
+	var res *emptypb.Empty
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*DeleteModeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(MemorySettingsApiClient).DeleteMode(ctx, request)
+		return err
+	})
+	return res, err
 }
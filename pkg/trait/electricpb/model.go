@@ -3,7 +3,6 @@ package electricpb
 import (
 	"context"
 	"math/rand"
-	"sync"
 	"time"
 
 	"google.golang.org/grpc/codes"
@@ -30,13 +29,13 @@ var (
 //  1. At most one mode has normal = true.
 //  2. The active mode cannot be deleted.
 //  3. Only a mode that exists can be active (except when the Model is first created, when a dummy mode is active)
+//
+// Invariants are protected by performing related reads and writes in a resource.Transaction.
 type Model struct {
 	demand     *resource.Value // of *traits.ElectricDemand
 	activeMode *resource.Value // of *traits.ElectricMode
 	modes      *resource.Collection
 
-	// mu protects invariants
-	mu    sync.RWMutex
 	clock clock.Clock
 	Rng   *rand.Rand // for generating mode ids
 }
@@ -61,6 +60,15 @@ func NewModel(opts ...resource.Option) *Model {
 	return mem
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"demand":     m.demand,
+		"activeMode": m.activeMode,
+		"modes":      m.modes,
+	}
+}
+
 // Demand gets the demand stored in this Model.
 // The fields returned can be filtered by passing resource.WithReadMask.
 func (m *Model) Demand(opts ...resource.ReadOption) *traits.ElectricDemand {
@@ -136,33 +144,34 @@ func (m *Model) PullActiveMode(ctx context.Context, opts ...resource.ReadOption)
 // The mode.Id should exist in the known Modes of this model or an error will be returned.
 // The mode.StartTime will not be set for you.
 func (m *Model) SetActiveMode(mode *traits.ElectricMode) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if _, ok := m.findMode(mode.Id); !ok {
-		return ErrModeNotFound
-	}
+	return resource.Transact(func(tx *resource.Transaction) error {
+		if _, ok := m.findMode(tx, mode.Id); !ok {
+			return ErrModeNotFound
+		}
 
-	_, err := m.activeMode.Set(mode)
-	return err
+		_, err := tx.Set(m.activeMode, mode)
+		return err
+	}, m.activeMode, m.modes)
 }
 
 // ChangeActiveMode will switch the active mode to a previously-defined mode with the given ID.
 // Attempting to change to a mode ID that does not exist on this device will result in an error.
 // Updates the StartTime of the mode to the current time if the mode changes.
-func (m *Model) ChangeActiveMode(id string) (*traits.ElectricMode, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	return m.changeActiveMode(id)
+func (m *Model) ChangeActiveMode(id string) (mode *traits.ElectricMode, err error) {
+	err = resource.Transact(func(tx *resource.Transaction) error {
+		mode, err = m.changeActiveMode(tx, id)
+		return err
+	}, m.activeMode, m.modes)
+	return mode, err
 }
 
-func (m *Model) changeActiveMode(id string) (*traits.ElectricMode, error) {
-	mode, ok := m.findMode(id)
+func (m *Model) changeActiveMode(tx *resource.Transaction, id string) (*traits.ElectricMode, error) {
+	mode, ok := m.findMode(tx, id)
 	if !ok {
 		return nil, ErrModeNotFound
 	}
 
-	updated, err := m.activeMode.Set(mode, resource.InterceptAfter(func(old, new proto.Message) {
+	updated, err := tx.Set(m.activeMode, mode, resource.InterceptAfter(func(old, new proto.Message) {
 		oldMode := old.(*traits.ElectricMode)
 		newMode := new.(*traits.ElectricMode)
 		if oldMode.Id != newMode.Id {
@@ -180,30 +189,32 @@ func (m *Model) changeActiveMode(id string) (*traits.ElectricMode, error) {
 // mode.
 // If this device does not have a normal mode, ErrModeNotFound is returned.
 // Updates the StartTime of the mode to the current time if the mode changes.
-func (m *Model) ChangeToNormalMode() (*traits.ElectricMode, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	normal, ok := m.normalMode()
-	if !ok {
-		return nil, ErrModeNotFound
-	}
+func (m *Model) ChangeToNormalMode() (mode *traits.ElectricMode, err error) {
+	err = resource.Transact(func(tx *resource.Transaction) error {
+		normal, ok := m.normalMode(tx.ListItems(m.modes))
+		if !ok {
+			return ErrModeNotFound
+		}
 
-	return m.changeActiveMode(normal.Id)
+		mode, err = m.changeActiveMode(tx, normal.Id)
+		return err
+	}, m.activeMode, m.modes)
+	return mode, err
 }
 
 // FindMode will attempt to retrieve the mode with the given ID.
 // If the mode was found, it is returned with ok == true.
 // Otherwise, the returned mode is unspecified and ok == false.
 func (m *Model) FindMode(id string) (mode *traits.ElectricMode, ok bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	return m.findMode(id)
+	msg, ok := m.modes.Get(id)
+	if !ok {
+		return nil, false
+	}
+	return msg.(*traits.ElectricMode), true
 }
 
-func (m *Model) findMode(id string) (*traits.ElectricMode, bool) {
-	mode, ok := m.modes.Get(id)
+func (m *Model) findMode(tx *resource.Transaction, id string) (*traits.ElectricMode, bool) {
+	mode, ok := tx.GetItem(m.modes, id)
 	if !ok {
 		return nil, false
 	}
@@ -221,6 +232,23 @@ func (m *Model) Modes(opts ...resource.ReadOption) []*traits.ElectricMode {
 	return modes
 }
 
+// ModesPage returns a page of modes, see resource.Collection.ListPage.
+func (m *Model) ModesPage(pageSize int, pageToken string, opts ...resource.ReadOption) (*traits.ListModesResponse, error) {
+	page, err := m.modes.ListPage(pageSize, pageToken, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &traits.ListModesResponse{
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int32(page.TotalSize),
+		Modes:         make([]*traits.ElectricMode, len(page.Items)),
+	}
+	for i, msg := range page.Items {
+		res.Modes[i] = msg.(*traits.ElectricMode)
+	}
+	return res, nil
+}
+
 // CreateMode adds a new mode to the device.
 // The Id field on the mode must not be set, as the Id will be allocated by the device.
 // If mode has Normal == true, and the device already has a normal mode, then ErrNormalModeExists will result.
@@ -231,9 +259,6 @@ func (m *Model) CreateMode(mode *traits.ElectricMode) (*traits.ElectricMode, err
 		panic("ID field is set")
 	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	return m.createOrAddMode(mode)
 }
 
@@ -246,9 +271,6 @@ func (m *Model) AddMode(mode *traits.ElectricMode) error {
 		panic("ID field is not set")
 	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	_, err := m.createOrAddMode(mode)
 	return err
 }
@@ -257,21 +279,27 @@ func (m *Model) createOrAddMode(mode *traits.ElectricMode) (*traits.ElectricMode
 	// clone mode to avoid mutating the caller's copy
 	mode = proto.Clone(mode).(*traits.ElectricMode)
 
-	// if this mode is normal, check that there isn't another normal mode
-	if mode.Normal {
-		_, ok := m.normalMode()
-		if ok {
-			return nil, ErrNormalModeExists
+	err := resource.Transact(func(tx *resource.Transaction) error {
+		// if this mode is normal, check that there isn't another normal mode
+		if mode.Normal {
+			_, ok := m.normalMode(tx.ListItems(m.modes))
+			if ok {
+				return ErrNormalModeExists
+			}
 		}
-	}
 
-	msg, err := m.modes.Add(mode.Id, mode, resource.WithGenIDIfAbsent(), resource.WithIDCallback(func(id string) {
-		mode.Id = id
-	}))
-	if msg != nil {
-		mode = msg.(*traits.ElectricMode)
+		msg, err := tx.AddItem(m.modes, mode.Id, mode, resource.WithGenIDIfAbsent(), resource.WithIDCallback(func(id string) {
+			mode.Id = id
+		}))
+		if msg != nil {
+			mode = msg.(*traits.ElectricMode)
+		}
+		return err
+	}, m.modes)
+	if err != nil {
+		return nil, err
 	}
-	return mode, err
+	return mode, nil
 }
 
 // DeleteMode will remove the mode with the given Id from the device.
@@ -279,38 +307,28 @@ func (m *Model) createOrAddMode(mode *traits.ElectricMode) (*traits.ElectricMode
 // If the mode specified is the active mode, then ErrDeleteActiveMode is returned and the mode is not deleted.
 // Otherwise, the operation succeeded and nil is returned.
 func (m *Model) DeleteMode(id string, opts ...resource.WriteOption) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.deleteMode(id, opts...)
-}
-
-func (m *Model) deleteMode(id string, opts ...resource.WriteOption) error {
-	active := m.activeMode.Get().(*traits.ElectricMode)
-	if id == active.Id {
-		return ErrDeleteActiveMode
-	}
+	return resource.Transact(func(tx *resource.Transaction) error {
+		active := tx.Get(m.activeMode).(*traits.ElectricMode)
+		if id == active.Id {
+			return ErrDeleteActiveMode
+		}
 
-	msg, err := m.modes.Delete(id, opts...)
-	if err != nil {
-		return err
-	}
-	if msg == nil {
-		return ErrModeNotFound
-	}
+		msg, err := tx.DeleteItem(m.modes, id, opts...)
+		if err != nil {
+			return err
+		}
+		if msg == nil {
+			return ErrModeNotFound
+		}
 
-	return nil
+		return nil
+	}, m.activeMode, m.modes)
 }
 
 // UpdateMode will modify one of the modes stored in this device.
 // The mode to be modified is specified by mode.Id, which must be set.
 // Fields to be modified can be selected using mask - to modify all fields, pass a nil mask.
 func (m *Model) UpdateMode(mode *traits.ElectricMode, opts ...resource.WriteOption) (*traits.ElectricMode, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.updateMode(mode, opts...)
-}
-
-func (m *Model) updateMode(mode *traits.ElectricMode, opts ...resource.WriteOption) (*traits.ElectricMode, error) {
 	msg, err := m.modes.Update(mode.Id, mode, opts...)
 	if err != nil {
 		return nil, err
@@ -355,14 +373,10 @@ func (m *Model) PullModes(ctx context.Context, opts ...resource.ReadOption) <-ch
 // NormalMode returns the mode which has Normal == true. A device can have at most 1 such mode.
 // If there is no normal mode on this device, then (nil, false) is returned.
 func (m *Model) NormalMode() (*traits.ElectricMode, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.normalMode()
+	return m.normalMode(m.modes.List())
 }
 
-func (m *Model) normalMode() (*traits.ElectricMode, bool) {
-	modes := m.modes.List()
-
+func (m *Model) normalMode(modes []proto.Message) (*traits.ElectricMode, bool) {
 	for _, mode := range modes {
 		mode := mode.(*traits.ElectricMode)
 		if mode.Normal {
@@ -2,7 +2,6 @@ package electricpb
 
 import (
 	"context"
-	"sort"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -10,7 +9,6 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/smart-core-os/sc-api/go/traits"
-	"github.com/smart-core-os/sc-api/go/types"
 
 	"github.com/smart-core-os/sc-golang/pkg/resource"
 )
@@ -43,7 +41,8 @@ func (s *ModelServer) GetDemand(_ context.Context, request *traits.GetDemandRequ
 }
 
 func (s *ModelServer) PullDemand(request *traits.PullDemandRequest, server traits.ElectricApi_PullDemandServer) error {
-	for update := range s.model.PullDemand(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for update := range s.model.PullDemand(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullDemandResponse_Change{
 			Name:       request.Name,
 			ChangeTime: timestamppb.New(update.ChangeTime),
@@ -57,6 +56,9 @@ func (s *ModelServer) PullDemand(request *traits.PullDemandRequest, server trait
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 	return server.Context().Err()
 }
 
@@ -80,7 +82,8 @@ func (s *ModelServer) ClearActiveMode(_ context.Context, _ *traits.ClearActiveMo
 }
 
 func (s *ModelServer) PullActiveMode(request *traits.PullActiveModeRequest, server traits.ElectricApi_PullActiveModeServer) error {
-	for event := range s.model.PullActiveMode(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for event := range s.model.PullActiveMode(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullActiveModeResponse_Change{
 			Name:       request.Name,
 			ActiveMode: event.ActiveMode,
@@ -93,51 +96,20 @@ func (s *ModelServer) PullActiveMode(request *traits.PullActiveModeRequest, serv
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 
 	return server.Context().Err()
 }
 
 func (s *ModelServer) ListModes(_ context.Context, request *traits.ListModesRequest) (*traits.ListModesResponse, error) {
-	pageToken := &types.PageToken{}
-	if err := decodePageToken(request.PageToken, pageToken); err != nil {
-		return nil, err
-	}
-
-	lastKey := pageToken.GetLastResourceName() // the key() of the last item we sent
-	pageSize := capPageSize(int(request.GetPageSize()))
-
-	sortedModes := s.model.Modes(resource.WithReadMask(request.ReadMask))
-	nextIndex := 0
-	if lastKey != "" {
-		nextIndex = sort.Search(len(sortedModes), func(i int) bool {
-			return sortedModes[i].Id > lastKey
-		})
-	}
-
-	result := &traits.ListModesResponse{
-		TotalSize: int32(len(sortedModes)),
-	}
-	upperBound := nextIndex + pageSize
-	if upperBound > len(sortedModes) {
-		upperBound = len(sortedModes)
-		pageToken = nil
-	} else {
-		pageToken.PageStart = &types.PageToken_LastResourceName{
-			LastResourceName: sortedModes[upperBound-1].Id,
-		}
-	}
-
-	var err error
-	result.NextPageToken, err = encodePageToken(pageToken)
-	if err != nil {
-		return nil, err
-	}
-	result.Modes = sortedModes[nextIndex:upperBound]
-	return result, nil
+	return s.model.ModesPage(int(request.GetPageSize()), request.GetPageToken(), resource.WithReadMask(request.ReadMask))
 }
 
 func (s *ModelServer) PullModes(request *traits.PullModesRequest, server traits.ElectricApi_PullModesServer) error {
-	for change := range s.model.PullModes(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range s.model.PullModes(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullModesResponse{Changes: []*traits.PullModesResponse_Change{
 			{
 				Name:       request.Name,
@@ -152,5 +124,8 @@ func (s *ModelServer) PullModes(request *traits.PullModesRequest, server traits.
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 	return server.Context().Err()
 }
@@ -0,0 +1,40 @@
+package electricpb
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-golang/pkg/resource"
+)
+
+func TestModel_snapshot(t *testing.T) {
+	src := NewModel()
+	mode, err := src.CreateMode(&traits.ElectricMode{Title: "Eco"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.ChangeActiveMode(mode.Id); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := resource.SnapshotModel(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(snapshot.Values) + len(snapshot.Collections); got != 3 {
+		t.Fatalf("want 3 resources in snapshot, got %d", got)
+	}
+
+	dst := NewModel()
+	if err := resource.RestoreModel(dst, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(src.Modes(), dst.Modes(), protocmp.Transform()); diff != "" {
+		t.Fatalf("modes (-want,+got)\n%s", diff)
+	}
+	if diff := cmp.Diff(src.ActiveMode(), dst.ActiveMode(), protocmp.Transform()); diff != "" {
+		t.Fatalf("active mode (-want,+got)\n%s", diff)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.EmergencyApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewEmergencyApiClient(conn)
+		}),
+	}
+}
+
 // WithEmergencyApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithEmergencyApiClientFactory(f func(name string) (traits.EmergencyApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.EmergencyApiClient)
 	return ok
 }
 
+// AddEmergencyApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.EmergencyApiClient, for example a router.Group.
 func (r *ApiRouter) AddEmergencyApiClient(name string, client traits.EmergencyApiClient) traits.EmergencyApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EmergencyApiClient)
+	res, _ := r.Add(name, client).(traits.EmergencyApiClient)
+	return res
 }
 
+// RemoveEmergencyApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.EmergencyApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveEmergencyApiClient(name string) traits.EmergencyApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EmergencyApiClient)
+	res, _ := r.Remove(name).(traits.EmergencyApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetEmergencyApiClient(name string) (traits.EmergencyApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetEmergencyApiClient(name string) (traits.EmergencyApiClien
 	return res.(traits.EmergencyApiClient), nil
 }
 
-func (r *ApiRouter) GetEmergency(ctx context.Context, request *traits.GetEmergencyRequest) (*traits.Emergency, error) {
-	child, err := r.GetEmergencyApiClient(request.Name)
+// ResolveEmergencyApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveEmergencyApiClient(name string) (traits.EmergencyApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.EmergencyApiClient), target, nil
+}
 
-	return child.GetEmergency(ctx, request)
+func (r *ApiRouter) GetEmergency(ctx context.Context, request *traits.GetEmergencyRequest) (*traits.Emergency, error) {
+	var res *traits.Emergency
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetEmergencyRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.EmergencyApiClient).GetEmergency(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateEmergency(ctx context.Context, request *traits.UpdateEmergencyRequest) (*traits.Emergency, error) {
-	child, err := r.GetEmergencyApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateEmergency(ctx, request)
+	var res *traits.Emergency
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateEmergencyRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.EmergencyApiClient).UpdateEmergency(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullEmergency(request *traits.PullEmergencyRequest, server traits.EmergencyApi_PullEmergencyServer) error {
-	child, err := r.GetEmergencyApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullEmergency(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.EmergencyApi_PullEmergencyClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullEmergencyRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.EmergencyApiClient).PullEmergency(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.EmergencyInfoServer that allows routing named requests to specific traits.EmergencyInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.EmergencyInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewEmergencyInfoClient(conn)
+		}),
+	}
+}
+
 // WithEmergencyInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithEmergencyInfoClientFactory(f func(name string) (traits.EmergencyInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.EmergencyInfoClient)
 	return ok
 }
 
+// AddEmergencyInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.EmergencyInfoClient, for example a router.Group.
 func (r *InfoRouter) AddEmergencyInfoClient(name string, client traits.EmergencyInfoClient) traits.EmergencyInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EmergencyInfoClient)
+	res, _ := r.Add(name, client).(traits.EmergencyInfoClient)
+	return res
 }
 
+// RemoveEmergencyInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.EmergencyInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveEmergencyInfoClient(name string) traits.EmergencyInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EmergencyInfoClient)
+	res, _ := r.Remove(name).(traits.EmergencyInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetEmergencyInfoClient(name string) (traits.EmergencyInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetEmergencyInfoClient(name string) (traits.EmergencyInfoCl
 	return res.(traits.EmergencyInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeEmergency(ctx context.Context, request *traits.DescribeEmergencyRequest) (*traits.EmergencySupport, error) {
-	child, err := r.GetEmergencyInfoClient(request.Name)
+// ResolveEmergencyInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveEmergencyInfoClient(name string) (traits.EmergencyInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.EmergencyInfoClient), target, nil
+}
 
-	return child.DescribeEmergency(ctx, request)
+func (r *InfoRouter) DescribeEmergency(ctx context.Context, request *traits.DescribeEmergencyRequest) (*traits.EmergencySupport, error) {
+	var res *traits.EmergencySupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeEmergencyRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.EmergencyInfoClient).DescribeEmergency(ctx, request)
+		return err
+	})
+	return res, err
 }
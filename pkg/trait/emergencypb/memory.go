@@ -51,7 +51,8 @@ func (t *MemoryDevice) UpdateEmergency(_ context.Context, request *traits.Update
 }
 
 func (t *MemoryDevice) PullEmergency(request *traits.PullEmergencyRequest, server traits.EmergencyApi_PullEmergencyServer) error {
-	for event := range t.state.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for event := range t.state.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullEmergencyResponse_Change{
 			Name:       request.Name,
 			Emergency:  event.Value.(*traits.Emergency),
@@ -64,6 +65,9 @@ func (t *MemoryDevice) PullEmergency(request *traits.PullEmergencyRequest, serve
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 
 	return server.Context().Err()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.EnergyStorageApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewEnergyStorageApiClient(conn)
+		}),
+	}
+}
+
 // WithEnergyStorageApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithEnergyStorageApiClientFactory(f func(name string) (traits.EnergyStorageApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.EnergyStorageApiClient)
 	return ok
 }
 
+// AddEnergyStorageApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.EnergyStorageApiClient, for example a router.Group.
 func (r *ApiRouter) AddEnergyStorageApiClient(name string, client traits.EnergyStorageApiClient) traits.EnergyStorageApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnergyStorageApiClient)
+	res, _ := r.Add(name, client).(traits.EnergyStorageApiClient)
+	return res
 }
 
+// RemoveEnergyStorageApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.EnergyStorageApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveEnergyStorageApiClient(name string) traits.EnergyStorageApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnergyStorageApiClient)
+	res, _ := r.Remove(name).(traits.EnergyStorageApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetEnergyStorageApiClient(name string) (traits.EnergyStorageApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetEnergyStorageApiClient(name string) (traits.EnergyStorage
 	return res.(traits.EnergyStorageApiClient), nil
 }
 
-func (r *ApiRouter) GetEnergyLevel(ctx context.Context, request *traits.GetEnergyLevelRequest) (*traits.EnergyLevel, error) {
-	child, err := r.GetEnergyStorageApiClient(request.Name)
+// ResolveEnergyStorageApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveEnergyStorageApiClient(name string) (traits.EnergyStorageApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetEnergyLevel(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.EnergyStorageApiClient), target, nil
 }
 
-func (r *ApiRouter) PullEnergyLevel(request *traits.PullEnergyLevelRequest, server traits.EnergyStorageApi_PullEnergyLevelServer) error {
-	child, err := r.GetEnergyStorageApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetEnergyLevel(ctx context.Context, request *traits.GetEnergyLevelRequest) (*traits.EnergyLevel, error) {
+	var res *traits.EnergyLevel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetEnergyLevelRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.EnergyStorageApiClient).GetEnergyLevel(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullEnergyLevel(request *traits.PullEnergyLevelRequest, server traits.EnergyStorageApi_PullEnergyLevelServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullEnergyLevel(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.EnergyStorageApi_PullEnergyLevelClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullEnergyLevelRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.EnergyStorageApiClient).PullEnergyLevel(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -148,10 +196,16 @@ func (r *ApiRouter) PullEnergyLevel(request *traits.PullEnergyLevelRequest, serv
 }
 
 func (r *ApiRouter) Charge(ctx context.Context, request *traits.ChargeRequest) (*traits.ChargeResponse, error) {
-	child, err := r.GetEnergyStorageApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.Charge(ctx, request)
+	var res *traits.ChargeResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ChargeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.EnergyStorageApiClient).Charge(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.EnergyStorageInfoServer that allows routing named requests to specific traits.EnergyStorageInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.EnergyStorageInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewEnergyStorageInfoClient(conn)
+		}),
+	}
+}
+
 // WithEnergyStorageInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithEnergyStorageInfoClientFactory(f func(name string) (traits.EnergyStorageInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.EnergyStorageInfoClient)
 	return ok
 }
 
+// AddEnergyStorageInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.EnergyStorageInfoClient, for example a router.Group.
 func (r *InfoRouter) AddEnergyStorageInfoClient(name string, client traits.EnergyStorageInfoClient) traits.EnergyStorageInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnergyStorageInfoClient)
+	res, _ := r.Add(name, client).(traits.EnergyStorageInfoClient)
+	return res
 }
 
+// RemoveEnergyStorageInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.EnergyStorageInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveEnergyStorageInfoClient(name string) traits.EnergyStorageInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnergyStorageInfoClient)
+	res, _ := r.Remove(name).(traits.EnergyStorageInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetEnergyStorageInfoClient(name string) (traits.EnergyStorageInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetEnergyStorageInfoClient(name string) (traits.EnergyStora
 	return res.(traits.EnergyStorageInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeEnergyLevel(ctx context.Context, request *traits.DescribeEnergyLevelRequest) (*traits.EnergyLevelSupport, error) {
-	child, err := r.GetEnergyStorageInfoClient(request.Name)
+// ResolveEnergyStorageInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveEnergyStorageInfoClient(name string) (traits.EnergyStorageInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.EnergyStorageInfoClient), target, nil
+}
 
-	return child.DescribeEnergyLevel(ctx, request)
+func (r *InfoRouter) DescribeEnergyLevel(ctx context.Context, request *traits.DescribeEnergyLevelRequest) (*traits.EnergyLevelSupport, error) {
+	var res *traits.EnergyLevelSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeEnergyLevelRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.EnergyStorageInfoClient).DescribeEnergyLevel(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -19,6 +19,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"energyLevel": m.energyLevel,
+	}
+}
+
 func (m *Model) GetEnergyLevel(opts ...resource.ReadOption) (*traits.EnergyLevel, error) {
 	res := m.energyLevel.Get(opts...)
 	return res.(*traits.EnergyLevel), nil
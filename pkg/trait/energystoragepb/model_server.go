@@ -41,7 +41,8 @@ func (s *ModelServer) GetEnergyLevel(_ context.Context, request *traits.GetEnerg
 }
 
 func (s *ModelServer) PullEnergyLevel(request *traits.PullEnergyLevelRequest, server traits.EnergyStorageApi_PullEnergyLevelServer) error {
-	for update := range s.model.PullEnergyLevel(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for update := range s.model.PullEnergyLevel(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		change := &traits.PullEnergyLevelResponse_Change{
 			Name:        request.Name,
 			ChangeTime:  timestamppb.New(update.ChangeTime),
@@ -55,6 +56,9 @@ func (s *ModelServer) PullEnergyLevel(request *traits.PullEnergyLevelRequest, se
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 
 	return server.Context().Err()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.EnterLeaveSensorApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewEnterLeaveSensorApiClient(conn)
+		}),
+	}
+}
+
 // WithEnterLeaveSensorApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithEnterLeaveSensorApiClientFactory(f func(name string) (traits.EnterLeaveSensorApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.EnterLeaveSensorApiClient)
 	return ok
 }
 
+// AddEnterLeaveSensorApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.EnterLeaveSensorApiClient, for example a router.Group.
 func (r *ApiRouter) AddEnterLeaveSensorApiClient(name string, client traits.EnterLeaveSensorApiClient) traits.EnterLeaveSensorApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnterLeaveSensorApiClient)
+	res, _ := r.Add(name, client).(traits.EnterLeaveSensorApiClient)
+	return res
 }
 
+// RemoveEnterLeaveSensorApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.EnterLeaveSensorApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveEnterLeaveSensorApiClient(name string) traits.EnterLeaveSensorApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnterLeaveSensorApiClient)
+	res, _ := r.Remove(name).(traits.EnterLeaveSensorApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetEnterLeaveSensorApiClient(name string) (traits.EnterLeaveSensorApiClient, error) {
@@ -79,25 +92,54 @@ func (r *ApiRouter) GetEnterLeaveSensorApiClient(name string) (traits.EnterLeave
 	return res.(traits.EnterLeaveSensorApiClient), nil
 }
 
-func (r *ApiRouter) PullEnterLeaveEvents(request *traits.PullEnterLeaveEventsRequest, server traits.EnterLeaveSensorApi_PullEnterLeaveEventsServer) error {
-	child, err := r.GetEnterLeaveSensorApiClient(request.Name)
+// ResolveEnterLeaveSensorApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveEnterLeaveSensorApiClient(name string) (traits.EnterLeaveSensorApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.EnterLeaveSensorApiClient), target, nil
+}
 
+func (r *ApiRouter) PullEnterLeaveEvents(request *traits.PullEnterLeaveEventsRequest, server traits.EnterLeaveSensorApi_PullEnterLeaveEventsServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullEnterLeaveEvents(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.EnterLeaveSensorApi_PullEnterLeaveEventsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullEnterLeaveEventsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.EnterLeaveSensorApiClient).PullEnterLeaveEvents(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -25,6 +25,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.EnterLeaveSensorInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewEnterLeaveSensorInfoClient(conn)
+		}),
+	}
+}
+
 // WithEnterLeaveSensorInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithEnterLeaveSensorInfoClientFactory(f func(name string) (traits.EnterLeaveSensorInfoClient, error)) router.Option {
@@ -46,24 +56,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.EnterLeaveSensorInfoClient)
 	return ok
 }
 
+// AddEnterLeaveSensorInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.EnterLeaveSensorInfoClient, for example a router.Group.
 func (r *InfoRouter) AddEnterLeaveSensorInfoClient(name string, client traits.EnterLeaveSensorInfoClient) traits.EnterLeaveSensorInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnterLeaveSensorInfoClient)
+	res, _ := r.Add(name, client).(traits.EnterLeaveSensorInfoClient)
+	return res
 }
 
+// RemoveEnterLeaveSensorInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.EnterLeaveSensorInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveEnterLeaveSensorInfoClient(name string) traits.EnterLeaveSensorInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.EnterLeaveSensorInfoClient)
+	res, _ := r.Remove(name).(traits.EnterLeaveSensorInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetEnterLeaveSensorInfoClient(name string) (traits.EnterLeaveSensorInfoClient, error) {
@@ -76,3 +87,16 @@ func (r *InfoRouter) GetEnterLeaveSensorInfoClient(name string) (traits.EnterLea
 	}
 	return res.(traits.EnterLeaveSensorInfoClient), nil
 }
+
+// ResolveEnterLeaveSensorInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveEnterLeaveSensorInfoClient(name string) (traits.EnterLeaveSensorInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
+	if err != nil {
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.EnterLeaveSensorInfoClient), target, nil
+}
@@ -23,6 +23,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"enterLeaveEvents": m.enterLeaveEvents,
+	}
+}
+
 // CreateEnterLeaveEvent creates and publishes a new EnterLeaveEvent to Pull subscribers.
 // The last call to CreateEnterLeaveEvent is the one that is used as the current state if Pull is called with a false
 // updates_only field.
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.ExtendRetractApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewExtendRetractApiClient(conn)
+		}),
+	}
+}
+
 // WithExtendRetractApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithExtendRetractApiClientFactory(f func(name string) (traits.ExtendRetractApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ExtendRetractApiClient)
 	return ok
 }
 
+// AddExtendRetractApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ExtendRetractApiClient, for example a router.Group.
 func (r *ApiRouter) AddExtendRetractApiClient(name string, client traits.ExtendRetractApiClient) traits.ExtendRetractApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ExtendRetractApiClient)
+	res, _ := r.Add(name, client).(traits.ExtendRetractApiClient)
+	return res
 }
 
+// RemoveExtendRetractApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ExtendRetractApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveExtendRetractApiClient(name string) traits.ExtendRetractApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ExtendRetractApiClient)
+	res, _ := r.Remove(name).(traits.ExtendRetractApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetExtendRetractApiClient(name string) (traits.ExtendRetractApiClient, error) {
@@ -79,61 +92,114 @@ func (r *ApiRouter) GetExtendRetractApiClient(name string) (traits.ExtendRetract
 	return res.(traits.ExtendRetractApiClient), nil
 }
 
-func (r *ApiRouter) GetExtension(ctx context.Context, request *traits.GetExtensionRequest) (*traits.Extension, error) {
-	child, err := r.GetExtendRetractApiClient(request.Name)
+// ResolveExtendRetractApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveExtendRetractApiClient(name string) (traits.ExtendRetractApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ExtendRetractApiClient), target, nil
+}
 
-	return child.GetExtension(ctx, request)
+func (r *ApiRouter) GetExtension(ctx context.Context, request *traits.GetExtensionRequest) (*traits.Extension, error) {
+	var res *traits.Extension
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetExtensionRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ExtendRetractApiClient).GetExtension(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateExtension(ctx context.Context, request *traits.UpdateExtensionRequest) (*traits.Extension, error) {
-	child, err := r.GetExtendRetractApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateExtension(ctx, request)
+	var res *traits.Extension
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateExtensionRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ExtendRetractApiClient).UpdateExtension(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) Stop(ctx context.Context, request *traits.ExtendRetractStopRequest) (*traits.Extension, error) {
-	child, err := r.GetExtendRetractApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.Stop(ctx, request)
+	var res *traits.Extension
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ExtendRetractStopRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ExtendRetractApiClient).Stop(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) CreateExtensionPreset(ctx context.Context, request *traits.CreateExtensionPresetRequest) (*traits.ExtensionPreset, error) {
-	child, err := r.GetExtendRetractApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.CreateExtensionPreset(ctx, request)
+	var res *traits.ExtensionPreset
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.CreateExtensionPresetRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ExtendRetractApiClient).CreateExtensionPreset(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullExtensions(request *traits.PullExtensionsRequest, server traits.ExtendRetractApi_PullExtensionsServer) error {
-	child, err := r.GetExtendRetractApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullExtensions(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.ExtendRetractApi_PullExtensionsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullExtensionsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.ExtendRetractApiClient).PullExtensions(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
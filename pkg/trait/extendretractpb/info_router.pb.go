@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.ExtendRetractInfoServer that allows routing named requests to specific traits.ExtendRetractInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.ExtendRetractInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewExtendRetractInfoClient(conn)
+		}),
+	}
+}
+
 // WithExtendRetractInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithExtendRetractInfoClientFactory(f func(name string) (traits.ExtendRetractInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ExtendRetractInfoClient)
 	return ok
 }
 
+// AddExtendRetractInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ExtendRetractInfoClient, for example a router.Group.
 func (r *InfoRouter) AddExtendRetractInfoClient(name string, client traits.ExtendRetractInfoClient) traits.ExtendRetractInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ExtendRetractInfoClient)
+	res, _ := r.Add(name, client).(traits.ExtendRetractInfoClient)
+	return res
 }
 
+// RemoveExtendRetractInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ExtendRetractInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveExtendRetractInfoClient(name string) traits.ExtendRetractInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ExtendRetractInfoClient)
+	res, _ := r.Remove(name).(traits.ExtendRetractInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetExtendRetractInfoClient(name string) (traits.ExtendRetractInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetExtendRetractInfoClient(name string) (traits.ExtendRetra
 	return res.(traits.ExtendRetractInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeExtension(ctx context.Context, request *traits.DescribeExtensionRequest) (*traits.ExtensionSupport, error) {
-	child, err := r.GetExtendRetractInfoClient(request.Name)
+// ResolveExtendRetractInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveExtendRetractInfoClient(name string) (traits.ExtendRetractInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ExtendRetractInfoClient), target, nil
+}
 
-	return child.DescribeExtension(ctx, request)
+func (r *InfoRouter) DescribeExtension(ctx context.Context, request *traits.DescribeExtensionRequest) (*traits.ExtensionSupport, error) {
+	var res *traits.ExtensionSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeExtensionRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ExtendRetractInfoClient).DescribeExtension(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.FanSpeedApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewFanSpeedApiClient(conn)
+		}),
+	}
+}
+
 // WithFanSpeedApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithFanSpeedApiClientFactory(f func(name string) (traits.FanSpeedApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.FanSpeedApiClient)
 	return ok
 }
 
+// AddFanSpeedApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.FanSpeedApiClient, for example a router.Group.
 func (r *ApiRouter) AddFanSpeedApiClient(name string, client traits.FanSpeedApiClient) traits.FanSpeedApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.FanSpeedApiClient)
+	res, _ := r.Add(name, client).(traits.FanSpeedApiClient)
+	return res
 }
 
+// RemoveFanSpeedApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.FanSpeedApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveFanSpeedApiClient(name string) traits.FanSpeedApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.FanSpeedApiClient)
+	res, _ := r.Remove(name).(traits.FanSpeedApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetFanSpeedApiClient(name string) (traits.FanSpeedApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetFanSpeedApiClient(name string) (traits.FanSpeedApiClient,
 	return res.(traits.FanSpeedApiClient), nil
 }
 
-func (r *ApiRouter) GetFanSpeed(ctx context.Context, request *traits.GetFanSpeedRequest) (*traits.FanSpeed, error) {
-	child, err := r.GetFanSpeedApiClient(request.Name)
+// ResolveFanSpeedApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveFanSpeedApiClient(name string) (traits.FanSpeedApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.FanSpeedApiClient), target, nil
+}
 
-	return child.GetFanSpeed(ctx, request)
+func (r *ApiRouter) GetFanSpeed(ctx context.Context, request *traits.GetFanSpeedRequest) (*traits.FanSpeed, error) {
+	var res *traits.FanSpeed
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetFanSpeedRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.FanSpeedApiClient).GetFanSpeed(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateFanSpeed(ctx context.Context, request *traits.UpdateFanSpeedRequest) (*traits.FanSpeed, error) {
-	child, err := r.GetFanSpeedApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateFanSpeed(ctx, request)
+	var res *traits.FanSpeed
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateFanSpeedRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.FanSpeedApiClient).UpdateFanSpeed(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullFanSpeed(request *traits.PullFanSpeedRequest, server traits.FanSpeedApi_PullFanSpeedServer) error {
-	child, err := r.GetFanSpeedApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullFanSpeed(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.FanSpeedApi_PullFanSpeedClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullFanSpeedRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.FanSpeedApiClient).PullFanSpeed(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -157,10 +211,16 @@ func (r *ApiRouter) PullFanSpeed(request *traits.PullFanSpeedRequest, server tra
 }
 
 func (r *ApiRouter) ReverseFanSpeedDirection(ctx context.Context, request *traits.ReverseFanSpeedDirectionRequest) (*traits.FanSpeed, error) {
-	child, err := r.GetFanSpeedApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ReverseFanSpeedDirection(ctx, request)
+	var res *traits.FanSpeed
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ReverseFanSpeedDirectionRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.FanSpeedApiClient).ReverseFanSpeedDirection(ctx, request)
+		return err
+	})
+	return res, err
 }
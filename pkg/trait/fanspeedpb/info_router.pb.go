@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.FanSpeedInfoServer that allows routing named requests to specific traits.FanSpeedInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.FanSpeedInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewFanSpeedInfoClient(conn)
+		}),
+	}
+}
+
 // WithFanSpeedInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithFanSpeedInfoClientFactory(f func(name string) (traits.FanSpeedInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.FanSpeedInfoClient)
 	return ok
 }
 
+// AddFanSpeedInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.FanSpeedInfoClient, for example a router.Group.
 func (r *InfoRouter) AddFanSpeedInfoClient(name string, client traits.FanSpeedInfoClient) traits.FanSpeedInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.FanSpeedInfoClient)
+	res, _ := r.Add(name, client).(traits.FanSpeedInfoClient)
+	return res
 }
 
+// RemoveFanSpeedInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.FanSpeedInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveFanSpeedInfoClient(name string) traits.FanSpeedInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.FanSpeedInfoClient)
+	res, _ := r.Remove(name).(traits.FanSpeedInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetFanSpeedInfoClient(name string) (traits.FanSpeedInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetFanSpeedInfoClient(name string) (traits.FanSpeedInfoClie
 	return res.(traits.FanSpeedInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeFanSpeed(ctx context.Context, request *traits.DescribeFanSpeedRequest) (*traits.FanSpeedSupport, error) {
-	child, err := r.GetFanSpeedInfoClient(request.Name)
+// ResolveFanSpeedInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveFanSpeedInfoClient(name string) (traits.FanSpeedInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.FanSpeedInfoClient), target, nil
+}
 
-	return child.DescribeFanSpeed(ctx, request)
+func (r *InfoRouter) DescribeFanSpeed(ctx context.Context, request *traits.DescribeFanSpeedRequest) (*traits.FanSpeedSupport, error) {
+	var res *traits.FanSpeedSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeFanSpeedRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.FanSpeedInfoClient).DescribeFanSpeed(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -42,6 +42,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"fanSpeed": m.fanSpeed,
+	}
+}
+
 // FanSpeed gets the current fan speed.
 func (m *Model) FanSpeed(opts ...resource.ReadOption) *traits.FanSpeed {
 	return m.fanSpeed.Get(opts...).(*traits.FanSpeed)
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.HailApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewHailApiClient(conn)
+		}),
+	}
+}
+
 // WithHailApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithHailApiClientFactory(f func(name string) (traits.HailApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.HailApiClient)
 	return ok
 }
 
+// AddHailApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.HailApiClient, for example a router.Group.
 func (r *ApiRouter) AddHailApiClient(name string, client traits.HailApiClient) traits.HailApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.HailApiClient)
+	res, _ := r.Add(name, client).(traits.HailApiClient)
+	return res
 }
 
+// RemoveHailApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.HailApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveHailApiClient(name string) traits.HailApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.HailApiClient)
+	res, _ := r.Remove(name).(traits.HailApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetHailApiClient(name string) (traits.HailApiClient, error) {
@@ -79,61 +92,114 @@ func (r *ApiRouter) GetHailApiClient(name string) (traits.HailApiClient, error)
 	return res.(traits.HailApiClient), nil
 }
 
-func (r *ApiRouter) CreateHail(ctx context.Context, request *traits.CreateHailRequest) (*traits.Hail, error) {
-	child, err := r.GetHailApiClient(request.Name)
+// ResolveHailApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveHailApiClient(name string) (traits.HailApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.HailApiClient), target, nil
+}
 
-	return child.CreateHail(ctx, request)
+func (r *ApiRouter) CreateHail(ctx context.Context, request *traits.CreateHailRequest) (*traits.Hail, error) {
+	var res *traits.Hail
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.CreateHailRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.HailApiClient).CreateHail(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) GetHail(ctx context.Context, request *traits.GetHailRequest) (*traits.Hail, error) {
-	child, err := r.GetHailApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.GetHail(ctx, request)
+	var res *traits.Hail
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetHailRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.HailApiClient).GetHail(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateHail(ctx context.Context, request *traits.UpdateHailRequest) (*traits.Hail, error) {
-	child, err := r.GetHailApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateHail(ctx, request)
+	var res *traits.Hail
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateHailRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.HailApiClient).UpdateHail(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) DeleteHail(ctx context.Context, request *traits.DeleteHailRequest) (*traits.DeleteHailResponse, error) {
-	child, err := r.GetHailApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.DeleteHail(ctx, request)
+	var res *traits.DeleteHailResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DeleteHailRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.HailApiClient).DeleteHail(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullHail(request *traits.PullHailRequest, server traits.HailApi_PullHailServer) error {
-	child, err := r.GetHailApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullHail(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.HailApi_PullHailClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullHailRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.HailApiClient).PullHail(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -175,33 +241,55 @@ func (r *ApiRouter) PullHail(request *traits.PullHailRequest, server traits.Hail
 }
 
 func (r *ApiRouter) ListHails(ctx context.Context, request *traits.ListHailsRequest) (*traits.ListHailsResponse, error) {
-	child, err := r.GetHailApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ListHails(ctx, request)
+	var res *traits.ListHailsResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListHailsRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.HailApiClient).ListHails(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullHails(request *traits.PullHailsRequest, server traits.HailApi_PullHailsServer) error {
-	child, err := r.GetHailApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullHails(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.HailApi_PullHailsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullHailsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.HailApiClient).PullHails(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
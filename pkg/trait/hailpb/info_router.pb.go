@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.HailInfoServer that allows routing named requests to specific traits.HailInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.HailInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewHailInfoClient(conn)
+		}),
+	}
+}
+
 // WithHailInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithHailInfoClientFactory(f func(name string) (traits.HailInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.HailInfoClient)
 	return ok
 }
 
+// AddHailInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.HailInfoClient, for example a router.Group.
 func (r *InfoRouter) AddHailInfoClient(name string, client traits.HailInfoClient) traits.HailInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.HailInfoClient)
+	res, _ := r.Add(name, client).(traits.HailInfoClient)
+	return res
 }
 
+// RemoveHailInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.HailInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveHailInfoClient(name string) traits.HailInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.HailInfoClient)
+	res, _ := r.Remove(name).(traits.HailInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetHailInfoClient(name string) (traits.HailInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetHailInfoClient(name string) (traits.HailInfoClient, erro
 	return res.(traits.HailInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeHail(ctx context.Context, request *traits.DescribeHailRequest) (*traits.HailSupport, error) {
-	child, err := r.GetHailInfoClient(request.Name)
+// ResolveHailInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveHailInfoClient(name string) (traits.HailInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.HailInfoClient), target, nil
+}
 
-	return child.DescribeHail(ctx, request)
+func (r *InfoRouter) DescribeHail(ctx context.Context, request *traits.DescribeHailRequest) (*traits.HailSupport, error) {
+	var res *traits.HailSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeHailRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.HailInfoClient).DescribeHail(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -16,27 +16,29 @@ import (
 // Model describes the data structure needed to implement the Hail trait.
 type Model struct {
 	hails *resource.Collection // of *traits.Hail
-
-	keepAlive time.Duration
-
-	gcTicket chan struct{} // size-1 chan that has an item in when a gc run should succeed
 }
 
 // NewModel creates a new model.
 func NewModel(opts ...resource.Option) *Model {
 	args := calcModelArgs(opts...)
-	gcTicket := make(chan struct{}, 1)
-	gcTicket <- struct{}{} // prime the ticket machine so the gc runs
+	hailsOptions := args.hailsOptions
+	if args.keepAlive >= 0 {
+		// prepend so any expiry configured by the caller takes precedence
+		hailsOptions = append([]resource.Option{resource.WithExpiry(expireAfterArrival(args.keepAlive))}, hailsOptions...)
+	}
 	return &Model{
-		hails:     resource.NewCollection(args.hailsOptions...),
-		keepAlive: args.keepAlive,
+		hails: resource.NewCollection(hailsOptions...),
+	}
+}
 
-		gcTicket: gcTicket,
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"hails": m.hails,
 	}
 }
 
 func (m *Model) CreateHail(hail *traits.Hail) (*traits.Hail, error) {
-	defer m.gc()
 	return castReturn(m.hails.Add("", hail, resource.WithGenIDIfAbsent(), resource.WithIDCallback(func(id string) {
 		hail.Id = id
 	})))
@@ -94,6 +96,23 @@ func (m *Model) ListHails(opts ...resource.ReadOption) []*traits.Hail {
 	return hails
 }
 
+// ListHailsPage returns a page of hails, see resource.Collection.ListPage.
+func (m *Model) ListHailsPage(pageSize int, pageToken string, opts ...resource.ReadOption) (*traits.ListHailsResponse, error) {
+	page, err := m.hails.ListPage(pageSize, pageToken, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &traits.ListHailsResponse{
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int32(page.TotalSize),
+		Hails:         make([]*traits.Hail, len(page.Items)),
+	}
+	for i, msg := range page.Items {
+		res.Hails[i] = msg.(*traits.Hail)
+	}
+	return res, nil
+}
+
 type HailsChange struct {
 	ChangeType types.ChangeType
 	ChangeTime time.Time
@@ -123,41 +142,18 @@ func (m *Model) PullHails(ctx context.Context, opts ...resource.ReadOption) <-ch
 	return send
 }
 
-func (m *Model) gc() {
-	if m.keepAlive < 0 {
-		return
-	}
-
-	select {
-	case <-m.gcTicket:
-		// there is a ticket
-	default:
-		return // no ticket
-	}
-
-	// setup the next gc
-	time.AfterFunc(m.keepAlive, func() {
-		m.gcTicket <- struct{}{}
-	})
-
-	now := m.hails.Clock().Now()
-	t := now.Add(-m.keepAlive)
-	for _, msg := range m.hails.List() {
-		hail := msg.(*traits.Hail)
-		if arrivedBefore(hail, t) {
-			_, _ = m.hails.Delete(hail.Id, resource.WithAllowMissing(true), resource.WithExpectedValue(hail))
+// expireAfterArrival returns a resource.ExpiryFunc that expires hails keepAlive after they arrive.
+// Hails that have not arrived do not expire.
+func expireAfterArrival(keepAlive time.Duration) resource.ExpiryFunc {
+	return func(_ string, item proto.Message, _ time.Time) time.Time {
+		hail := item.(*traits.Hail)
+		if hail.ArriveTime == nil {
+			return time.Time{}
 		}
+		return hail.ArriveTime.AsTime().Add(keepAlive)
 	}
 }
 
-func arrivedBefore(hail *traits.Hail, t time.Time) bool {
-	if hail.ArriveTime == nil {
-		return false
-	}
-	arriveTime := hail.ArriveTime.AsTime()
-	return arriveTime.Before(t)
-}
-
 func castReturn(msg proto.Message, err error) (*traits.Hail, error) {
 	if msg == nil {
 		return nil, err
@@ -2,7 +2,6 @@ package hailpb
 
 import (
 	"context"
-	"sort"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -10,7 +9,6 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/smart-core-os/sc-api/go/traits"
-	"github.com/smart-core-os/sc-api/go/types"
 	"github.com/smart-core-os/sc-golang/pkg/resource"
 )
 
@@ -58,7 +56,8 @@ func (m *ModelServer) DeleteHail(_ context.Context, request *traits.DeleteHailRe
 }
 
 func (m *ModelServer) PullHail(request *traits.PullHailRequest, server traits.HailApi_PullHailServer) error {
-	for change := range m.model.PullHail(server.Context(), request.Id, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullHail(server.Context(), request.Id, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullHailResponse{Changes: []*traits.PullHailResponse_Change{
 			{Name: request.Name, ChangeTime: timestamppb.New(change.ChangeTime), Hail: change.Value},
 		}})
@@ -66,50 +65,16 @@ func (m *ModelServer) PullHail(request *traits.PullHailRequest, server traits.Ha
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (m *ModelServer) ListHails(_ context.Context, request *traits.ListHailsRequest) (*traits.ListHailsResponse, error) {
-	pageToken := &types.PageToken{}
-	if err := decodePageToken(request.PageToken, pageToken); err != nil {
-		return nil, err
-	}
-
-	lastKey := pageToken.GetLastResourceName() // the key() of the last item we sent
-	pageSize := capPageSize(int(request.GetPageSize()))
-
-	sortedItems := m.model.ListHails(resource.WithReadMask(request.ReadMask))
-	nextIndex := 0
-	if lastKey != "" {
-		nextIndex = sort.Search(len(sortedItems), func(i int) bool {
-			return sortedItems[i].Id > lastKey
-		})
-	}
-
-	result := &traits.ListHailsResponse{
-		TotalSize: int32(len(sortedItems)),
-	}
-	upperBound := nextIndex + pageSize
-	if upperBound > len(sortedItems) {
-		upperBound = len(sortedItems)
-		pageToken = nil
-	} else {
-		pageToken.PageStart = &types.PageToken_LastResourceName{
-			LastResourceName: sortedItems[upperBound-1].Id,
-		}
-	}
-
-	var err error
-	result.NextPageToken, err = encodePageToken(pageToken)
-	if err != nil {
-		return nil, err
-	}
-	result.Hails = sortedItems[nextIndex:upperBound]
-	return result, nil
+	return m.model.ListHailsPage(int(request.GetPageSize()), request.GetPageToken(), resource.WithReadMask(request.ReadMask))
 }
 
 func (m *ModelServer) PullHails(request *traits.PullHailsRequest, server traits.HailApi_PullHailsServer) error {
-	for change := range m.model.PullHails(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullHails(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullHailsResponse{Changes: []*traits.PullHailsResponse_Change{
 			{Name: request.Name, Type: change.ChangeType, ChangeTime: timestamppb.New(change.ChangeTime), OldValue: change.OldValue, NewValue: change.NewValue},
 		}})
@@ -117,5 +82,5 @@ func (m *ModelServer) PullHails(request *traits.PullHailsRequest, server traits.
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
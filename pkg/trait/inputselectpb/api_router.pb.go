@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.InputSelectApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewInputSelectApiClient(conn)
+		}),
+	}
+}
+
 // WithInputSelectApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithInputSelectApiClientFactory(f func(name string) (traits.InputSelectApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.InputSelectApiClient)
 	return ok
 }
 
+// AddInputSelectApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.InputSelectApiClient, for example a router.Group.
 func (r *ApiRouter) AddInputSelectApiClient(name string, client traits.InputSelectApiClient) traits.InputSelectApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.InputSelectApiClient)
+	res, _ := r.Add(name, client).(traits.InputSelectApiClient)
+	return res
 }
 
+// RemoveInputSelectApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.InputSelectApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveInputSelectApiClient(name string) traits.InputSelectApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.InputSelectApiClient)
+	res, _ := r.Remove(name).(traits.InputSelectApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetInputSelectApiClient(name string) (traits.InputSelectApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetInputSelectApiClient(name string) (traits.InputSelectApiC
 	return res.(traits.InputSelectApiClient), nil
 }
 
-func (r *ApiRouter) UpdateInput(ctx context.Context, request *traits.UpdateInputRequest) (*traits.Input, error) {
-	child, err := r.GetInputSelectApiClient(request.Name)
+// ResolveInputSelectApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveInputSelectApiClient(name string) (traits.InputSelectApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.InputSelectApiClient), target, nil
+}
 
-	return child.UpdateInput(ctx, request)
+func (r *ApiRouter) UpdateInput(ctx context.Context, request *traits.UpdateInputRequest) (*traits.Input, error) {
+	var res *traits.Input
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateInputRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.InputSelectApiClient).UpdateInput(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) GetInput(ctx context.Context, request *traits.GetInputRequest) (*traits.Input, error) {
-	child, err := r.GetInputSelectApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.GetInput(ctx, request)
+	var res *traits.Input
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetInputRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.InputSelectApiClient).GetInput(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullInput(request *traits.PullInputRequest, server traits.InputSelectApi_PullInputServer) error {
-	child, err := r.GetInputSelectApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullInput(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.InputSelectApi_PullInputClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullInputRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.InputSelectApiClient).PullInput(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.InputSelectInfoServer that allows routing named requests to specific traits.InputSelectInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.InputSelectInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewInputSelectInfoClient(conn)
+		}),
+	}
+}
+
 // WithInputSelectInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithInputSelectInfoClientFactory(f func(name string) (traits.InputSelectInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.InputSelectInfoClient)
 	return ok
 }
 
+// AddInputSelectInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.InputSelectInfoClient, for example a router.Group.
 func (r *InfoRouter) AddInputSelectInfoClient(name string, client traits.InputSelectInfoClient) traits.InputSelectInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.InputSelectInfoClient)
+	res, _ := r.Add(name, client).(traits.InputSelectInfoClient)
+	return res
 }
 
+// RemoveInputSelectInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.InputSelectInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveInputSelectInfoClient(name string) traits.InputSelectInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.InputSelectInfoClient)
+	res, _ := r.Remove(name).(traits.InputSelectInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetInputSelectInfoClient(name string) (traits.InputSelectInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetInputSelectInfoClient(name string) (traits.InputSelectIn
 	return res.(traits.InputSelectInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeInput(ctx context.Context, request *traits.DescribeInputRequest) (*traits.InputSupport, error) {
-	child, err := r.GetInputSelectInfoClient(request.Name)
+// ResolveInputSelectInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveInputSelectInfoClient(name string) (traits.InputSelectInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.InputSelectInfoClient), target, nil
+}
 
-	return child.DescribeInput(ctx, request)
+func (r *InfoRouter) DescribeInput(ctx context.Context, request *traits.DescribeInputRequest) (*traits.InputSupport, error) {
+	var res *traits.InputSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeInputRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.InputSelectInfoClient).DescribeInput(ctx, request)
+		return err
+	})
+	return res, err
 }
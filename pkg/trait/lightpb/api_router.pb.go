@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.LightApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewLightApiClient(conn)
+		}),
+	}
+}
+
 // WithLightApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithLightApiClientFactory(f func(name string) (traits.LightApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.LightApiClient)
 	return ok
 }
 
+// AddLightApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.LightApiClient, for example a router.Group.
 func (r *ApiRouter) AddLightApiClient(name string, client traits.LightApiClient) traits.LightApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LightApiClient)
+	res, _ := r.Add(name, client).(traits.LightApiClient)
+	return res
 }
 
+// RemoveLightApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.LightApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveLightApiClient(name string) traits.LightApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LightApiClient)
+	res, _ := r.Remove(name).(traits.LightApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetLightApiClient(name string) (traits.LightApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetLightApiClient(name string) (traits.LightApiClient, error
 	return res.(traits.LightApiClient), nil
 }
 
-func (r *ApiRouter) UpdateBrightness(ctx context.Context, request *traits.UpdateBrightnessRequest) (*traits.Brightness, error) {
-	child, err := r.GetLightApiClient(request.Name)
+// ResolveLightApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveLightApiClient(name string) (traits.LightApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.LightApiClient), target, nil
+}
 
-	return child.UpdateBrightness(ctx, request)
+func (r *ApiRouter) UpdateBrightness(ctx context.Context, request *traits.UpdateBrightnessRequest) (*traits.Brightness, error) {
+	var res *traits.Brightness
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateBrightnessRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.LightApiClient).UpdateBrightness(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) GetBrightness(ctx context.Context, request *traits.GetBrightnessRequest) (*traits.Brightness, error) {
-	child, err := r.GetLightApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.GetBrightness(ctx, request)
+	var res *traits.Brightness
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetBrightnessRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.LightApiClient).GetBrightness(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullBrightness(request *traits.PullBrightnessRequest, server traits.LightApi_PullBrightnessServer) error {
-	child, err := r.GetLightApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullBrightness(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.LightApi_PullBrightnessClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullBrightnessRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.LightApiClient).PullBrightness(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
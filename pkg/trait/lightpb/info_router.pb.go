@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.LightInfoServer that allows routing named requests to specific traits.LightInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.LightInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewLightInfoClient(conn)
+		}),
+	}
+}
+
 // WithLightInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithLightInfoClientFactory(f func(name string) (traits.LightInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.LightInfoClient)
 	return ok
 }
 
+// AddLightInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.LightInfoClient, for example a router.Group.
 func (r *InfoRouter) AddLightInfoClient(name string, client traits.LightInfoClient) traits.LightInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LightInfoClient)
+	res, _ := r.Add(name, client).(traits.LightInfoClient)
+	return res
 }
 
+// RemoveLightInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.LightInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveLightInfoClient(name string) traits.LightInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LightInfoClient)
+	res, _ := r.Remove(name).(traits.LightInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetLightInfoClient(name string) (traits.LightInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetLightInfoClient(name string) (traits.LightInfoClient, er
 	return res.(traits.LightInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeBrightness(ctx context.Context, request *traits.DescribeBrightnessRequest) (*traits.BrightnessSupport, error) {
-	child, err := r.GetLightInfoClient(request.Name)
+// ResolveLightInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveLightInfoClient(name string) (traits.LightInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.LightInfoClient), target, nil
+}
 
-	return child.DescribeBrightness(ctx, request)
+func (r *InfoRouter) DescribeBrightness(ctx context.Context, request *traits.DescribeBrightnessRequest) (*traits.BrightnessSupport, error) {
+	var res *traits.BrightnessSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeBrightnessRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.LightInfoClient).DescribeBrightness(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -2,15 +2,13 @@ package lightpb
 
 import (
 	"context"
+	"sync"
 	"time"
 
-	"github.com/tanema/gween"
-	"github.com/tanema/gween/ease"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/smart-core-os/sc-api/go/traits"
-	"github.com/smart-core-os/sc-api/go/types"
 	"github.com/smart-core-os/sc-golang/pkg/resource"
 )
 
@@ -20,6 +18,9 @@ type MemoryDevice struct {
 	brightness     *resource.Value
 	brightnessTick time.Duration // duration between updates when tweening brightness
 
+	updateMu      sync.Mutex         // serialises updates so only one animation runs at a time
+	stopAnimation context.CancelFunc // stops the running animation, if any, protected by updateMu
+
 	// todo: support presets
 }
 
@@ -46,6 +47,15 @@ func (s *MemoryDevice) GetBrightness(_ context.Context, req *traits.GetBrightnes
 }
 
 func (s *MemoryDevice) UpdateBrightness(ctx context.Context, request *traits.UpdateBrightnessRequest) (*traits.Brightness, error) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	// any new update replaces a running animation.
+	// Stop it after writing so it doesn't clear its target first, it can't write once the value has changed.
+	if stop := s.stopAnimation; stop != nil {
+		s.stopAnimation = nil
+		defer stop()
+	}
+
 	if request.GetBrightness().GetPreset() != nil {
 		res, err := s.brightness.Set(request.GetBrightness())
 		return res.(*traits.Brightness), err
@@ -57,68 +67,31 @@ func (s *MemoryDevice) UpdateBrightness(ctx context.Context, request *traits.Upd
 
 	duration := request.Brightness.GetBrightnessTween().GetTotalDuration().AsDuration()
 	if duration > 0 {
-		startTime := time.Now()
-		lastObj, err := s.brightness.Set(request.Brightness,
-			resource.WithUpdatePaths("level_percent", "brightness_tween", "target_level_percent"),
-			resource.WithMoreWritablePaths("brightness_tween", "target_level_percent"),
-			resource.InterceptBefore(func(old, new proto.Message) {
-				current := old.(*traits.Brightness)
-				next := new.(*traits.Brightness)
+		// the animation outlives the request, but not the next update
+		animCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+		animation, err := resource.Animate(animCtx, s.brightness, "level_percent", float64(request.Brightness.LevelPercent),
+			resource.WithAnimationTargetFunc(func(current, target float64) float64 {
+				next := &traits.Brightness{LevelPercent: float32(target)}
 				if request.Delta {
-					next.LevelPercent += current.LevelPercent
+					next.LevelPercent += float32(current)
 				}
 				capLevelPercent(next)
-				// move properties into their tween equivalents
-				next.TargetLevelPercent = next.LevelPercent
-				next.LevelPercent = current.LevelPercent
-				next.BrightnessTween.Progress = 0
+				return float64(next.LevelPercent)
 			}),
+			resource.WithAnimationDuration(duration),
+			resource.WithAnimationTick(s.brightnessTick),
+			resource.WithAnimationTargetPath("target_level_percent"),
+			resource.WithAnimationTweenPath("brightness_tween"),
 		)
 		if err != nil {
+			stop()
 			return nil, err
 		}
-
-		startVal := lastObj.(*traits.Brightness)
-		tween := gween.New(startVal.LevelPercent, startVal.TargetLevelPercent, float32(duration.Milliseconds()), ease.Linear)
-
-		go func() {
-			ticker := time.NewTicker(s.brightnessTick)
-			defer ticker.Stop()
-			for {
-				now := <-ticker.C
-				playTime := now.Sub(startTime)
-				newValue, finished := tween.Set(float32(playTime.Milliseconds()))
-				if finished {
-					// the tween has completed, reset the tween data
-					_, err := s.brightness.Set(&traits.Brightness{LevelPercent: newValue},
-						resource.WithUpdatePaths("level_percent"),
-						resource.WithResetPaths("target_level_percent", "brightness_tween"),
-						resource.WithExpectedValue(lastObj),
-					)
-					if err != nil && err != resource.ExpectedValuePreconditionFailed {
-						panic(err) // programmer error
-					}
-					return
-				}
-
-				// calculate using time, not value, which leave room for easing (and is mentioned in the tween spec)
-				progress := 100 * float32(playTime.Milliseconds()) / float32(duration.Milliseconds())
-				lastObj, err = s.brightness.Set(&traits.Brightness{LevelPercent: newValue, BrightnessTween: &types.Tween{Progress: progress}},
-					resource.WithUpdatePaths("level_percent", "brightness_tween.progress"),
-					resource.WithMoreWritablePaths("brightness_tween.progress"),
-					resource.WithExpectedValue(lastObj),
-				)
-				switch {
-				case err == resource.ExpectedValuePreconditionFailed:
-					// somebody else changed the value, tweening is done
-					return
-				case err != nil:
-					panic(err) // programmer error
-				}
-			}
-		}()
-
-		return startVal, nil
+		s.stopAnimation = func() {
+			stop()
+			<-animation.Done()
+		}
+		return animation.Started().(*traits.Brightness), nil
 	}
 
 	res, err := s.brightness.Set(
@@ -140,7 +113,8 @@ func (s *MemoryDevice) UpdateBrightness(ctx context.Context, request *traits.Upd
 }
 
 func (s *MemoryDevice) PullBrightness(request *traits.PullBrightnessRequest, server traits.LightApi_PullBrightnessServer) error {
-	for event := range s.brightness.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for event := range s.brightness.Pull(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		brightness := event.Value.(*traits.Brightness)
 		// don't emit progress if the caller doesn't want it
 		if request.ExcludeRamping {
@@ -162,6 +136,9 @@ func (s *MemoryDevice) PullBrightness(request *traits.PullBrightnessRequest, ser
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 
 	return server.Context().Err()
 }
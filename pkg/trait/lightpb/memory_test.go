@@ -158,4 +158,39 @@ func TestMemoryDevice_UpdateBrightness(t *testing.T) {
 			t.Fatalf("final value (-want,+got)\n%v", diff)
 		}
 	})
+
+	t.Run("tween delta", func(t *testing.T) {
+		api := NewMemoryDevice()
+		api.brightnessTick = 10 * time.Millisecond
+		if _, err := api.brightness.Set(&traits.Brightness{LevelPercent: 80}); err != nil {
+			t.Fatal(err)
+		}
+		started, err := api.UpdateBrightness(th.Ctx, &traits.UpdateBrightnessRequest{
+			Delta: true,
+			Brightness: &traits.Brightness{
+				LevelPercent:    30,
+				BrightnessTween: &types.Tween{TotalDuration: durationpb.New(time.Hour)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		if got := started.TargetLevelPercent; got != 100 {
+			t.Fatalf("target want 100, got %v", got)
+		}
+
+		// a later update stops the animation
+		if _, err := api.UpdateBrightness(th.Ctx, &traits.UpdateBrightnessRequest{
+			Brightness: &traits.Brightness{LevelPercent: 10},
+		}); err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		if api.stopAnimation != nil {
+			t.Fatalf("want no running animation")
+		}
+		time.Sleep(50 * time.Millisecond)
+		if diff := cmp.Diff(&traits.Brightness{LevelPercent: 10}, api.brightness.Get(), protocmp.Transform()); diff != "" {
+			t.Fatalf("value (-want,+got)\n%v", diff)
+		}
+	})
 }
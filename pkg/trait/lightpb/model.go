@@ -21,6 +21,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"brightness": m.brightness,
+	}
+}
+
 func (m *Model) GetBrightness(opts ...resource.ReadOption) (*traits.Brightness, error) {
 	return m.brightness.Get(opts...).(*traits.Brightness), nil
 }
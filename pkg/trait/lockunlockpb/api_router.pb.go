@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.LockUnlockApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewLockUnlockApiClient(conn)
+		}),
+	}
+}
+
 // WithLockUnlockApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithLockUnlockApiClientFactory(f func(name string) (traits.LockUnlockApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.LockUnlockApiClient)
 	return ok
 }
 
+// AddLockUnlockApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.LockUnlockApiClient, for example a router.Group.
 func (r *ApiRouter) AddLockUnlockApiClient(name string, client traits.LockUnlockApiClient) traits.LockUnlockApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LockUnlockApiClient)
+	res, _ := r.Add(name, client).(traits.LockUnlockApiClient)
+	return res
 }
 
+// RemoveLockUnlockApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.LockUnlockApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveLockUnlockApiClient(name string) traits.LockUnlockApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LockUnlockApiClient)
+	res, _ := r.Remove(name).(traits.LockUnlockApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetLockUnlockApiClient(name string) (traits.LockUnlockApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetLockUnlockApiClient(name string) (traits.LockUnlockApiCli
 	return res.(traits.LockUnlockApiClient), nil
 }
 
-func (r *ApiRouter) GetLockUnlock(ctx context.Context, request *traits.GetLockUnlockRequest) (*traits.LockUnlock, error) {
-	child, err := r.GetLockUnlockApiClient(request.Name)
+// ResolveLockUnlockApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveLockUnlockApiClient(name string) (traits.LockUnlockApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.LockUnlockApiClient), target, nil
+}
 
-	return child.GetLockUnlock(ctx, request)
+func (r *ApiRouter) GetLockUnlock(ctx context.Context, request *traits.GetLockUnlockRequest) (*traits.LockUnlock, error) {
+	var res *traits.LockUnlock
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetLockUnlockRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.LockUnlockApiClient).GetLockUnlock(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateLockUnlock(ctx context.Context, request *traits.UpdateLockUnlockRequest) (*traits.LockUnlock, error) {
-	child, err := r.GetLockUnlockApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateLockUnlock(ctx, request)
+	var res *traits.LockUnlock
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateLockUnlockRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.LockUnlockApiClient).UpdateLockUnlock(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullLockUnlock(request *traits.PullLockUnlockRequest, server traits.LockUnlockApi_PullLockUnlockServer) error {
-	child, err := r.GetLockUnlockApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullLockUnlock(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.LockUnlockApi_PullLockUnlockClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullLockUnlockRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.LockUnlockApiClient).PullLockUnlock(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -25,6 +25,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.LockUnlockInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewLockUnlockInfoClient(conn)
+		}),
+	}
+}
+
 // WithLockUnlockInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithLockUnlockInfoClientFactory(f func(name string) (traits.LockUnlockInfoClient, error)) router.Option {
@@ -46,24 +56,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.LockUnlockInfoClient)
 	return ok
 }
 
+// AddLockUnlockInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.LockUnlockInfoClient, for example a router.Group.
 func (r *InfoRouter) AddLockUnlockInfoClient(name string, client traits.LockUnlockInfoClient) traits.LockUnlockInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LockUnlockInfoClient)
+	res, _ := r.Add(name, client).(traits.LockUnlockInfoClient)
+	return res
 }
 
+// RemoveLockUnlockInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.LockUnlockInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveLockUnlockInfoClient(name string) traits.LockUnlockInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.LockUnlockInfoClient)
+	res, _ := r.Remove(name).(traits.LockUnlockInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetLockUnlockInfoClient(name string) (traits.LockUnlockInfoClient, error) {
@@ -76,3 +87,16 @@ func (r *InfoRouter) GetLockUnlockInfoClient(name string) (traits.LockUnlockInfo
 	}
 	return res.(traits.LockUnlockInfoClient), nil
 }
+
+// ResolveLockUnlockInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveLockUnlockInfoClient(name string) (traits.LockUnlockInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
+	if err != nil {
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.LockUnlockInfoClient), target, nil
+}
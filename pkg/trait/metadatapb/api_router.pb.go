@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.MetadataApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMetadataApiClient(conn)
+		}),
+	}
+}
+
 // WithMetadataApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMetadataApiClientFactory(f func(name string) (traits.MetadataApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MetadataApiClient)
 	return ok
 }
 
+// AddMetadataApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MetadataApiClient, for example a router.Group.
 func (r *ApiRouter) AddMetadataApiClient(name string, client traits.MetadataApiClient) traits.MetadataApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MetadataApiClient)
+	res, _ := r.Add(name, client).(traits.MetadataApiClient)
+	return res
 }
 
+// RemoveMetadataApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MetadataApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveMetadataApiClient(name string) traits.MetadataApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MetadataApiClient)
+	res, _ := r.Remove(name).(traits.MetadataApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetMetadataApiClient(name string) (traits.MetadataApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetMetadataApiClient(name string) (traits.MetadataApiClient,
 	return res.(traits.MetadataApiClient), nil
 }
 
-func (r *ApiRouter) GetMetadata(ctx context.Context, request *traits.GetMetadataRequest) (*traits.Metadata, error) {
-	child, err := r.GetMetadataApiClient(request.Name)
+// ResolveMetadataApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveMetadataApiClient(name string) (traits.MetadataApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetMetadata(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.MetadataApiClient), target, nil
 }
 
-func (r *ApiRouter) PullMetadata(request *traits.PullMetadataRequest, server traits.MetadataApi_PullMetadataServer) error {
-	child, err := r.GetMetadataApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetMetadata(ctx context.Context, request *traits.GetMetadataRequest) (*traits.Metadata, error) {
+	var res *traits.Metadata
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetMetadataRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MetadataApiClient).GetMetadata(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullMetadata(request *traits.PullMetadataRequest, server traits.MetadataApi_PullMetadataServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullMetadata(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.MetadataApi_PullMetadataClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullMetadataRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.MetadataApiClient).PullMetadata(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
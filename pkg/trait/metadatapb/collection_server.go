@@ -26,7 +26,8 @@ func (s *CollectionServer) GetMetadata(_ context.Context, request *traits.GetMet
 }
 
 func (s *CollectionServer) PullMetadata(request *traits.PullMetadataRequest, server traits.MetadataApi_PullMetadataServer) error {
-	for change := range s.model.PullMetadata(server.Context(), request.Name, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range s.model.PullMetadata(server.Context(), request.Name, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullMetadataResponse{Changes: []*traits.PullMetadataResponse_Change{
 			{Name: request.Name, ChangeTime: change.ChangeTime, Metadata: change.Metadata},
 		}})
@@ -34,5 +35,8 @@ func (s *CollectionServer) PullMetadata(request *traits.PullMetadataRequest, ser
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 	return server.Context().Err() // the loop only ends when the context is done
 }
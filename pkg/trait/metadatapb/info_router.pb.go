@@ -25,6 +25,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.MetadataInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMetadataInfoClient(conn)
+		}),
+	}
+}
+
 // WithMetadataInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMetadataInfoClientFactory(f func(name string) (traits.MetadataInfoClient, error)) router.Option {
@@ -46,24 +56,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MetadataInfoClient)
 	return ok
 }
 
+// AddMetadataInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MetadataInfoClient, for example a router.Group.
 func (r *InfoRouter) AddMetadataInfoClient(name string, client traits.MetadataInfoClient) traits.MetadataInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MetadataInfoClient)
+	res, _ := r.Add(name, client).(traits.MetadataInfoClient)
+	return res
 }
 
+// RemoveMetadataInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MetadataInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveMetadataInfoClient(name string) traits.MetadataInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MetadataInfoClient)
+	res, _ := r.Remove(name).(traits.MetadataInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetMetadataInfoClient(name string) (traits.MetadataInfoClient, error) {
@@ -76,3 +87,16 @@ func (r *InfoRouter) GetMetadataInfoClient(name string) (traits.MetadataInfoClie
 	}
 	return res.(traits.MetadataInfoClient), nil
 }
+
+// ResolveMetadataInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveMetadataInfoClient(name string) (traits.MetadataInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
+	if err != nil {
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.MetadataInfoClient), target, nil
+}
@@ -22,6 +22,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"metadata": m.metadata,
+	}
+}
+
 func (m *Model) GetMetadata(opts ...resource.ReadOption) (*traits.Metadata, error) {
 	res := m.metadata.Get(opts...)
 	return res.(*traits.Metadata), nil
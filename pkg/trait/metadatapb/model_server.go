@@ -33,7 +33,8 @@ func (s *ModelServer) GetMetadata(_ context.Context, request *traits.GetMetadata
 }
 
 func (s *ModelServer) PullMetadata(request *traits.PullMetadataRequest, server traits.MetadataApi_PullMetadataServer) error {
-	for change := range s.model.PullMetadata(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range s.model.PullMetadata(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullMetadataResponse{Changes: []*traits.PullMetadataResponse_Change{
 			{Name: request.Name, ChangeTime: change.ChangeTime, Metadata: change.Metadata},
 		}})
@@ -41,5 +42,8 @@ func (s *ModelServer) PullMetadata(request *traits.PullMetadataRequest, server t
 			return err
 		}
 	}
+	if err := pullErr(); err != nil {
+		return err
+	}
 	return server.Context().Err() // the loop only ends when the context is done
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.MeterApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMeterApiClient(conn)
+		}),
+	}
+}
+
 // WithMeterApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMeterApiClientFactory(f func(name string) (traits.MeterApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MeterApiClient)
 	return ok
 }
 
+// AddMeterApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MeterApiClient, for example a router.Group.
 func (r *ApiRouter) AddMeterApiClient(name string, client traits.MeterApiClient) traits.MeterApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MeterApiClient)
+	res, _ := r.Add(name, client).(traits.MeterApiClient)
+	return res
 }
 
+// RemoveMeterApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MeterApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveMeterApiClient(name string) traits.MeterApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MeterApiClient)
+	res, _ := r.Remove(name).(traits.MeterApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetMeterApiClient(name string) (traits.MeterApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetMeterApiClient(name string) (traits.MeterApiClient, error
 	return res.(traits.MeterApiClient), nil
 }
 
-func (r *ApiRouter) GetMeterReading(ctx context.Context, request *traits.GetMeterReadingRequest) (*traits.MeterReading, error) {
-	child, err := r.GetMeterApiClient(request.Name)
+// ResolveMeterApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveMeterApiClient(name string) (traits.MeterApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetMeterReading(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.MeterApiClient), target, nil
 }
 
-func (r *ApiRouter) PullMeterReadings(request *traits.PullMeterReadingsRequest, server traits.MeterApi_PullMeterReadingsServer) error {
-	child, err := r.GetMeterApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetMeterReading(ctx context.Context, request *traits.GetMeterReadingRequest) (*traits.MeterReading, error) {
+	var res *traits.MeterReading
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetMeterReadingRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MeterApiClient).GetMeterReading(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullMeterReadings(request *traits.PullMeterReadingsRequest, server traits.MeterApi_PullMeterReadingsServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullMeterReadings(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.MeterApi_PullMeterReadingsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullMeterReadingsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.MeterApiClient).PullMeterReadings(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.MeterInfoServer that allows routing named requests to specific traits.MeterInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.MeterInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMeterInfoClient(conn)
+		}),
+	}
+}
+
 // WithMeterInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMeterInfoClientFactory(f func(name string) (traits.MeterInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MeterInfoClient)
 	return ok
 }
 
+// AddMeterInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MeterInfoClient, for example a router.Group.
 func (r *InfoRouter) AddMeterInfoClient(name string, client traits.MeterInfoClient) traits.MeterInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MeterInfoClient)
+	res, _ := r.Add(name, client).(traits.MeterInfoClient)
+	return res
 }
 
+// RemoveMeterInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MeterInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveMeterInfoClient(name string) traits.MeterInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MeterInfoClient)
+	res, _ := r.Remove(name).(traits.MeterInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetMeterInfoClient(name string) (traits.MeterInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetMeterInfoClient(name string) (traits.MeterInfoClient, er
 	return res.(traits.MeterInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeMeterReading(ctx context.Context, request *traits.DescribeMeterReadingRequest) (*traits.MeterReadingSupport, error) {
-	child, err := r.GetMeterInfoClient(request.Name)
+// ResolveMeterInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveMeterInfoClient(name string) (traits.MeterInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.MeterInfoClient), target, nil
+}
 
-	return child.DescribeMeterReading(ctx, request)
+func (r *InfoRouter) DescribeMeterReading(ctx context.Context, request *traits.DescribeMeterReadingRequest) (*traits.MeterReadingSupport, error) {
+	var res *traits.MeterReadingSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeMeterReadingRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MeterInfoClient).DescribeMeterReading(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -35,6 +35,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"meterReading": m.meterReading,
+	}
+}
+
 func (m *Model) GetMeterReading(opts ...resource.ReadOption) (*traits.MeterReading, error) {
 	return m.meterReading.Get(opts...).(*traits.MeterReading), nil
 }
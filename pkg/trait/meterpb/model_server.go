@@ -32,7 +32,8 @@ func (m *ModelServer) GetMeterReading(_ context.Context, request *traits.GetMete
 }
 
 func (m *ModelServer) PullMeterReadings(request *traits.PullMeterReadingsRequest, server traits.MeterApi_PullMeterReadingsServer) error {
-	for change := range m.model.PullMeterReadings(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullMeterReadings(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		msg := &traits.PullMeterReadingsResponse{Changes: []*traits.PullMeterReadingsResponse_Change{{
 			Name:         request.Name,
 			ChangeTime:   timestamppb.New(change.ChangeTime),
@@ -42,5 +43,5 @@ func (m *ModelServer) PullMeterReadings(request *traits.PullMeterReadingsRequest
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
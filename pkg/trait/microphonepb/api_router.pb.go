@@ -9,6 +9,8 @@ import (
 	types "github.com/smart-core-os/sc-api/go/types"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -28,6 +30,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.MicrophoneApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMicrophoneApiClient(conn)
+		}),
+	}
+}
+
 // WithMicrophoneApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMicrophoneApiClientFactory(f func(name string) (traits.MicrophoneApiClient, error)) router.Option {
@@ -49,24 +61,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MicrophoneApiClient)
 	return ok
 }
 
+// AddMicrophoneApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MicrophoneApiClient, for example a router.Group.
 func (r *ApiRouter) AddMicrophoneApiClient(name string, client traits.MicrophoneApiClient) traits.MicrophoneApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MicrophoneApiClient)
+	res, _ := r.Add(name, client).(traits.MicrophoneApiClient)
+	return res
 }
 
+// RemoveMicrophoneApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MicrophoneApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveMicrophoneApiClient(name string) traits.MicrophoneApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MicrophoneApiClient)
+	res, _ := r.Remove(name).(traits.MicrophoneApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetMicrophoneApiClient(name string) (traits.MicrophoneApiClient, error) {
@@ -80,43 +93,84 @@ func (r *ApiRouter) GetMicrophoneApiClient(name string) (traits.MicrophoneApiCli
 	return res.(traits.MicrophoneApiClient), nil
 }
 
-func (r *ApiRouter) GetGain(ctx context.Context, request *traits.GetMicrophoneGainRequest) (*types.AudioLevel, error) {
-	child, err := r.GetMicrophoneApiClient(request.Name)
+// ResolveMicrophoneApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveMicrophoneApiClient(name string) (traits.MicrophoneApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.MicrophoneApiClient), target, nil
+}
 
-	return child.GetGain(ctx, request)
+func (r *ApiRouter) GetGain(ctx context.Context, request *traits.GetMicrophoneGainRequest) (*types.AudioLevel, error) {
+	var res *types.AudioLevel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetMicrophoneGainRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MicrophoneApiClient).GetGain(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateGain(ctx context.Context, request *traits.UpdateMicrophoneGainRequest) (*types.AudioLevel, error) {
-	child, err := r.GetMicrophoneApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateGain(ctx, request)
+	var res *types.AudioLevel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateMicrophoneGainRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MicrophoneApiClient).UpdateGain(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullGain(request *traits.PullMicrophoneGainRequest, server traits.MicrophoneApi_PullGainServer) error {
-	child, err := r.GetMicrophoneApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullGain(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.MicrophoneApi_PullGainClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullMicrophoneGainRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.MicrophoneApiClient).PullGain(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.MicrophoneInfoServer that allows routing named requests to specific traits.MicrophoneInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.MicrophoneInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMicrophoneInfoClient(conn)
+		}),
+	}
+}
+
 // WithMicrophoneInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMicrophoneInfoClientFactory(f func(name string) (traits.MicrophoneInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MicrophoneInfoClient)
 	return ok
 }
 
+// AddMicrophoneInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MicrophoneInfoClient, for example a router.Group.
 func (r *InfoRouter) AddMicrophoneInfoClient(name string, client traits.MicrophoneInfoClient) traits.MicrophoneInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MicrophoneInfoClient)
+	res, _ := r.Add(name, client).(traits.MicrophoneInfoClient)
+	return res
 }
 
+// RemoveMicrophoneInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MicrophoneInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveMicrophoneInfoClient(name string) traits.MicrophoneInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MicrophoneInfoClient)
+	res, _ := r.Remove(name).(traits.MicrophoneInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetMicrophoneInfoClient(name string) (traits.MicrophoneInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetMicrophoneInfoClient(name string) (traits.MicrophoneInfo
 	return res.(traits.MicrophoneInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeGain(ctx context.Context, request *traits.DescribeGainRequest) (*traits.GainSupport, error) {
-	child, err := r.GetMicrophoneInfoClient(request.Name)
+// ResolveMicrophoneInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveMicrophoneInfoClient(name string) (traits.MicrophoneInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.MicrophoneInfoClient), target, nil
+}
 
-	return child.DescribeGain(ctx, request)
+func (r *InfoRouter) DescribeGain(ctx context.Context, request *traits.DescribeGainRequest) (*traits.GainSupport, error) {
+	var res *traits.GainSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeGainRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MicrophoneInfoClient).DescribeGain(ctx, request)
+		return err
+	})
+	return res, err
 }
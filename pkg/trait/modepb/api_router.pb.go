@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.ModeApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewModeApiClient(conn)
+		}),
+	}
+}
+
 // WithModeApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithModeApiClientFactory(f func(name string) (traits.ModeApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ModeApiClient)
 	return ok
 }
 
+// AddModeApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ModeApiClient, for example a router.Group.
 func (r *ApiRouter) AddModeApiClient(name string, client traits.ModeApiClient) traits.ModeApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ModeApiClient)
+	res, _ := r.Add(name, client).(traits.ModeApiClient)
+	return res
 }
 
+// RemoveModeApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ModeApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveModeApiClient(name string) traits.ModeApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ModeApiClient)
+	res, _ := r.Remove(name).(traits.ModeApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetModeApiClient(name string) (traits.ModeApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetModeApiClient(name string) (traits.ModeApiClient, error)
 	return res.(traits.ModeApiClient), nil
 }
 
-func (r *ApiRouter) GetModeValues(ctx context.Context, request *traits.GetModeValuesRequest) (*traits.ModeValues, error) {
-	child, err := r.GetModeApiClient(request.Name)
+// ResolveModeApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveModeApiClient(name string) (traits.ModeApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ModeApiClient), target, nil
+}
 
-	return child.GetModeValues(ctx, request)
+func (r *ApiRouter) GetModeValues(ctx context.Context, request *traits.GetModeValuesRequest) (*traits.ModeValues, error) {
+	var res *traits.ModeValues
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetModeValuesRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ModeApiClient).GetModeValues(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateModeValues(ctx context.Context, request *traits.UpdateModeValuesRequest) (*traits.ModeValues, error) {
-	child, err := r.GetModeApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateModeValues(ctx, request)
+	var res *traits.ModeValues
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateModeValuesRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ModeApiClient).UpdateModeValues(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullModeValues(request *traits.PullModeValuesRequest, server traits.ModeApi_PullModeValuesServer) error {
-	child, err := r.GetModeApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullModeValues(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.ModeApi_PullModeValuesClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullModeValuesRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.ModeApiClient).PullModeValues(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.ModeInfoServer that allows routing named requests to specific traits.ModeInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.ModeInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewModeInfoClient(conn)
+		}),
+	}
+}
+
 // WithModeInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithModeInfoClientFactory(f func(name string) (traits.ModeInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ModeInfoClient)
 	return ok
 }
 
+// AddModeInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ModeInfoClient, for example a router.Group.
 func (r *InfoRouter) AddModeInfoClient(name string, client traits.ModeInfoClient) traits.ModeInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ModeInfoClient)
+	res, _ := r.Add(name, client).(traits.ModeInfoClient)
+	return res
 }
 
+// RemoveModeInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ModeInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveModeInfoClient(name string) traits.ModeInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ModeInfoClient)
+	res, _ := r.Remove(name).(traits.ModeInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetModeInfoClient(name string) (traits.ModeInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetModeInfoClient(name string) (traits.ModeInfoClient, erro
 	return res.(traits.ModeInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeModes(ctx context.Context, request *traits.DescribeModesRequest) (*traits.ModesSupport, error) {
-	child, err := r.GetModeInfoClient(request.Name)
+// ResolveModeInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveModeInfoClient(name string) (traits.ModeInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.ModeInfoClient), target, nil
+}
 
-	return child.DescribeModes(ctx, request)
+func (r *InfoRouter) DescribeModes(ctx context.Context, request *traits.DescribeModesRequest) (*traits.ModesSupport, error) {
+	var res *traits.ModesSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeModesRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.ModeInfoClient).DescribeModes(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -37,6 +37,13 @@ func NewModel() *Model {
 	return NewModelModes(DefaultModes)
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"modeValues": m.modeValues,
+	}
+}
+
 // NewModelModes constructs a Model with the given modes.
 // The first value of each mode will be selected.
 func NewModelModes(modes *traits.Modes) *Model {
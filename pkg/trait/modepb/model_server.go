@@ -93,7 +93,8 @@ func (m *ModelServer) relativeAdjustment(relative map[string]int32) resource.Upd
 }
 
 func (m *ModelServer) PullModeValues(request *traits.PullModeValuesRequest, server traits.ModeApi_PullModeValuesServer) error {
-	for change := range m.model.PullModeValues(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullModeValues(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullModeValuesResponse{Changes: []*traits.PullModeValuesResponse_Change{
 			{
 				Name:       request.Name,
@@ -105,5 +106,5 @@ func (m *ModelServer) PullModeValues(request *traits.PullModeValuesRequest, serv
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.MotionSensorApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMotionSensorApiClient(conn)
+		}),
+	}
+}
+
 // WithMotionSensorApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMotionSensorApiClientFactory(f func(name string) (traits.MotionSensorApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MotionSensorApiClient)
 	return ok
 }
 
+// AddMotionSensorApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MotionSensorApiClient, for example a router.Group.
 func (r *ApiRouter) AddMotionSensorApiClient(name string, client traits.MotionSensorApiClient) traits.MotionSensorApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MotionSensorApiClient)
+	res, _ := r.Add(name, client).(traits.MotionSensorApiClient)
+	return res
 }
 
+// RemoveMotionSensorApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MotionSensorApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveMotionSensorApiClient(name string) traits.MotionSensorApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MotionSensorApiClient)
+	res, _ := r.Remove(name).(traits.MotionSensorApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetMotionSensorApiClient(name string) (traits.MotionSensorApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetMotionSensorApiClient(name string) (traits.MotionSensorAp
 	return res.(traits.MotionSensorApiClient), nil
 }
 
-func (r *ApiRouter) GetMotionDetection(ctx context.Context, request *traits.GetMotionDetectionRequest) (*traits.MotionDetection, error) {
-	child, err := r.GetMotionSensorApiClient(request.Name)
+// ResolveMotionSensorApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveMotionSensorApiClient(name string) (traits.MotionSensorApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetMotionDetection(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.MotionSensorApiClient), target, nil
 }
 
-func (r *ApiRouter) PullMotionDetections(request *traits.PullMotionDetectionRequest, server traits.MotionSensorApi_PullMotionDetectionsServer) error {
-	child, err := r.GetMotionSensorApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetMotionDetection(ctx context.Context, request *traits.GetMotionDetectionRequest) (*traits.MotionDetection, error) {
+	var res *traits.MotionDetection
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetMotionDetectionRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MotionSensorApiClient).GetMotionDetection(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullMotionDetections(request *traits.PullMotionDetectionRequest, server traits.MotionSensorApi_PullMotionDetectionsServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullMotionDetections(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.MotionSensorApi_PullMotionDetectionsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullMotionDetectionRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.MotionSensorApiClient).PullMotionDetections(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/smart-core-os/sc-api/go/traits"
 	"github.com/smart-core-os/sc-golang/pkg/router"
@@ -28,6 +29,16 @@ func NewSensorInfoRouter(opts ...router.Option) *SensorInfoRouter {
 	}
 }
 
+// NewSensorInfoRouterFromServiceRouter returns a SensorInfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewSensorInfoRouterFromServiceRouter(services *router.ServiceRouter) *SensorInfoRouter {
+	return &SensorInfoRouter{
+		Router: services.ForService(traits.MotionSensorSensorInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewMotionSensorSensorInfoClient(conn)
+		}),
+	}
+}
+
 // WithMotionSensorSensorInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithMotionSensorSensorInfoClientFactory(f func(name string) (traits.MotionSensorSensorInfoClient, error)) router.Option {
@@ -49,24 +60,25 @@ func (r *SensorInfoRouter) Add(name string, client any) any {
 }
 
 func (r *SensorInfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.MotionSensorSensorInfoClient)
 	return ok
 }
 
+// AddMotionSensorSensorInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.MotionSensorSensorInfoClient, for example a router.Group.
 func (r *SensorInfoRouter) AddMotionSensorSensorInfoClient(name string, client traits.MotionSensorSensorInfoClient) traits.MotionSensorSensorInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MotionSensorSensorInfoClient)
+	res, _ := r.Add(name, client).(traits.MotionSensorSensorInfoClient)
+	return res
 }
 
+// RemoveMotionSensorSensorInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.MotionSensorSensorInfoClient, for example a router.Group.
 func (r *SensorInfoRouter) RemoveMotionSensorSensorInfoClient(name string) traits.MotionSensorSensorInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.MotionSensorSensorInfoClient)
+	res, _ := r.Remove(name).(traits.MotionSensorSensorInfoClient)
+	return res
 }
 
 func (r *SensorInfoRouter) GetMotionSensorSensorInfoClient(name string) (traits.MotionSensorSensorInfoClient, error) {
@@ -80,11 +92,30 @@ func (r *SensorInfoRouter) GetMotionSensorSensorInfoClient(name string) (traits.
 	return res.(traits.MotionSensorSensorInfoClient), nil
 }
 
-func (r *SensorInfoRouter) DescribeMotionDetection(ctx context.Context, request *traits.DescribeMotionDetectionRequest) (*traits.MotionDetectionSupport, error) {
-	child, err := r.GetMotionSensorSensorInfoClient(request.Name)
+// ResolveMotionSensorSensorInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *SensorInfoRouter) ResolveMotionSensorSensorInfoClient(name string) (traits.MotionSensorSensorInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.MotionSensorSensorInfoClient), target, nil
+}
 
-	return child.DescribeMotionDetection(ctx, request)
+func (r *SensorInfoRouter) DescribeMotionDetection(ctx context.Context, request *traits.DescribeMotionDetectionRequest) (*traits.MotionDetectionSupport, error) {
+	var res *traits.MotionDetectionSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeMotionDetectionRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.MotionSensorSensorInfoClient).DescribeMotionDetection(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.OccupancySensorApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewOccupancySensorApiClient(conn)
+		}),
+	}
+}
+
 // WithOccupancySensorApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithOccupancySensorApiClientFactory(f func(name string) (traits.OccupancySensorApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.OccupancySensorApiClient)
 	return ok
 }
 
+// AddOccupancySensorApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.OccupancySensorApiClient, for example a router.Group.
 func (r *ApiRouter) AddOccupancySensorApiClient(name string, client traits.OccupancySensorApiClient) traits.OccupancySensorApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OccupancySensorApiClient)
+	res, _ := r.Add(name, client).(traits.OccupancySensorApiClient)
+	return res
 }
 
+// RemoveOccupancySensorApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.OccupancySensorApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveOccupancySensorApiClient(name string) traits.OccupancySensorApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OccupancySensorApiClient)
+	res, _ := r.Remove(name).(traits.OccupancySensorApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetOccupancySensorApiClient(name string) (traits.OccupancySensorApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetOccupancySensorApiClient(name string) (traits.OccupancySe
 	return res.(traits.OccupancySensorApiClient), nil
 }
 
-func (r *ApiRouter) GetOccupancy(ctx context.Context, request *traits.GetOccupancyRequest) (*traits.Occupancy, error) {
-	child, err := r.GetOccupancySensorApiClient(request.Name)
+// ResolveOccupancySensorApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveOccupancySensorApiClient(name string) (traits.OccupancySensorApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetOccupancy(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.OccupancySensorApiClient), target, nil
 }
 
-func (r *ApiRouter) PullOccupancy(request *traits.PullOccupancyRequest, server traits.OccupancySensorApi_PullOccupancyServer) error {
-	child, err := r.GetOccupancySensorApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetOccupancy(ctx context.Context, request *traits.GetOccupancyRequest) (*traits.Occupancy, error) {
+	var res *traits.Occupancy
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetOccupancyRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OccupancySensorApiClient).GetOccupancy(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullOccupancy(request *traits.PullOccupancyRequest, server traits.OccupancySensorApi_PullOccupancyServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullOccupancy(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.OccupancySensorApi_PullOccupancyClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullOccupancyRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.OccupancySensorApiClient).PullOccupancy(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.OccupancySensorInfoServer that allows routing named requests to specific traits.OccupancySensorInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.OccupancySensorInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewOccupancySensorInfoClient(conn)
+		}),
+	}
+}
+
 // WithOccupancySensorInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithOccupancySensorInfoClientFactory(f func(name string) (traits.OccupancySensorInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.OccupancySensorInfoClient)
 	return ok
 }
 
+// AddOccupancySensorInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.OccupancySensorInfoClient, for example a router.Group.
 func (r *InfoRouter) AddOccupancySensorInfoClient(name string, client traits.OccupancySensorInfoClient) traits.OccupancySensorInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OccupancySensorInfoClient)
+	res, _ := r.Add(name, client).(traits.OccupancySensorInfoClient)
+	return res
 }
 
+// RemoveOccupancySensorInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.OccupancySensorInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveOccupancySensorInfoClient(name string) traits.OccupancySensorInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OccupancySensorInfoClient)
+	res, _ := r.Remove(name).(traits.OccupancySensorInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetOccupancySensorInfoClient(name string) (traits.OccupancySensorInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetOccupancySensorInfoClient(name string) (traits.Occupancy
 	return res.(traits.OccupancySensorInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeOccupancy(ctx context.Context, request *traits.DescribeOccupancyRequest) (*traits.OccupancySupport, error) {
-	child, err := r.GetOccupancySensorInfoClient(request.Name)
+// ResolveOccupancySensorInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveOccupancySensorInfoClient(name string) (traits.OccupancySensorInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.OccupancySensorInfoClient), target, nil
+}
 
-	return child.DescribeOccupancy(ctx, request)
+func (r *InfoRouter) DescribeOccupancy(ctx context.Context, request *traits.DescribeOccupancyRequest) (*traits.OccupancySupport, error) {
+	var res *traits.OccupancySupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeOccupancyRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OccupancySensorInfoClient).DescribeOccupancy(ctx, request)
+		return err
+	})
+	return res, err
 }
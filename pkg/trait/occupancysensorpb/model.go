@@ -19,6 +19,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"occupancy": m.occupancy,
+	}
+}
+
 // SetOccupancy updates the known occupancy state for this device
 func (m *Model) SetOccupancy(occupancy *traits.Occupancy, opts ...resource.WriteOption) (*traits.Occupancy, error) {
 	res, err := m.occupancy.Set(occupancy, opts...)
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.OnOffApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewOnOffApiClient(conn)
+		}),
+	}
+}
+
 // WithOnOffApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithOnOffApiClientFactory(f func(name string) (traits.OnOffApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.OnOffApiClient)
 	return ok
 }
 
+// AddOnOffApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.OnOffApiClient, for example a router.Group.
 func (r *ApiRouter) AddOnOffApiClient(name string, client traits.OnOffApiClient) traits.OnOffApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OnOffApiClient)
+	res, _ := r.Add(name, client).(traits.OnOffApiClient)
+	return res
 }
 
+// RemoveOnOffApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.OnOffApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveOnOffApiClient(name string) traits.OnOffApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OnOffApiClient)
+	res, _ := r.Remove(name).(traits.OnOffApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetOnOffApiClient(name string) (traits.OnOffApiClient, error) {
@@ -79,43 +92,84 @@ func (r *ApiRouter) GetOnOffApiClient(name string) (traits.OnOffApiClient, error
 	return res.(traits.OnOffApiClient), nil
 }
 
-func (r *ApiRouter) GetOnOff(ctx context.Context, request *traits.GetOnOffRequest) (*traits.OnOff, error) {
-	child, err := r.GetOnOffApiClient(request.Name)
+// ResolveOnOffApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveOnOffApiClient(name string) (traits.OnOffApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.OnOffApiClient), target, nil
+}
 
-	return child.GetOnOff(ctx, request)
+func (r *ApiRouter) GetOnOff(ctx context.Context, request *traits.GetOnOffRequest) (*traits.OnOff, error) {
+	var res *traits.OnOff
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetOnOffRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OnOffApiClient).GetOnOff(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateOnOff(ctx context.Context, request *traits.UpdateOnOffRequest) (*traits.OnOff, error) {
-	child, err := r.GetOnOffApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateOnOff(ctx, request)
+	var res *traits.OnOff
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateOnOffRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OnOffApiClient).UpdateOnOff(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullOnOff(request *traits.PullOnOffRequest, server traits.OnOffApi_PullOnOffServer) error {
-	child, err := r.GetOnOffApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullOnOff(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.OnOffApi_PullOnOffClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullOnOffRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.OnOffApiClient).PullOnOff(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -0,0 +1,152 @@
+package onoffpb
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-golang/pkg/router"
+)
+
+func TestApiRouter_prefixStripping(t *testing.T) {
+	r := NewApiRouter(router.WithPrefixStripping())
+	srv := &nameRecordingServer{}
+	r.Add("site/building-a/**", WrapApi(srv))
+
+	request := &traits.GetOnOffRequest{Name: "site/building-a/floor-1/light"}
+	if _, err := r.GetOnOff(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.lastName.Load(); got != "floor-1/light" {
+		t.Fatalf("want name floor-1/light, got %v", got)
+	}
+	if request.Name != "site/building-a/floor-1/light" {
+		t.Fatalf("want the callers request unchanged, got name %v", request.Name)
+	}
+}
+
+func TestApiRouter_group(t *testing.T) {
+	primary := NewModel(WithInitialOnOff(&traits.OnOff{State: traits.OnOff_ON}))
+	backup := NewModel(WithInitialOnOff(&traits.OnOff{State: traits.OnOff_OFF}))
+	var primaryDown atomic.Bool
+	g := router.NewGroup(router.PrimaryBackup, []router.Member{
+		{Client: WrapApi(NewModelServer(primary)), Check: func(ctx context.Context) error {
+			if primaryDown.Load() {
+				return errors.New("down")
+			}
+			return nil
+		}},
+		{Client: WrapApi(NewModelServer(backup))},
+	}, router.WithCheckInterval(time.Hour))
+	t.Cleanup(func() { g.Close() })
+
+	r := NewApiRouter()
+	r.Add("light", g)
+	assertState := func(want traits.OnOff_State) {
+		t.Helper()
+		got, err := r.GetOnOff(context.Background(), &traits.GetOnOffRequest{Name: "light"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.State != want {
+			t.Fatalf("want %v, got %v", want, got.State)
+		}
+	}
+
+	assertState(traits.OnOff_ON)
+	primaryDown.Store(true)
+	g.Check(context.Background())
+	assertState(traits.OnOff_OFF)
+
+	bad := router.NewGroup(router.PrimaryBackup, []router.Member{{Client: "not a client"}})
+	t.Cleanup(func() { bad.Close() })
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("want panic adding a group with the wrong client type")
+		}
+	}()
+	r.Add("bad", bad)
+}
+
+func TestApiRouter_removeGroup(t *testing.T) {
+	g := router.NewGroup(router.PrimaryBackup, []router.Member{{Client: WrapApi(NewModelServer(NewModel()))}})
+	t.Cleanup(func() { g.Close() })
+
+	r := NewApiRouter()
+	r.Add("light", g)
+	if old := r.AddOnOffApiClient("light", WrapApi(NewModelServer(NewModel()))); old != nil {
+		t.Fatalf("want nil replacing a group, got %v", old)
+	}
+	r.Add("light", g)
+	if old := r.RemoveOnOffApiClient("light"); old != nil {
+		t.Fatalf("want nil removing a group, got %v", old)
+	}
+	if r.Has("light") {
+		t.Fatalf("want group removed")
+	}
+}
+
+func TestApiRouter_failover(t *testing.T) {
+	// a new group for each call so the primary is always tried first
+	newClient := func() traits.OnOffApiClient {
+		backup := NewModel(WithInitialOnOff(&traits.OnOff{State: traits.OnOff_ON}))
+		g := router.NewGroup(router.PrimaryBackup, []router.Member{
+			{Client: WrapApi(unavailableServer{})},
+			{Client: WrapApi(NewModelServer(backup))},
+		}, router.WithCheckInterval(time.Hour))
+		t.Cleanup(func() { g.Close() })
+		r := NewApiRouter()
+		r.Add("light", g)
+		return WrapApi(r)
+	}
+
+	got, err := newClient().GetOnOff(context.Background(), &traits.GetOnOffRequest{Name: "light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != traits.OnOff_ON {
+		t.Fatalf("want backup state ON, got %v", got.State)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	stream, err := newClient().PullOnOff(ctx, &traits.PullOnOffRequest{Name: "light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := res.Changes[0].OnOff.State; state != traits.OnOff_ON {
+		t.Fatalf("want backup state ON from pull, got %v", state)
+	}
+}
+
+type unavailableServer struct {
+	traits.UnimplementedOnOffApiServer
+}
+
+func (unavailableServer) GetOnOff(context.Context, *traits.GetOnOffRequest) (*traits.OnOff, error) {
+	return nil, status.Error(codes.Unavailable, "down")
+}
+
+func (unavailableServer) PullOnOff(*traits.PullOnOffRequest, traits.OnOffApi_PullOnOffServer) error {
+	return status.Error(codes.Unavailable, "down")
+}
+
+type nameRecordingServer struct {
+	traits.UnimplementedOnOffApiServer
+	lastName atomic.Value
+}
+
+func (s *nameRecordingServer) GetOnOff(_ context.Context, req *traits.GetOnOffRequest) (*traits.OnOff, error) {
+	s.lastName.Store(req.Name)
+	return &traits.OnOff{}, nil
+}
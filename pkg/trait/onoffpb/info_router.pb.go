@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.OnOffInfoServer that allows routing named requests to specific traits.OnOffInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.OnOffInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewOnOffInfoClient(conn)
+		}),
+	}
+}
+
 // WithOnOffInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithOnOffInfoClientFactory(f func(name string) (traits.OnOffInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.OnOffInfoClient)
 	return ok
 }
 
+// AddOnOffInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.OnOffInfoClient, for example a router.Group.
 func (r *InfoRouter) AddOnOffInfoClient(name string, client traits.OnOffInfoClient) traits.OnOffInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OnOffInfoClient)
+	res, _ := r.Add(name, client).(traits.OnOffInfoClient)
+	return res
 }
 
+// RemoveOnOffInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.OnOffInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveOnOffInfoClient(name string) traits.OnOffInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OnOffInfoClient)
+	res, _ := r.Remove(name).(traits.OnOffInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetOnOffInfoClient(name string) (traits.OnOffInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetOnOffInfoClient(name string) (traits.OnOffInfoClient, er
 	return res.(traits.OnOffInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeOnOff(ctx context.Context, request *traits.DescribeOnOffRequest) (*traits.OnOffSupport, error) {
-	child, err := r.GetOnOffInfoClient(request.Name)
+// ResolveOnOffInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveOnOffInfoClient(name string) (traits.OnOffInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.OnOffInfoClient), target, nil
+}
 
-	return child.DescribeOnOff(ctx, request)
+func (r *InfoRouter) DescribeOnOff(ctx context.Context, request *traits.DescribeOnOffRequest) (*traits.OnOffSupport, error) {
+	var res *traits.OnOffSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeOnOffRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OnOffInfoClient).DescribeOnOff(ctx, request)
+		return err
+	})
+	return res, err
 }
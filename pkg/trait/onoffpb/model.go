@@ -19,6 +19,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"onOff": m.onOff,
+	}
+}
+
 func (m *Model) GetOnOff(opts ...resource.ReadOption) (*traits.OnOff, error) {
 	return m.onOff.Get(opts...).(*traits.OnOff), nil
 }
@@ -0,0 +1,28 @@
+package onoffpb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smart-core-os/sc-golang/pkg/resource"
+)
+
+func TestModelServer_PullOnOff_tooManySubscribers(t *testing.T) {
+	model := NewModel(resource.WithInitialValue(&traits.OnOff{}), resource.WithMaxSubscribers(1))
+	client := WrapApi(NewModelServer(model))
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	_ = model.PullOnOff(ctx)
+	stream, err := client.PullOnOff(ctx, &traits.PullOnOffRequest{Name: "light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("want ResourceExhausted, got %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.OpenCloseApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewOpenCloseApiClient(conn)
+		}),
+	}
+}
+
 // WithOpenCloseApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithOpenCloseApiClientFactory(f func(name string) (traits.OpenCloseApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.OpenCloseApiClient)
 	return ok
 }
 
+// AddOpenCloseApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.OpenCloseApiClient, for example a router.Group.
 func (r *ApiRouter) AddOpenCloseApiClient(name string, client traits.OpenCloseApiClient) traits.OpenCloseApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OpenCloseApiClient)
+	res, _ := r.Add(name, client).(traits.OpenCloseApiClient)
+	return res
 }
 
+// RemoveOpenCloseApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.OpenCloseApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveOpenCloseApiClient(name string) traits.OpenCloseApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OpenCloseApiClient)
+	res, _ := r.Remove(name).(traits.OpenCloseApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetOpenCloseApiClient(name string) (traits.OpenCloseApiClient, error) {
@@ -79,52 +92,99 @@ func (r *ApiRouter) GetOpenCloseApiClient(name string) (traits.OpenCloseApiClien
 	return res.(traits.OpenCloseApiClient), nil
 }
 
-func (r *ApiRouter) GetPositions(ctx context.Context, request *traits.GetOpenClosePositionsRequest) (*traits.OpenClosePositions, error) {
-	child, err := r.GetOpenCloseApiClient(request.Name)
+// ResolveOpenCloseApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveOpenCloseApiClient(name string) (traits.OpenCloseApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.OpenCloseApiClient), target, nil
+}
 
-	return child.GetPositions(ctx, request)
+func (r *ApiRouter) GetPositions(ctx context.Context, request *traits.GetOpenClosePositionsRequest) (*traits.OpenClosePositions, error) {
+	var res *traits.OpenClosePositions
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetOpenClosePositionsRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OpenCloseApiClient).GetPositions(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdatePositions(ctx context.Context, request *traits.UpdateOpenClosePositionsRequest) (*traits.OpenClosePositions, error) {
-	child, err := r.GetOpenCloseApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdatePositions(ctx, request)
+	var res *traits.OpenClosePositions
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateOpenClosePositionsRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OpenCloseApiClient).UpdatePositions(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) Stop(ctx context.Context, request *traits.StopOpenCloseRequest) (*traits.OpenClosePositions, error) {
-	child, err := r.GetOpenCloseApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.Stop(ctx, request)
+	var res *traits.OpenClosePositions
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.StopOpenCloseRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OpenCloseApiClient).Stop(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullPositions(request *traits.PullOpenClosePositionsRequest, server traits.OpenCloseApi_PullPositionsServer) error {
-	child, err := r.GetOpenCloseApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullPositions(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.OpenCloseApi_PullPositionsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullOpenClosePositionsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.OpenCloseApiClient).PullPositions(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
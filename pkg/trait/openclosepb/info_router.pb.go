@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.OpenCloseInfoServer that allows routing named requests to specific traits.OpenCloseInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.OpenCloseInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewOpenCloseInfoClient(conn)
+		}),
+	}
+}
+
 // WithOpenCloseInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithOpenCloseInfoClientFactory(f func(name string) (traits.OpenCloseInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.OpenCloseInfoClient)
 	return ok
 }
 
+// AddOpenCloseInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.OpenCloseInfoClient, for example a router.Group.
 func (r *InfoRouter) AddOpenCloseInfoClient(name string, client traits.OpenCloseInfoClient) traits.OpenCloseInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OpenCloseInfoClient)
+	res, _ := r.Add(name, client).(traits.OpenCloseInfoClient)
+	return res
 }
 
+// RemoveOpenCloseInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.OpenCloseInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveOpenCloseInfoClient(name string) traits.OpenCloseInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.OpenCloseInfoClient)
+	res, _ := r.Remove(name).(traits.OpenCloseInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetOpenCloseInfoClient(name string) (traits.OpenCloseInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetOpenCloseInfoClient(name string) (traits.OpenCloseInfoCl
 	return res.(traits.OpenCloseInfoClient), nil
 }
 
-func (r *InfoRouter) DescribePositions(ctx context.Context, request *traits.DescribePositionsRequest) (*traits.PositionsSupport, error) {
-	child, err := r.GetOpenCloseInfoClient(request.Name)
+// ResolveOpenCloseInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveOpenCloseInfoClient(name string) (traits.OpenCloseInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.OpenCloseInfoClient), target, nil
+}
 
-	return child.DescribePositions(ctx, request)
+func (r *InfoRouter) DescribePositions(ctx context.Context, request *traits.DescribePositionsRequest) (*traits.PositionsSupport, error) {
+	var res *traits.PositionsSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribePositionsRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.OpenCloseInfoClient).DescribePositions(ctx, request)
+		return err
+	})
+	return res, err
 }
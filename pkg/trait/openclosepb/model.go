@@ -32,6 +32,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"positions": m.positions,
+	}
+}
+
 // preset describes a proto preset combined with the positions applied when activating that preset.
 type preset struct {
 	desc      *traits.OpenClosePositions_Preset
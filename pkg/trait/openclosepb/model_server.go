@@ -38,7 +38,8 @@ func (s *ModelServer) UpdatePositions(_ context.Context, request *traits.UpdateO
 }
 
 func (s *ModelServer) PullPositions(request *traits.PullOpenClosePositionsRequest, server traits.OpenCloseApi_PullPositionsServer) error {
-	for change := range s.model.PullPositions(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.GetUpdatesOnly())) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range s.model.PullPositions(server.Context(), resource.WithReadMask(request.GetReadMask()), resource.WithUpdatesOnly(request.GetUpdatesOnly()), rejected) {
 		msg := &traits.PullOpenClosePositionsResponse{Changes: []*traits.PullOpenClosePositionsResponse_Change{{
 			Name:              request.Name,
 			ChangeTime:        timestamppb.New(change.ChangeTime),
@@ -48,7 +49,7 @@ func (s *ModelServer) PullPositions(request *traits.PullOpenClosePositionsReques
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (s *ModelServer) DescribePositions(_ context.Context, _ *traits.DescribePositionsRequest) (*traits.PositionsSupport, error) {
@@ -25,6 +25,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.ParentInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewParentInfoClient(conn)
+		}),
+	}
+}
+
 // WithParentInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithParentInfoClientFactory(f func(name string) (traits.ParentInfoClient, error)) router.Option {
@@ -46,24 +56,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.ParentInfoClient)
 	return ok
 }
 
+// AddParentInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.ParentInfoClient, for example a router.Group.
 func (r *InfoRouter) AddParentInfoClient(name string, client traits.ParentInfoClient) traits.ParentInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ParentInfoClient)
+	res, _ := r.Add(name, client).(traits.ParentInfoClient)
+	return res
 }
 
+// RemoveParentInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.ParentInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveParentInfoClient(name string) traits.ParentInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.ParentInfoClient)
+	res, _ := r.Remove(name).(traits.ParentInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetParentInfoClient(name string) (traits.ParentInfoClient, error) {
@@ -76,3 +87,16 @@ func (r *InfoRouter) GetParentInfoClient(name string) (traits.ParentInfoClient,
 	}
 	return res.(traits.ParentInfoClient), nil
 }
+
+// ResolveParentInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveParentInfoClient(name string) (traits.ParentInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
+	if err != nil {
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.ParentInfoClient), target, nil
+}
@@ -28,6 +28,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"children": m.children,
+	}
+}
+
 // AddChild inserts a child into this model.
 // If a child with the given Child.Name already exists, no changes will be made.
 // Panics if child.Traits are not sorted in ascending order, required by AddChildTrait.
@@ -110,6 +117,23 @@ func (m *Model) ListChildren() []*traits.Child {
 	return children
 }
 
+// ListChildrenPage returns a page of children, see resource.Collection.ListPage.
+func (m *Model) ListChildrenPage(pageSize int, pageToken string, opts ...resource.ReadOption) (*traits.ListChildrenResponse, error) {
+	page, err := m.children.ListPage(pageSize, pageToken, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &traits.ListChildrenResponse{
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int32(page.TotalSize),
+		Children:      make([]*traits.Child, len(page.Items)),
+	}
+	for i, msg := range page.Items {
+		res.Children[i] = msg.(*traits.Child)
+	}
+	return res, nil
+}
+
 // PullChildren returns a chan that will emit when changes are made to the known children of this model.
 func (m *Model) PullChildren(ctx context.Context, opts ...resource.ReadOption) <-chan *traits.PullChildrenResponse_Change {
 	out := make(chan *traits.PullChildrenResponse_Change)
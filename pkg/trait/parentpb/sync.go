@@ -0,0 +1,37 @@
+package parentpb
+
+import (
+	"context"
+
+	"github.com/smart-core-os/sc-golang/pkg/router"
+	"github.com/smart-core-os/sc-golang/pkg/trait"
+)
+
+// SyncRouter keeps the children of m in sync with the names known to r, until ctx is done.
+// Each name in r is added as a child of m supporting traitName, names removed from r, or evicted, have traitName
+// removed from their child, removing the child if it has no other traits.
+// Patterns like "a/**" are not names and are ignored, as are names that are only reachable via a Factory or Fallback
+// until they are created.
+//
+// SyncRouter blocks until ctx is done, returning ctx.Err(), or returns immediately if r does not support router.Pull.
+// Use SyncRouter with the router for each trait a device supports to describe all of them as children of m.
+func (m *Model) SyncRouter(ctx context.Context, r router.Router, traitName ...trait.Name) error {
+	changes, err := router.Pull(ctx, r)
+	if err != nil {
+		return err
+	}
+	for change := range changes {
+		if router.IsPattern(change.Name) {
+			continue
+		}
+		if change.New != nil {
+			m.AddChildTrait(change.Name, traitName...)
+			continue
+		}
+		child := m.RemoveChildTrait(change.Name, traitName...)
+		if child != nil && len(child.Traits) == 0 {
+			_, _ = m.RemoveChildByName(change.Name)
+		}
+	}
+	return ctx.Err()
+}
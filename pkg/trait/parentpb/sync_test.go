@@ -0,0 +1,63 @@
+package parentpb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/smart-core-os/sc-api/go/traits"
+	"github.com/smart-core-os/sc-golang/pkg/router"
+	"github.com/smart-core-os/sc-golang/pkg/trait"
+)
+
+func TestModel_SyncRouter(t *testing.T) {
+	model := NewModel()
+	onOff := router.NewRouter()
+	light := router.NewRouter()
+	onOff.Add("lamp", "lamp")
+	onOff.Add("gateway/**", "gateway")
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	done := make(chan error, 2)
+	go func() { done <- model.SyncRouter(ctx, onOff, trait.OnOff) }()
+	go func() { done <- model.SyncRouter(ctx, light, trait.Light) }()
+
+	waitForChildren(t, model, []*traits.Child{
+		{Name: "lamp", Traits: []*traits.Trait{{Name: string(trait.OnOff)}}},
+	})
+
+	light.Add("lamp", "lamp")
+	light.Add("spot", "spot")
+	waitForChildren(t, model, []*traits.Child{
+		{Name: "lamp", Traits: []*traits.Trait{{Name: string(trait.Light)}, {Name: string(trait.OnOff)}}},
+		{Name: "spot", Traits: []*traits.Trait{{Name: string(trait.Light)}}},
+	})
+
+	onOff.Remove("lamp")
+	light.Remove("spot")
+	waitForChildren(t, model, []*traits.Child{
+		{Name: "lamp", Traits: []*traits.Trait{{Name: string(trait.Light)}}},
+	})
+
+	stop()
+	for range 2 {
+		if err := <-done; err != context.Canceled {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	}
+}
+
+func waitForChildren(t *testing.T, model *Model, want []*traits.Child) {
+	t.Helper()
+	var diff string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if diff = cmp.Diff(want, model.ListChildren(), protocmp.Transform()); diff == "" {
+			return
+		}
+	}
+	t.Fatalf("children (-want,+got)\n%s", diff)
+}
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.PressApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewPressApiClient(conn)
+		}),
+	}
+}
+
 // WithPressApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithPressApiClientFactory(f func(name string) (traits.PressApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.PressApiClient)
 	return ok
 }
 
+// AddPressApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.PressApiClient, for example a router.Group.
 func (r *ApiRouter) AddPressApiClient(name string, client traits.PressApiClient) traits.PressApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PressApiClient)
+	res, _ := r.Add(name, client).(traits.PressApiClient)
+	return res
 }
 
+// RemovePressApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.PressApiClient, for example a router.Group.
 func (r *ApiRouter) RemovePressApiClient(name string) traits.PressApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PressApiClient)
+	res, _ := r.Remove(name).(traits.PressApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetPressApiClient(name string) (traits.PressApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetPressApiClient(name string) (traits.PressApiClient, error
 	return res.(traits.PressApiClient), nil
 }
 
-func (r *ApiRouter) GetPressedState(ctx context.Context, request *traits.GetPressedStateRequest) (*traits.PressedState, error) {
-	child, err := r.GetPressApiClient(request.Name)
+// ResolvePressApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolvePressApiClient(name string) (traits.PressApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetPressedState(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.PressApiClient), target, nil
 }
 
-func (r *ApiRouter) PullPressedState(request *traits.PullPressedStateRequest, server traits.PressApi_PullPressedStateServer) error {
-	child, err := r.GetPressApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetPressedState(ctx context.Context, request *traits.GetPressedStateRequest) (*traits.PressedState, error) {
+	var res *traits.PressedState
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetPressedStateRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PressApiClient).GetPressedState(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullPressedState(request *traits.PullPressedStateRequest, server traits.PressApi_PullPressedStateServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullPressedState(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.PressApi_PullPressedStateClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullPressedStateRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.PressApiClient).PullPressedState(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -148,10 +196,16 @@ func (r *ApiRouter) PullPressedState(request *traits.PullPressedStateRequest, se
 }
 
 func (r *ApiRouter) UpdatePressedState(ctx context.Context, request *traits.UpdatePressedStateRequest) (*traits.PressedState, error) {
-	child, err := r.GetPressApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdatePressedState(ctx, request)
+	var res *traits.PressedState
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdatePressedStateRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PressApiClient).UpdatePressedState(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -20,6 +20,13 @@ func NewModel(initialPressState traits.PressedState_Press) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"pressedState": m.pressedState,
+	}
+}
+
 func (m *Model) GetPressedState(options ...resource.ReadOption) *traits.PressedState {
 	return m.pressedState.Get(options...).(*traits.PressedState)
 }
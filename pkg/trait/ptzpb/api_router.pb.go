@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.PtzApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewPtzApiClient(conn)
+		}),
+	}
+}
+
 // WithPtzApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithPtzApiClientFactory(f func(name string) (traits.PtzApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.PtzApiClient)
 	return ok
 }
 
+// AddPtzApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.PtzApiClient, for example a router.Group.
 func (r *ApiRouter) AddPtzApiClient(name string, client traits.PtzApiClient) traits.PtzApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PtzApiClient)
+	res, _ := r.Add(name, client).(traits.PtzApiClient)
+	return res
 }
 
+// RemovePtzApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.PtzApiClient, for example a router.Group.
 func (r *ApiRouter) RemovePtzApiClient(name string) traits.PtzApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PtzApiClient)
+	res, _ := r.Remove(name).(traits.PtzApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetPtzApiClient(name string) (traits.PtzApiClient, error) {
@@ -79,61 +92,114 @@ func (r *ApiRouter) GetPtzApiClient(name string) (traits.PtzApiClient, error) {
 	return res.(traits.PtzApiClient), nil
 }
 
-func (r *ApiRouter) GetPtz(ctx context.Context, request *traits.GetPtzRequest) (*traits.Ptz, error) {
-	child, err := r.GetPtzApiClient(request.Name)
+// ResolvePtzApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolvePtzApiClient(name string) (traits.PtzApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.PtzApiClient), target, nil
+}
 
-	return child.GetPtz(ctx, request)
+func (r *ApiRouter) GetPtz(ctx context.Context, request *traits.GetPtzRequest) (*traits.Ptz, error) {
+	var res *traits.Ptz
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetPtzRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PtzApiClient).GetPtz(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdatePtz(ctx context.Context, request *traits.UpdatePtzRequest) (*traits.Ptz, error) {
-	child, err := r.GetPtzApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdatePtz(ctx, request)
+	var res *traits.Ptz
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdatePtzRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PtzApiClient).UpdatePtz(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) Stop(ctx context.Context, request *traits.StopPtzRequest) (*traits.Ptz, error) {
-	child, err := r.GetPtzApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.Stop(ctx, request)
+	var res *traits.Ptz
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.StopPtzRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PtzApiClient).Stop(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) CreatePreset(ctx context.Context, request *traits.CreatePtzPresetRequest) (*traits.PtzPreset, error) {
-	child, err := r.GetPtzApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.CreatePreset(ctx, request)
+	var res *traits.PtzPreset
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.CreatePtzPresetRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PtzApiClient).CreatePreset(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullPtz(request *traits.PullPtzRequest, server traits.PtzApi_PullPtzServer) error {
-	child, err := r.GetPtzApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullPtz(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.PtzApi_PullPtzClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullPtzRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.PtzApiClient).PullPtz(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.PtzInfoServer that allows routing named requests to specific traits.PtzInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.PtzInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewPtzInfoClient(conn)
+		}),
+	}
+}
+
 // WithPtzInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithPtzInfoClientFactory(f func(name string) (traits.PtzInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.PtzInfoClient)
 	return ok
 }
 
+// AddPtzInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.PtzInfoClient, for example a router.Group.
 func (r *InfoRouter) AddPtzInfoClient(name string, client traits.PtzInfoClient) traits.PtzInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PtzInfoClient)
+	res, _ := r.Add(name, client).(traits.PtzInfoClient)
+	return res
 }
 
+// RemovePtzInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.PtzInfoClient, for example a router.Group.
 func (r *InfoRouter) RemovePtzInfoClient(name string) traits.PtzInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PtzInfoClient)
+	res, _ := r.Remove(name).(traits.PtzInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetPtzInfoClient(name string) (traits.PtzInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetPtzInfoClient(name string) (traits.PtzInfoClient, error)
 	return res.(traits.PtzInfoClient), nil
 }
 
-func (r *InfoRouter) DescribePtz(ctx context.Context, request *traits.DescribePtzRequest) (*traits.PtzSupport, error) {
-	child, err := r.GetPtzInfoClient(request.Name)
+// ResolvePtzInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolvePtzInfoClient(name string) (traits.PtzInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.PtzInfoClient), target, nil
+}
 
-	return child.DescribePtz(ctx, request)
+func (r *InfoRouter) DescribePtz(ctx context.Context, request *traits.DescribePtzRequest) (*traits.PtzSupport, error) {
+	var res *traits.PtzSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribePtzRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PtzInfoClient).DescribePtz(ctx, request)
+		return err
+	})
+	return res, err
 }
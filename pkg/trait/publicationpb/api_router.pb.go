@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.PublicationApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewPublicationApiClient(conn)
+		}),
+	}
+}
+
 // WithPublicationApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithPublicationApiClientFactory(f func(name string) (traits.PublicationApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.PublicationApiClient)
 	return ok
 }
 
+// AddPublicationApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.PublicationApiClient, for example a router.Group.
 func (r *ApiRouter) AddPublicationApiClient(name string, client traits.PublicationApiClient) traits.PublicationApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PublicationApiClient)
+	res, _ := r.Add(name, client).(traits.PublicationApiClient)
+	return res
 }
 
+// RemovePublicationApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.PublicationApiClient, for example a router.Group.
 func (r *ApiRouter) RemovePublicationApiClient(name string) traits.PublicationApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.PublicationApiClient)
+	res, _ := r.Remove(name).(traits.PublicationApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetPublicationApiClient(name string) (traits.PublicationApiClient, error) {
@@ -79,61 +92,114 @@ func (r *ApiRouter) GetPublicationApiClient(name string) (traits.PublicationApiC
 	return res.(traits.PublicationApiClient), nil
 }
 
-func (r *ApiRouter) CreatePublication(ctx context.Context, request *traits.CreatePublicationRequest) (*traits.Publication, error) {
-	child, err := r.GetPublicationApiClient(request.Name)
+// ResolvePublicationApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolvePublicationApiClient(name string) (traits.PublicationApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.PublicationApiClient), target, nil
+}
 
-	return child.CreatePublication(ctx, request)
+func (r *ApiRouter) CreatePublication(ctx context.Context, request *traits.CreatePublicationRequest) (*traits.Publication, error) {
+	var res *traits.Publication
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.CreatePublicationRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PublicationApiClient).CreatePublication(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) GetPublication(ctx context.Context, request *traits.GetPublicationRequest) (*traits.Publication, error) {
-	child, err := r.GetPublicationApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.GetPublication(ctx, request)
+	var res *traits.Publication
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetPublicationRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PublicationApiClient).GetPublication(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdatePublication(ctx context.Context, request *traits.UpdatePublicationRequest) (*traits.Publication, error) {
-	child, err := r.GetPublicationApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdatePublication(ctx, request)
+	var res *traits.Publication
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdatePublicationRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PublicationApiClient).UpdatePublication(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) DeletePublication(ctx context.Context, request *traits.DeletePublicationRequest) (*traits.Publication, error) {
-	child, err := r.GetPublicationApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.DeletePublication(ctx, request)
+	var res *traits.Publication
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DeletePublicationRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PublicationApiClient).DeletePublication(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullPublication(request *traits.PullPublicationRequest, server traits.PublicationApi_PullPublicationServer) error {
-	child, err := r.GetPublicationApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullPublication(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.PublicationApi_PullPublicationClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullPublicationRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.PublicationApiClient).PullPublication(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -175,33 +241,55 @@ func (r *ApiRouter) PullPublication(request *traits.PullPublicationRequest, serv
 }
 
 func (r *ApiRouter) ListPublications(ctx context.Context, request *traits.ListPublicationsRequest) (*traits.ListPublicationsResponse, error) {
-	child, err := r.GetPublicationApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ListPublications(ctx, request)
+	var res *traits.ListPublicationsResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListPublicationsRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PublicationApiClient).ListPublications(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullPublications(request *traits.PullPublicationsRequest, server traits.PublicationApi_PullPublicationsServer) error {
-	child, err := r.GetPublicationApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullPublications(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.PublicationApi_PullPublicationsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullPublicationsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.PublicationApiClient).PullPublications(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -243,10 +331,16 @@ func (r *ApiRouter) PullPublications(request *traits.PullPublicationsRequest, se
 }
 
 func (r *ApiRouter) AcknowledgePublication(ctx context.Context, request *traits.AcknowledgePublicationRequest) (*traits.Publication, error) {
-	child, err := r.GetPublicationApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.AcknowledgePublication(ctx, request)
+	var res *traits.Publication
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.AcknowledgePublicationRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.PublicationApiClient).AcknowledgePublication(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -26,6 +26,13 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"publications": m.publications,
+	}
+}
+
 func (m *Model) CreatePublication(publication *traits.Publication, opts ...resource.WriteOption) (*traits.Publication, error) {
 	args := calcWriteArgs(opts...)
 	return toPublication(m.publications.Add(publication.Id, publication,
@@ -84,6 +91,23 @@ func (m *Model) ListPublications(opts ...resource.ReadOption) []*traits.Publicat
 	return items
 }
 
+// ListPublicationsPage returns a page of publications, see resource.Collection.ListPage.
+func (m *Model) ListPublicationsPage(pageSize int, pageToken string, opts ...resource.ReadOption) (*traits.ListPublicationsResponse, error) {
+	page, err := m.publications.ListPage(pageSize, pageToken, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &traits.ListPublicationsResponse{
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int32(page.TotalSize),
+		Publications:  make([]*traits.Publication, len(page.Items)),
+	}
+	for i, msg := range page.Items {
+		res.Publications[i] = msg.(*traits.Publication)
+	}
+	return res, nil
+}
+
 type PublicationsChange struct {
 	ID         string
 	ChangeTime time.Time
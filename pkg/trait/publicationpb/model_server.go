@@ -2,7 +2,6 @@ package publicationpb
 
 import (
 	"context"
-	"sort"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -11,7 +10,6 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/smart-core-os/sc-api/go/traits"
-	"github.com/smart-core-os/sc-api/go/types"
 	"github.com/smart-core-os/sc-golang/pkg/resource"
 )
 
@@ -87,7 +85,8 @@ func (m *ModelServer) PullPublication(request *traits.PullPublicationRequest, se
 	if request.Id == "" {
 		return status.Error(codes.InvalidArgument, "id is required")
 	}
-	for change := range m.model.PullPublication(server.Context(), request.Id, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullPublication(server.Context(), request.Id, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullPublicationResponse{Changes: []*traits.PullPublicationResponse_Change{
 			{Name: request.Name, ChangeTime: timestamppb.New(change.ChangeTime), Publication: change.Value},
 		}})
@@ -95,50 +94,16 @@ func (m *ModelServer) PullPublication(request *traits.PullPublicationRequest, se
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (m *ModelServer) ListPublications(_ context.Context, request *traits.ListPublicationsRequest) (*traits.ListPublicationsResponse, error) {
-	pageToken := &types.PageToken{}
-	if err := decodePageToken(request.PageToken, pageToken); err != nil {
-		return nil, err
-	}
-
-	lastKey := pageToken.GetLastResourceName() // the key() of the last item we sent
-	pageSize := capPageSize(int(request.GetPageSize()))
-
-	sortedItems := m.model.ListPublications(resource.WithReadMask(request.ReadMask))
-	nextIndex := 0
-	if lastKey != "" {
-		nextIndex = sort.Search(len(sortedItems), func(i int) bool {
-			return sortedItems[i].Id > lastKey
-		})
-	}
-
-	result := &traits.ListPublicationsResponse{
-		TotalSize: int32(len(sortedItems)),
-	}
-	upperBound := nextIndex + pageSize
-	if upperBound > len(sortedItems) {
-		upperBound = len(sortedItems)
-		pageToken = nil
-	} else {
-		pageToken.PageStart = &types.PageToken_LastResourceName{
-			LastResourceName: sortedItems[upperBound-1].Id,
-		}
-	}
-
-	var err error
-	result.NextPageToken, err = encodePageToken(pageToken)
-	if err != nil {
-		return nil, err
-	}
-	result.Publications = sortedItems[nextIndex:upperBound]
-	return result, nil
+	return m.model.ListPublicationsPage(int(request.GetPageSize()), request.GetPageToken(), resource.WithReadMask(request.ReadMask))
 }
 
 func (m *ModelServer) PullPublications(request *traits.PullPublicationsRequest, server traits.PublicationApi_PullPublicationsServer) error {
-	for change := range m.model.PullPublications(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullPublications(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullPublicationsResponse{Changes: []*traits.PullPublicationsResponse_Change{
 			{Name: request.Name, Type: change.ChangeType, ChangeTime: timestamppb.New(change.ChangeTime), OldValue: change.OldValue, NewValue: change.NewValue},
 		}})
@@ -146,7 +111,7 @@ func (m *ModelServer) PullPublications(request *traits.PullPublicationsRequest,
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (m *ModelServer) AcknowledgePublication(_ context.Context, request *traits.AcknowledgePublicationRequest) (*traits.Publication, error) {
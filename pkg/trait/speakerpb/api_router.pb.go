@@ -9,6 +9,8 @@ import (
 	types "github.com/smart-core-os/sc-api/go/types"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -28,6 +30,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.SpeakerApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewSpeakerApiClient(conn)
+		}),
+	}
+}
+
 // WithSpeakerApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithSpeakerApiClientFactory(f func(name string) (traits.SpeakerApiClient, error)) router.Option {
@@ -49,24 +61,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.SpeakerApiClient)
 	return ok
 }
 
+// AddSpeakerApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.SpeakerApiClient, for example a router.Group.
 func (r *ApiRouter) AddSpeakerApiClient(name string, client traits.SpeakerApiClient) traits.SpeakerApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.SpeakerApiClient)
+	res, _ := r.Add(name, client).(traits.SpeakerApiClient)
+	return res
 }
 
+// RemoveSpeakerApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.SpeakerApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveSpeakerApiClient(name string) traits.SpeakerApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.SpeakerApiClient)
+	res, _ := r.Remove(name).(traits.SpeakerApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetSpeakerApiClient(name string) (traits.SpeakerApiClient, error) {
@@ -80,43 +93,84 @@ func (r *ApiRouter) GetSpeakerApiClient(name string) (traits.SpeakerApiClient, e
 	return res.(traits.SpeakerApiClient), nil
 }
 
-func (r *ApiRouter) GetVolume(ctx context.Context, request *traits.GetSpeakerVolumeRequest) (*types.AudioLevel, error) {
-	child, err := r.GetSpeakerApiClient(request.Name)
+// ResolveSpeakerApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveSpeakerApiClient(name string) (traits.SpeakerApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.SpeakerApiClient), target, nil
+}
 
-	return child.GetVolume(ctx, request)
+func (r *ApiRouter) GetVolume(ctx context.Context, request *traits.GetSpeakerVolumeRequest) (*types.AudioLevel, error) {
+	var res *types.AudioLevel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetSpeakerVolumeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.SpeakerApiClient).GetVolume(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateVolume(ctx context.Context, request *traits.UpdateSpeakerVolumeRequest) (*types.AudioLevel, error) {
-	child, err := r.GetSpeakerApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateVolume(ctx, request)
+	var res *types.AudioLevel
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateSpeakerVolumeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.SpeakerApiClient).UpdateVolume(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullVolume(request *traits.PullSpeakerVolumeRequest, server traits.SpeakerApi_PullVolumeServer) error {
-	child, err := r.GetSpeakerApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullVolume(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.SpeakerApi_PullVolumeClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullSpeakerVolumeRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.SpeakerApiClient).PullVolume(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.SpeakerInfoServer that allows routing named requests to specific traits.SpeakerInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.SpeakerInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewSpeakerInfoClient(conn)
+		}),
+	}
+}
+
 // WithSpeakerInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithSpeakerInfoClientFactory(f func(name string) (traits.SpeakerInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.SpeakerInfoClient)
 	return ok
 }
 
+// AddSpeakerInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.SpeakerInfoClient, for example a router.Group.
 func (r *InfoRouter) AddSpeakerInfoClient(name string, client traits.SpeakerInfoClient) traits.SpeakerInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.SpeakerInfoClient)
+	res, _ := r.Add(name, client).(traits.SpeakerInfoClient)
+	return res
 }
 
+// RemoveSpeakerInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.SpeakerInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveSpeakerInfoClient(name string) traits.SpeakerInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.SpeakerInfoClient)
+	res, _ := r.Remove(name).(traits.SpeakerInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetSpeakerInfoClient(name string) (traits.SpeakerInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetSpeakerInfoClient(name string) (traits.SpeakerInfoClient
 	return res.(traits.SpeakerInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeVolume(ctx context.Context, request *traits.DescribeVolumeRequest) (*traits.VolumeSupport, error) {
-	child, err := r.GetSpeakerInfoClient(request.Name)
+// ResolveSpeakerInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveSpeakerInfoClient(name string) (traits.SpeakerInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.SpeakerInfoClient), target, nil
+}
 
-	return child.DescribeVolume(ctx, request)
+func (r *InfoRouter) DescribeVolume(ctx context.Context, request *traits.DescribeVolumeRequest) (*traits.VolumeSupport, error) {
+	var res *traits.VolumeSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeVolumeRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.SpeakerInfoClient).DescribeVolume(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.TemperatureApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewTemperatureApiClient(conn)
+		}),
+	}
+}
+
 // WithTemperatureApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithTemperatureApiClientFactory(f func(name string) (traits.TemperatureApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.TemperatureApiClient)
 	return ok
 }
 
+// AddTemperatureApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.TemperatureApiClient, for example a router.Group.
 func (r *ApiRouter) AddTemperatureApiClient(name string, client traits.TemperatureApiClient) traits.TemperatureApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.TemperatureApiClient)
+	res, _ := r.Add(name, client).(traits.TemperatureApiClient)
+	return res
 }
 
+// RemoveTemperatureApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.TemperatureApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveTemperatureApiClient(name string) traits.TemperatureApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.TemperatureApiClient)
+	res, _ := r.Remove(name).(traits.TemperatureApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetTemperatureApiClient(name string) (traits.TemperatureApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetTemperatureApiClient(name string) (traits.TemperatureApiC
 	return res.(traits.TemperatureApiClient), nil
 }
 
-func (r *ApiRouter) GetTemperature(ctx context.Context, request *traits.GetTemperatureRequest) (*traits.Temperature, error) {
-	child, err := r.GetTemperatureApiClient(request.Name)
+// ResolveTemperatureApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveTemperatureApiClient(name string) (traits.TemperatureApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.GetTemperature(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.TemperatureApiClient), target, nil
 }
 
-func (r *ApiRouter) PullTemperature(request *traits.PullTemperatureRequest, server traits.TemperatureApi_PullTemperatureServer) error {
-	child, err := r.GetTemperatureApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) GetTemperature(ctx context.Context, request *traits.GetTemperatureRequest) (*traits.Temperature, error) {
+	var res *traits.Temperature
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetTemperatureRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.TemperatureApiClient).GetTemperature(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullTemperature(request *traits.PullTemperatureRequest, server traits.TemperatureApi_PullTemperatureServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullTemperature(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.TemperatureApi_PullTemperatureClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullTemperatureRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.TemperatureApiClient).PullTemperature(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -148,10 +196,16 @@ func (r *ApiRouter) PullTemperature(request *traits.PullTemperatureRequest, serv
 }
 
 func (r *ApiRouter) UpdateTemperature(ctx context.Context, request *traits.UpdateTemperatureRequest) (*traits.Temperature, error) {
-	child, err := r.GetTemperatureApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateTemperature(ctx, request)
+	var res *traits.Temperature
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateTemperatureRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.TemperatureApiClient).UpdateTemperature(ctx, request)
+		return err
+	})
+	return res, err
 }
@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.VendingApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewVendingApiClient(conn)
+		}),
+	}
+}
+
 // WithVendingApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithVendingApiClientFactory(f func(name string) (traits.VendingApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.VendingApiClient)
 	return ok
 }
 
+// AddVendingApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.VendingApiClient, for example a router.Group.
 func (r *ApiRouter) AddVendingApiClient(name string, client traits.VendingApiClient) traits.VendingApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.VendingApiClient)
+	res, _ := r.Add(name, client).(traits.VendingApiClient)
+	return res
 }
 
+// RemoveVendingApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.VendingApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveVendingApiClient(name string) traits.VendingApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.VendingApiClient)
+	res, _ := r.Remove(name).(traits.VendingApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetVendingApiClient(name string) (traits.VendingApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetVendingApiClient(name string) (traits.VendingApiClient, e
 	return res.(traits.VendingApiClient), nil
 }
 
-func (r *ApiRouter) ListConsumables(ctx context.Context, request *traits.ListConsumablesRequest) (*traits.ListConsumablesResponse, error) {
-	child, err := r.GetVendingApiClient(request.Name)
+// ResolveVendingApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveVendingApiClient(name string) (traits.VendingApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.ListConsumables(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.VendingApiClient), target, nil
 }
 
-func (r *ApiRouter) PullConsumables(request *traits.PullConsumablesRequest, server traits.VendingApi_PullConsumablesServer) error {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) ListConsumables(ctx context.Context, request *traits.ListConsumablesRequest) (*traits.ListConsumablesResponse, error) {
+	var res *traits.ListConsumablesResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListConsumablesRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.VendingApiClient).ListConsumables(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullConsumables(request *traits.PullConsumablesRequest, server traits.VendingApi_PullConsumablesServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullConsumables(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.VendingApi_PullConsumablesClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullConsumablesRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.VendingApiClient).PullConsumables(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -148,42 +196,70 @@ func (r *ApiRouter) PullConsumables(request *traits.PullConsumablesRequest, serv
 }
 
 func (r *ApiRouter) GetStock(ctx context.Context, request *traits.GetStockRequest) (*traits.Consumable_Stock, error) {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.GetStock(ctx, request)
+	var res *traits.Consumable_Stock
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.GetStockRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.VendingApiClient).GetStock(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) UpdateStock(ctx context.Context, request *traits.UpdateStockRequest) (*traits.Consumable_Stock, error) {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.UpdateStock(ctx, request)
+	var res *traits.Consumable_Stock
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.UpdateStockRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.VendingApiClient).UpdateStock(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullStock(request *traits.PullStockRequest, server traits.VendingApi_PullStockServer) error {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullStock(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.VendingApi_PullStockClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullStockRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.VendingApiClient).PullStock(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -225,33 +301,55 @@ func (r *ApiRouter) PullStock(request *traits.PullStockRequest, server traits.Ve
 }
 
 func (r *ApiRouter) ListInventory(ctx context.Context, request *traits.ListInventoryRequest) (*traits.ListInventoryResponse, error) {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.ListInventory(ctx, request)
+	var res *traits.ListInventoryResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListInventoryRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.VendingApiClient).ListInventory(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) PullInventory(request *traits.PullInventoryRequest, server traits.VendingApi_PullInventoryServer) error {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
-		return err
-	}
-
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullInventory(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.VendingApi_PullInventoryClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullInventoryRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.VendingApiClient).PullInventory(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -293,19 +391,31 @@ func (r *ApiRouter) PullInventory(request *traits.PullInventoryRequest, server t
 }
 
 func (r *ApiRouter) Dispense(ctx context.Context, request *traits.DispenseRequest) (*traits.Consumable_Stock, error) {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.Dispense(ctx, request)
+	var res *traits.Consumable_Stock
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DispenseRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.VendingApiClient).Dispense(ctx, request)
+		return err
+	})
+	return res, err
 }
 
 func (r *ApiRouter) StopDispense(ctx context.Context, request *traits.StopDispenseRequest) (*traits.Consumable_Stock, error) {
-	child, err := r.GetVendingApiClient(request.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	return child.StopDispense(ctx, request)
+	var res *traits.Consumable_Stock
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.StopDispenseRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.VendingApiClient).StopDispense(ctx, request)
+		return err
+	})
+	return res, err
 }
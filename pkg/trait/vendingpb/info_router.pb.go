@@ -25,6 +25,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.VendingInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewVendingInfoClient(conn)
+		}),
+	}
+}
+
 // WithVendingInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithVendingInfoClientFactory(f func(name string) (traits.VendingInfoClient, error)) router.Option {
@@ -46,24 +56,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.VendingInfoClient)
 	return ok
 }
 
+// AddVendingInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.VendingInfoClient, for example a router.Group.
 func (r *InfoRouter) AddVendingInfoClient(name string, client traits.VendingInfoClient) traits.VendingInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.VendingInfoClient)
+	res, _ := r.Add(name, client).(traits.VendingInfoClient)
+	return res
 }
 
+// RemoveVendingInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.VendingInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveVendingInfoClient(name string) traits.VendingInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.VendingInfoClient)
+	res, _ := r.Remove(name).(traits.VendingInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetVendingInfoClient(name string) (traits.VendingInfoClient, error) {
@@ -76,3 +87,16 @@ func (r *InfoRouter) GetVendingInfoClient(name string) (traits.VendingInfoClient
 	}
 	return res.(traits.VendingInfoClient), nil
 }
+
+// ResolveVendingInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveVendingInfoClient(name string) (traits.VendingInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
+	if err != nil {
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.VendingInfoClient), target, nil
+}
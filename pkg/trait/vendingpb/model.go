@@ -32,6 +32,14 @@ func NewModel(opts ...resource.Option) *Model {
 	}
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"inventory":   m.inventory,
+		"consumables": m.consumables,
+	}
+}
+
 // CreateConsumable creates a new consumable record.
 // If consumable.Name is specified it will be used as the key, it absent a new name will be invented.
 // If the consumables name already exists, an error will be returned.
@@ -90,6 +98,23 @@ func (m *Model) ListConsumables(opts ...resource.ReadOption) []*traits.Consumabl
 	return res
 }
 
+// ListConsumablesPage returns a page of consumables, see resource.Collection.ListPage.
+func (m *Model) ListConsumablesPage(pageSize int, pageToken string, opts ...resource.ReadOption) (*traits.ListConsumablesResponse, error) {
+	page, err := m.consumables.ListPage(pageSize, pageToken, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &traits.ListConsumablesResponse{
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int32(page.TotalSize),
+		Consumables:   make([]*traits.Consumable, len(page.Items)),
+	}
+	for i, msg := range page.Items {
+		res.Consumables[i] = msg.(*traits.Consumable)
+	}
+	return res, nil
+}
+
 type ConsumablesChange struct {
 	ID         string
 	ChangeTime time.Time
@@ -182,6 +207,23 @@ func (m *Model) ListInventory(opts ...resource.ReadOption) []*traits.Consumable_
 	return res
 }
 
+// ListInventoryPage returns a page of inventory, see resource.Collection.ListPage.
+func (m *Model) ListInventoryPage(pageSize int, pageToken string, opts ...resource.ReadOption) (*traits.ListInventoryResponse, error) {
+	page, err := m.inventory.ListPage(pageSize, pageToken, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &traits.ListInventoryResponse{
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int32(page.TotalSize),
+		Inventory:     make([]*traits.Consumable_Stock, len(page.Items)),
+	}
+	for i, msg := range page.Items {
+		res.Inventory[i] = msg.(*traits.Consumable_Stock)
+	}
+	return res, nil
+}
+
 type InventoryChange struct {
 	ID         string
 	ChangeTime time.Time
@@ -2,7 +2,6 @@ package vendingpb
 
 import (
 	"context"
-	"sort"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -10,7 +9,6 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/smart-core-os/sc-api/go/traits"
-	"github.com/smart-core-os/sc-api/go/types"
 	"github.com/smart-core-os/sc-golang/pkg/resource"
 )
 
@@ -33,46 +31,12 @@ func (m *ModelServer) Register(server grpc.ServiceRegistrar) {
 }
 
 func (m *ModelServer) ListConsumables(_ context.Context, request *traits.ListConsumablesRequest) (*traits.ListConsumablesResponse, error) {
-	pageToken := &types.PageToken{}
-	if err := decodePageToken(request.PageToken, pageToken); err != nil {
-		return nil, err
-	}
-
-	lastKey := pageToken.GetLastResourceName() // the key() of the last item we sent
-	pageSize := capPageSize(int(request.GetPageSize()))
-
-	sortedItems := m.model.ListConsumables(resource.WithReadMask(request.ReadMask))
-	nextIndex := 0
-	if lastKey != "" {
-		nextIndex = sort.Search(len(sortedItems), func(i int) bool {
-			return sortedItems[i].Name > lastKey
-		})
-	}
-
-	result := &traits.ListConsumablesResponse{
-		TotalSize: int32(len(sortedItems)),
-	}
-	upperBound := nextIndex + pageSize
-	if upperBound > len(sortedItems) {
-		upperBound = len(sortedItems)
-		pageToken = nil
-	} else {
-		pageToken.PageStart = &types.PageToken_LastResourceName{
-			LastResourceName: sortedItems[upperBound-1].Name,
-		}
-	}
-
-	var err error
-	result.NextPageToken, err = encodePageToken(pageToken)
-	if err != nil {
-		return nil, err
-	}
-	result.Consumables = sortedItems[nextIndex:upperBound]
-	return result, nil
+	return m.model.ListConsumablesPage(int(request.GetPageSize()), request.GetPageToken(), resource.WithReadMask(request.ReadMask))
 }
 
 func (m *ModelServer) PullConsumables(request *traits.PullConsumablesRequest, server traits.VendingApi_PullConsumablesServer) error {
-	for change := range m.model.PullConsumables(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullConsumables(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullConsumablesResponse{Changes: []*traits.PullConsumablesResponse_Change{
 			{Name: request.Name, Type: change.ChangeType, ChangeTime: timestamppb.New(change.ChangeTime), OldValue: change.OldValue, NewValue: change.NewValue},
 		}})
@@ -80,7 +44,7 @@ func (m *ModelServer) PullConsumables(request *traits.PullConsumablesRequest, se
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (m *ModelServer) GetStock(_ context.Context, request *traits.GetStockRequest) (*traits.Consumable_Stock, error) {
@@ -99,7 +63,8 @@ func (m *ModelServer) UpdateStock(_ context.Context, request *traits.UpdateStock
 }
 
 func (m *ModelServer) PullStock(request *traits.PullStockRequest, server traits.VendingApi_PullStockServer) error {
-	for change := range m.model.PullStock(server.Context(), request.Consumable, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullStock(server.Context(), request.Consumable, resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullStockResponse{Changes: []*traits.PullStockResponse_Change{
 			{Name: request.Name, ChangeTime: timestamppb.New(change.ChangeTime), Stock: change.Value},
 		}})
@@ -107,50 +72,16 @@ func (m *ModelServer) PullStock(request *traits.PullStockRequest, server traits.
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (m *ModelServer) ListInventory(_ context.Context, request *traits.ListInventoryRequest) (*traits.ListInventoryResponse, error) {
-	pageToken := &types.PageToken{}
-	if err := decodePageToken(request.PageToken, pageToken); err != nil {
-		return nil, err
-	}
-
-	lastKey := pageToken.GetLastResourceName() // the key() of the last item we sent
-	pageSize := capPageSize(int(request.GetPageSize()))
-
-	sortedItems := m.model.ListInventory(resource.WithReadMask(request.ReadMask))
-	nextIndex := 0
-	if lastKey != "" {
-		nextIndex = sort.Search(len(sortedItems), func(i int) bool {
-			return sortedItems[i].Consumable > lastKey
-		})
-	}
-
-	result := &traits.ListInventoryResponse{
-		TotalSize: int32(len(sortedItems)),
-	}
-	upperBound := nextIndex + pageSize
-	if upperBound > len(sortedItems) {
-		upperBound = len(sortedItems)
-		pageToken = nil
-	} else {
-		pageToken.PageStart = &types.PageToken_LastResourceName{
-			LastResourceName: sortedItems[upperBound-1].Consumable,
-		}
-	}
-
-	var err error
-	result.NextPageToken, err = encodePageToken(pageToken)
-	if err != nil {
-		return nil, err
-	}
-	result.Inventory = sortedItems[nextIndex:upperBound]
-	return result, nil
+	return m.model.ListInventoryPage(int(request.GetPageSize()), request.GetPageToken(), resource.WithReadMask(request.ReadMask))
 }
 
 func (m *ModelServer) PullInventory(request *traits.PullInventoryRequest, server traits.VendingApi_PullInventoryServer) error {
-	for change := range m.model.PullInventory(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.model.PullInventory(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		err := server.Send(&traits.PullInventoryResponse{Changes: []*traits.PullInventoryResponse_Change{
 			{Name: request.Name, Type: change.ChangeType, ChangeTime: timestamppb.New(change.ChangeTime), OldValue: change.OldValue, NewValue: change.NewValue},
 		}})
@@ -158,7 +89,7 @@ func (m *ModelServer) PullInventory(request *traits.PullInventoryRequest, server
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (m *ModelServer) Dispense(_ context.Context, request *traits.DispenseRequest) (*traits.Consumable_Stock, error) {
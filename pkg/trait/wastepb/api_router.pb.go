@@ -8,6 +8,8 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	proto "google.golang.org/protobuf/proto"
 	io "io"
 )
 
@@ -27,6 +29,16 @@ func NewApiRouter(opts ...router.Option) *ApiRouter {
 	}
 }
 
+// NewApiRouterFromServiceRouter returns a ApiRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewApiRouterFromServiceRouter(services *router.ServiceRouter) *ApiRouter {
+	return &ApiRouter{
+		Router: services.ForService(traits.WasteApi_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewWasteApiClient(conn)
+		}),
+	}
+}
+
 // WithWasteApiClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithWasteApiClientFactory(f func(name string) (traits.WasteApiClient, error)) router.Option {
@@ -48,24 +60,25 @@ func (r *ApiRouter) Add(name string, client any) any {
 }
 
 func (r *ApiRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.WasteApiClient)
 	return ok
 }
 
+// AddWasteApiClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.WasteApiClient, for example a router.Group.
 func (r *ApiRouter) AddWasteApiClient(name string, client traits.WasteApiClient) traits.WasteApiClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.WasteApiClient)
+	res, _ := r.Add(name, client).(traits.WasteApiClient)
+	return res
 }
 
+// RemoveWasteApiClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.WasteApiClient, for example a router.Group.
 func (r *ApiRouter) RemoveWasteApiClient(name string) traits.WasteApiClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.WasteApiClient)
+	res, _ := r.Remove(name).(traits.WasteApiClient)
+	return res
 }
 
 func (r *ApiRouter) GetWasteApiClient(name string) (traits.WasteApiClient, error) {
@@ -79,34 +92,69 @@ func (r *ApiRouter) GetWasteApiClient(name string) (traits.WasteApiClient, error
 	return res.(traits.WasteApiClient), nil
 }
 
-func (r *ApiRouter) ListWasteRecords(ctx context.Context, request *traits.ListWasteRecordsRequest) (*traits.ListWasteRecordsResponse, error) {
-	child, err := r.GetWasteApiClient(request.Name)
+// ResolveWasteApiClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *ApiRouter) ResolveWasteApiClient(name string) (traits.WasteApiClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
 	}
-
-	return child.ListWasteRecords(ctx, request)
+	if res == nil {
+		return nil, target, nil
+	}
+	return res.(traits.WasteApiClient), target, nil
 }
 
-func (r *ApiRouter) PullWasteRecords(request *traits.PullWasteRecordsRequest, server traits.WasteApi_PullWasteRecordsServer) error {
-	child, err := r.GetWasteApiClient(request.Name)
-	if err != nil {
+func (r *ApiRouter) ListWasteRecords(ctx context.Context, request *traits.ListWasteRecordsRequest) (*traits.ListWasteRecordsResponse, error) {
+	var res *traits.ListWasteRecordsResponse
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.ListWasteRecordsRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.WasteApiClient).ListWasteRecords(ctx, request)
 		return err
-	}
+	})
+	return res, err
+}
 
+func (r *ApiRouter) PullWasteRecords(request *traits.PullWasteRecordsRequest, server traits.WasteApi_PullWasteRecordsServer) error {
 	// so we can cancel our forwarding request if we can't send responses to our caller
 	reqCtx, reqDone := context.WithCancel(server.Context())
-	// issue the request
-	stream, err := child.PullWasteRecords(reqCtx, request)
+	defer reqDone()
+
+	// issue the request, failing over to another client until the stream starts, see router.Failover
+	var (
+		stream traits.WasteApi_PullWasteRecordsClient
+		header metadata.MD
+	)
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.PullWasteRecordsRequest)
+			request.Name = name
+		}
+		var err error
+		stream, err = client.(traits.WasteApiClient).PullWasteRecords(reqCtx, request)
+		if err != nil {
+			return err
+		}
+		header, err = stream.Header()
+		if err == nil && header == nil {
+			// the stream ended without starting, Recv returns why
+			if _, err = stream.Recv(); err == io.EOF {
+				err = nil
+			}
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	// send the stream header
-	header, err := stream.Header()
-	if err != nil {
-		return err
-	}
 	if err = server.SendHeader(header); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	traits "github.com/smart-core-os/sc-api/go/traits"
 	router "github.com/smart-core-os/sc-golang/pkg/router"
 	grpc "google.golang.org/grpc"
+	proto "google.golang.org/protobuf/proto"
 )
 
 // InfoRouter is a traits.WasteInfoServer that allows routing named requests to specific traits.WasteInfoClient
@@ -26,6 +27,16 @@ func NewInfoRouter(opts ...router.Option) *InfoRouter {
 	}
 }
 
+// NewInfoRouterFromServiceRouter returns a InfoRouter that routes requests using the connections in services.
+// Clients added to the returned router are added to services, see router.ServiceRouter.ForService.
+func NewInfoRouterFromServiceRouter(services *router.ServiceRouter) *InfoRouter {
+	return &InfoRouter{
+		Router: services.ForService(traits.WasteInfo_ServiceDesc.ServiceName, func(conn grpc.ClientConnInterface) any {
+			return traits.NewWasteInfoClient(conn)
+		}),
+	}
+}
+
 // WithWasteInfoClientFactory instructs the router to create a new
 // client the first time Get is called for that name.
 func WithWasteInfoClientFactory(f func(name string) (traits.WasteInfoClient, error)) router.Option {
@@ -47,24 +58,25 @@ func (r *InfoRouter) Add(name string, client any) any {
 }
 
 func (r *InfoRouter) HoldsType(client any) bool {
+	if router.GroupHoldsType(client, r.HoldsType) {
+		return true
+	}
 	_, ok := client.(traits.WasteInfoClient)
 	return ok
 }
 
+// AddWasteInfoClient adds client to the router, returning the old client for name.
+// Returns nil if there was no old client, or it was not a traits.WasteInfoClient, for example a router.Group.
 func (r *InfoRouter) AddWasteInfoClient(name string, client traits.WasteInfoClient) traits.WasteInfoClient {
-	res := r.Add(name, client)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.WasteInfoClient)
+	res, _ := r.Add(name, client).(traits.WasteInfoClient)
+	return res
 }
 
+// RemoveWasteInfoClient removes the client for name from the router, returning it.
+// Returns nil if there was no client, or it was not a traits.WasteInfoClient, for example a router.Group.
 func (r *InfoRouter) RemoveWasteInfoClient(name string) traits.WasteInfoClient {
-	res := r.Remove(name)
-	if res == nil {
-		return nil
-	}
-	return res.(traits.WasteInfoClient)
+	res, _ := r.Remove(name).(traits.WasteInfoClient)
+	return res
 }
 
 func (r *InfoRouter) GetWasteInfoClient(name string) (traits.WasteInfoClient, error) {
@@ -78,11 +90,30 @@ func (r *InfoRouter) GetWasteInfoClient(name string) (traits.WasteInfoClient, er
 	return res.(traits.WasteInfoClient), nil
 }
 
-func (r *InfoRouter) DescribeWasteRecord(ctx context.Context, request *traits.DescribeWasteRecordRequest) (*traits.WasteRecordSupport, error) {
-	child, err := r.GetWasteInfoClient(request.Name)
+// ResolveWasteInfoClient returns the client for name and the name requests should be forwarded with.
+// See router.Resolve.
+func (r *InfoRouter) ResolveWasteInfoClient(name string) (traits.WasteInfoClient, string, error) {
+	res, target, err := router.Resolve(r.Router, name)
 	if err != nil {
-		return nil, err
+		return nil, target, err
+	}
+	if res == nil {
+		return nil, target, nil
 	}
+	return res.(traits.WasteInfoClient), target, nil
+}
 
-	return child.DescribeWasteRecord(ctx, request)
+func (r *InfoRouter) DescribeWasteRecord(ctx context.Context, request *traits.DescribeWasteRecordRequest) (*traits.WasteRecordSupport, error) {
+	var res *traits.WasteRecordSupport
+	err := router.Failover(r.Router, request.Name, func(client any, name string) error {
+		if name != request.Name {
+			// don't modify the callers request, they may still be using it
+			request = proto.Clone(request).(*traits.DescribeWasteRecordRequest)
+			request.Name = name
+		}
+		var err error
+		res, err = client.(traits.WasteInfoClient).DescribeWasteRecord(ctx, request)
+		return err
+	})
+	return res, err
 }
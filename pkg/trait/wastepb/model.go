@@ -49,6 +49,13 @@ func NewModel(opts ...resource.Option) *Model {
 	return m
 }
 
+// Resources returns the resources of m keyed by name, see resource.ModelResources.
+func (m *Model) Resources() map[string]resource.Participant {
+	return map[string]resource.Participant{
+		"lastWasteRecord": m.lastWasteRecord,
+	}
+}
+
 // AddWasteRecord manually adds a waste record to the model
 func (m *Model) AddWasteRecord(wr *traits.WasteRecord, opts ...resource.WriteOption) (*traits.WasteRecord, error) {
 	v, err := m.lastWasteRecord.Set(wr, opts...)
@@ -123,14 +130,15 @@ func (m *Model) pullWasteRecordsWrapper(request *traits.PullWasteRecordsRequest,
 		}
 		m.mu.Unlock()
 	}
-	for change := range m.PullWasteRecords(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly)) {
+	rejected, pullErr := resource.RejectedErr()
+	for change := range m.PullWasteRecords(server.Context(), resource.WithReadMask(request.ReadMask), resource.WithUpdatesOnly(request.UpdatesOnly), rejected) {
 		msg := &traits.PullWasteRecordsResponse{}
 		msg.Changes = append(msg.Changes, change)
 		if err := server.Send(msg); err != nil {
 			return err
 		}
 	}
-	return nil
+	return pullErr()
 }
 
 func (m *Model) PullWasteRecords(ctx context.Context, opts ...resource.ReadOption) <-chan *traits.PullWasteRecordsResponse_Change {